@@ -0,0 +1,372 @@
+package chargen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Amiga diskfont errors.
+var (
+	ErrDiskFontContents = errors.New("chargen: not an Amiga font contents file")
+	ErrDiskFontHunk     = errors.New("chargen: not an Amiga font hunk file")
+	ErrDiskFontHeader   = errors.New("chargen: invalid Amiga disk font header")
+)
+
+// Amiga font contents file IDs.
+const (
+	diskFontContentsID       = 0x0f00
+	diskFontTaggedContentsID = 0x0f02
+	diskFontScalableID       = 0x0f03
+	diskFontHeaderID         = 0x0f80
+)
+
+// Amiga hunk types.
+const (
+	hunkHeader   = 0x3f3
+	hunkCode     = 0x3e9
+	hunkData     = 0x3ea
+	hunkTypeMask = 0x3fffffff
+)
+
+// Amiga font flags.
+const (
+	DiskFontRomFont      = 0x01
+	DiskFontDiskFont     = 0x02
+	DiskFontRevPath      = 0x04
+	DiskFontTallDot      = 0x08
+	DiskFontWideDot      = 0x10
+	DiskFontProportional = 0x20
+	DiskFontDesigned     = 0x40
+)
+
+// diskFontHeader starts after the "moveq #-1,d0; rts" instructions at the start
+// of the font hunk, the pointers are offsets in the hunk.
+type diskFontHeader struct {
+	Node      [14]byte
+	FileID    uint16
+	Revision  uint16
+	Segment   int32
+	Name      [32]byte
+	Message   [20]byte
+	YSize     uint16
+	Style     uint8
+	Flags     uint8
+	XSize     uint16
+	Baseline  uint16
+	BoldSmear uint16
+	Accessors uint16
+	LoChar    uint8
+	HiChar    uint8
+	CharData  uint32
+	Modulo    uint16
+	CharLoc   uint32
+	CharSpace uint32
+	CharKern  uint32
+}
+
+// FontContents is an entry in an Amiga font contents (.font) file.
+type FontContents struct {
+	// FileName of the font bitmap file, relative to the fonts directory.
+	FileName string
+
+	// YSize is the height of the font.
+	YSize int
+
+	// Style and Flags of the font.
+	Style, Flags uint8
+}
+
+// DiskFont is an Amiga disk font normalised to fixed size character cells.
+type DiskFont struct {
+	*Font
+
+	// Name of the font.
+	Name string
+
+	// Baseline is the distance from the top of the cell to the baseline.
+	Baseline int
+
+	// Origin is the horizontal position of the pen in the character cell,
+	// this is non-zero for fonts with negative kerning.
+	Origin int
+
+	// Style and Flags of the font.
+	Style, Flags uint8
+
+	// BoldSmear is the number of pixels the glyphs are smeared for bold text,
+	// see BoldSmear.
+	BoldSmear int
+
+	// Advance is the horizontal advance in pixels for each character.
+	Advance [256]int
+
+	// Kerning is the horizontal offset in pixels from the pen position to
+	// the left of the glyph for each character.
+	Kerning [256]int
+}
+
+// Proportional checks if the font is a proportional font.
+func (f *DiskFont) Proportional() bool {
+	return f.Flags&DiskFontProportional != 0
+}
+
+// ReadFontContents reads an Amiga font contents (.font) file.
+func ReadFontContents(r io.Reader) ([]FontContents, error) {
+	var header struct {
+		FileID     uint16
+		NumEntries uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+	switch header.FileID {
+	case diskFontContentsID, diskFontTaggedContentsID, diskFontScalableID:
+	default:
+		return nil, ErrDiskFontContents
+	}
+
+	contents := make([]FontContents, header.NumEntries)
+	for i := range contents {
+		var entry struct {
+			FileName [256]byte
+			YSize    uint16
+			Style    uint8
+			Flags    uint8
+		}
+		if err := binary.Read(r, binary.BigEndian, &entry); err != nil {
+			return nil, err
+		}
+		name := entry.FileName[:]
+		if header.FileID != diskFontContentsID {
+			// Tagged contents use the last two bytes for the tag count
+			name = name[:254]
+		}
+		if i := bytes.IndexByte(name, 0); i != -1 {
+			name = name[:i]
+		}
+		contents[i] = FontContents{
+			FileName: string(name),
+			YSize:    int(entry.YSize),
+			Style:    entry.Style,
+			Flags:    entry.Flags,
+		}
+	}
+	return contents, nil
+}
+
+// OpenDiskFont opens an Amiga font by the name of its contents (.font) file,
+// the font bitmap files are looked up relative to the contents file. If
+// ysize is zero, the first size in the contents file is used.
+func OpenDiskFont(name string, ysize int) (*DiskFont, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := ReadFontContents(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range contents {
+		if ysize != 0 && entry.YSize != ysize {
+			continue
+		}
+		if f, err = os.Open(filepath.Join(filepath.Dir(name), filepath.FromSlash(entry.FileName))); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ReadDiskFont(f)
+	}
+	return nil, fmt.Errorf("chargen: font %s has no size %d", name, ysize)
+}
+
+// ReadDiskFont reads an Amiga font bitmap file. The glyphs are normalised to
+// fixed size character cells; the advance and kerning of proportional fonts
+// are preserved in the metrics. Characters that are not in the font use the
+// font's default glyph. Runes are mapped using ISO 8859-1, the character set
+// used by AmigaOS.
+func ReadDiskFont(r io.Reader) (*DiskFont, error) {
+	hunk, err := readFirstHunk(r)
+	if err != nil {
+		return nil, err
+	}
+
+	const headerOffset = 4
+	var header diskFontHeader
+	if len(hunk) < headerOffset+binary.Size(header) {
+		return nil, ErrDiskFontHeader
+	}
+	binary.Read(bytes.NewReader(hunk[headerOffset:]), binary.BigEndian, &header)
+	if header.FileID != diskFontHeaderID || header.HiChar < header.LoChar || header.YSize == 0 {
+		return nil, ErrDiskFontHeader
+	}
+
+	var (
+		glyphs = int(header.HiChar-header.LoChar) + 2 // Including the default glyph
+		locs   = make([]uint32, glyphs)
+		space  = make([]int16, glyphs)
+		kern   = make([]int16, glyphs)
+	)
+	if err = readHunkTable(hunk, header.CharLoc, locs); err != nil {
+		return nil, err
+	}
+	if header.CharSpace != 0 {
+		if err = readHunkTable(hunk, header.CharSpace, space); err != nil {
+			return nil, err
+		}
+	} else {
+		for i := range space {
+			space[i] = int16(header.XSize)
+		}
+	}
+	if header.CharKern != 0 {
+		if err = readHunkTable(hunk, header.CharKern, kern); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		height = int(header.YSize)
+		modulo = int(header.Modulo)
+	)
+	if modulo == 0 || int(header.CharData)+modulo*height > len(hunk) {
+		return nil, errors.New("chargen: Amiga disk font character data out of bounds")
+	}
+	charData := hunk[header.CharData:]
+
+	// Determine the cell size
+	var left, right int
+	for i, loc := range locs {
+		var (
+			offset = int(loc >> 16)
+			width  = int(loc & 0xffff)
+		)
+		if offset+width > modulo*8 {
+			return nil, fmt.Errorf("chargen: Amiga disk font glyph %d out of bounds", i)
+		}
+		left = min(left, int(kern[i]))
+		right = max(right, max(int(kern[i])+width, int(space[i])))
+	}
+	right = max(right, int(header.XSize))
+	if right-left > maxGlyphSize || height > maxGlyphSize {
+		return nil, fmt.Errorf("chargen: Amiga disk font of %dx%d pixels is too large", right-left, height)
+	}
+
+	font := &DiskFont{
+		Name:     string(bytes.TrimRight(header.Name[:], "\x00")),
+		Baseline: int(header.Baseline),
+		Origin:   -left,
+		Style:    header.Style,
+		Flags:    header.Flags,
+	}
+	if font.BoldSmear = int(header.BoldSmear); font.BoldSmear == 0 {
+		font.BoldSmear = 1
+	}
+	var (
+		size   = image.Pt(right-left, height)
+		stride = size.X * size.Y
+		data   = make([]byte, (256*stride+7)>>3)
+	)
+	for char := 0; char < 256; char++ {
+		i := glyphs - 1
+		if char >= int(header.LoChar) && char <= int(header.HiChar) {
+			i = char - int(header.LoChar)
+		}
+		var (
+			offset = int(locs[i] >> 16)
+			width  = int(locs[i] & 0xffff)
+			x0     = int(kern[i]) - left
+		)
+		font.Advance[char] = int(space[i])
+		font.Kerning[char] = int(kern[i])
+		for y := 0; y < height; y++ {
+			row := charData[y*modulo:]
+			for x := 0; x < width; x++ {
+				src := offset + x
+				if row[src>>3]&(0x80>>uint(src&7)) == 0 {
+					continue
+				}
+				bit := char*stride + y*size.X + x0 + x
+				data[bit>>3] |= 0x80 >> uint(bit&7)
+			}
+		}
+	}
+	font.Font = New(NewBytesMask(data, MaskOptions{Size: size}))
+	font.Runes = RuneMap(charmap.ISO8859_1)
+	return font, nil
+}
+
+// readFirstHunk returns the contents of the first code or data hunk in an
+// Amiga hunk file.
+func readFirstHunk(r io.Reader) ([]byte, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		offset int
+		long   = func() (uint32, bool) {
+			if offset+4 > len(b) {
+				return 0, false
+			}
+			v := binary.BigEndian.Uint32(b[offset:])
+			offset += 4
+			return v, true
+		}
+	)
+	if v, ok := long(); !ok || v != hunkHeader {
+		return nil, ErrDiskFontHunk
+	}
+
+	// Resident library names
+	for {
+		n, ok := long()
+		if !ok {
+			return nil, ErrDiskFontHunk
+		}
+		if n == 0 {
+			break
+		}
+		offset += int(n) * 4
+	}
+
+	// Table size, first and last hunk, followed by the hunk sizes
+	long()
+	first, _ := long()
+	last, ok := long()
+	if !ok || last < first {
+		return nil, ErrDiskFontHunk
+	}
+	offset += int(last-first+1) * 4
+
+	kind, ok := long()
+	if !ok {
+		return nil, ErrDiskFontHunk
+	}
+	if kind&hunkTypeMask != hunkCode && kind&hunkTypeMask != hunkData {
+		return nil, fmt.Errorf("chargen: unexpected Amiga hunk type %#x", kind)
+	}
+	n, ok := long()
+	size := int(n&hunkTypeMask) * 4
+	if !ok || offset+size > len(b) {
+		return nil, ErrDiskFontHunk
+	}
+	return b[offset : offset+size], nil
+}
+
+// readHunkTable reads a big endian table at offset in the hunk.
+func readHunkTable(hunk []byte, offset uint32, data interface{}) error {
+	if offset == 0 || int(offset)+binary.Size(data) > len(hunk) {
+		return errors.New("chargen: Amiga disk font table out of bounds")
+	}
+	return binary.Read(bytes.NewReader(hunk[offset:]), binary.BigEndian, data)
+}
@@ -0,0 +1,190 @@
+package chargen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testDiskFont builds a proportional 5 pixel high font with the characters
+// 'A' and 'B', and a default glyph.
+func testDiskFont() []byte {
+	var (
+		locs   = []uint32{0<<16 | 3, 3<<16 | 2, 5<<16 | 1}
+		space  = []int16{4, 3, 2}
+		kern   = []int16{0, -1, 0}
+		modulo = 2
+		bitmap = []string{
+			".#.##.",
+			"#.##..",
+			"####..",
+			"#.##.#",
+			"#.##..",
+		}
+		hunk   = new(bytes.Buffer)
+		header = diskFontHeader{
+			FileID:   diskFontHeaderID,
+			YSize:    5,
+			Flags:    DiskFontDiskFont | DiskFontProportional,
+			XSize:    4,
+			Baseline: 4,
+			LoChar:   'A',
+			HiChar:   'B',
+			Modulo:   uint16(modulo),
+		}
+	)
+	copy(header.Name[:], "test.font")
+
+	// Tables follow the header
+	offset := uint32(4 + binary.Size(header))
+	header.CharData = offset
+	offset += uint32(modulo * len(bitmap))
+	header.CharLoc = offset
+	offset += uint32(4 * len(locs))
+	header.CharSpace = offset
+	offset += uint32(2 * len(space))
+	header.CharKern = offset
+
+	hunk.Write([]byte{0x70, 0xff, 0x4e, 0x75})
+	binary.Write(hunk, binary.BigEndian, header)
+	for _, row := range bitmap {
+		var v uint16
+		for x, c := range row {
+			if c == '#' {
+				v |= 0x8000 >> uint(x)
+			}
+		}
+		binary.Write(hunk, binary.BigEndian, v)
+	}
+	binary.Write(hunk, binary.BigEndian, locs)
+	binary.Write(hunk, binary.BigEndian, space)
+	binary.Write(hunk, binary.BigEndian, kern)
+	for hunk.Len()%4 != 0 {
+		hunk.WriteByte(0)
+	}
+
+	b := new(bytes.Buffer)
+	binary.Write(b, binary.BigEndian, []uint32{
+		hunkHeader, 0, 1, 0, 0, uint32(hunk.Len() / 4),
+		hunkCode, uint32(hunk.Len() / 4),
+	})
+	b.Write(hunk.Bytes())
+	binary.Write(b, binary.BigEndian, []uint32{0x3f2})
+	return b.Bytes()
+}
+
+func TestReadDiskFont(t *testing.T) {
+	font, err := ReadDiskFont(bytes.NewReader(testDiskFont()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.Name != "test.font" || !font.Proportional() || font.Baseline != 4 {
+		t.Fatalf("unexpected font header %q, proportional %t, baseline %d", font.Name, font.Proportional(), font.Baseline)
+	}
+	if font.Origin != 1 {
+		t.Fatalf("expected origin 1, got %d", font.Origin)
+	}
+	if font.Size.X != 5 || font.Size.Y != 5 {
+		t.Fatalf("expected 5x5 font, got %s", font.Size)
+	}
+	if font.Advance['A'] != 4 || font.Advance['B'] != 3 || font.Kerning['B'] != -1 {
+		t.Fatalf("unexpected metrics %d, %d, %d", font.Advance['A'], font.Advance['B'], font.Kerning['B'])
+	}
+
+	for _, test := range []struct {
+		Char uint16
+		Want []string
+	}{
+		{'A', []string{"..#..", ".#.#.", ".###.", ".#.#.", ".#.#."}},
+		{'B', []string{"##...", "#....", "#....", "#....", "#...."}},
+		{'Z', []string{".....", ".....", ".....", ".#...", "....."}}, // default glyph
+	} {
+		mask, sp := font.CharMask(test.Char)
+		for y, row := range test.Want {
+			for x, c := range row {
+				if got := isOpaque(mask.At(sp.X+x, sp.Y+y)); got != (c == '#') {
+					t.Fatalf("char %q pixel (%d,%d): expected %t", rune(test.Char), x, y, c == '#')
+				}
+			}
+		}
+	}
+
+	if _, err = ReadDiskFont(bytes.NewReader([]byte("not a font"))); err != ErrDiskFontHunk {
+		t.Fatalf("expected %v, got %v", ErrDiskFontHunk, err)
+	}
+}
+
+func TestReadDiskFontLimits(t *testing.T) {
+	const (
+		header = 32 + 4 // Hunk file header and the code before the font header
+		xsize  = header + 78
+		modulo = header + 92
+	)
+	charLoc := header + binary.Size(diskFontHeader{}) + 2*5
+	for _, test := range []struct {
+		Name   string
+		Offset int
+		Value  []byte
+	}{
+		{"modulo", modulo, []byte{0x00, 0x00}},
+		{"glyph", charLoc, []byte{0x00, 0x00, 0x00, 0x11}},
+		{"size", xsize, []byte{0x10, 0x00}},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			b := testDiskFont()
+			copy(b[test.Offset:], test.Value)
+			if _, err := ReadDiskFont(bytes.NewReader(b)); err == nil {
+				t.Fatal("expected error")
+			} else {
+				t.Logf("expected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenDiskFont(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chargen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = os.Mkdir(filepath.Join(dir, "test"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "test", "5"), testDiskFont(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	contents := new(bytes.Buffer)
+	binary.Write(contents, binary.BigEndian, []uint16{diskFontContentsID, 1})
+	var name [256]byte
+	copy(name[:], "test/5")
+	contents.Write(name[:])
+	binary.Write(contents, binary.BigEndian, []uint16{5, DiskFontDiskFont | DiskFontProportional})
+	if err = ioutil.WriteFile(filepath.Join(dir, "test.font"), contents.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ReadFontContents(bytes.NewReader(contents.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].FileName != "test/5" || entries[0].YSize != 5 {
+		t.Fatalf("unexpected contents %+v", entries)
+	}
+
+	font, err := OpenDiskFont(filepath.Join(dir, "test.font"), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.Size.Y != 5 {
+		t.Fatalf("expected font height 5, got %d", font.Size.Y)
+	}
+	if _, err = OpenDiskFont(filepath.Join(dir, "test.font"), 8); err == nil {
+		t.Fatal("expected error for missing font size")
+	}
+}
@@ -0,0 +1,333 @@
+package chargen
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// BDF errors.
+var (
+	ErrBDFHeader = errors.New("chargen: not a BDF font")
+	ErrNoGlyphs  = errors.New("chargen: font contains no glyphs")
+)
+
+// Limits of the glyphs read from font files.
+const (
+	// maxGlyphSize is the maximum width, height and offset of a glyph.
+	maxGlyphSize = 1024
+
+	// maxGlyphData is the maximum size of the mask of a font in bytes.
+	maxGlyphData = 64 << 20
+)
+
+// glyph is a single glyph read from a font file, the coordinates are relative
+// to the origin on the baseline with the Y axis pointing up.
+type glyph struct {
+	encoding int
+	bounds   image.Rectangle // Bounding box, Min is the lower left corner
+	advance  int
+	rows     [][]byte // Rows from top to bottom, each row is MSB first
+}
+
+func (g glyph) bit(x, y int) bool {
+	i := x >> 3
+	return i < len(g.rows[y]) && g.rows[y][i]&(0x80>>uint(x&7)) != 0
+}
+
+// newGlyphFont places the glyphs in a mask. If cm is not nil, the glyph
+// encodings are Unicode code points that are mapped to the code page,
+// otherwise the encoding is used as character index.
+func newGlyphFont(glyphs []glyph, cm *charmap.Charmap) (*Font, error) {
+	var (
+		characters = 256
+		index      = make(map[int]int) // character to glyph
+		runes      map[rune]uint16
+		left       int
+		right      int
+		ascent     int
+		descent    int
+	)
+	if cm == nil {
+		characters = 0
+	} else {
+		runes = RuneMap(cm)
+	}
+	for i, g := range glyphs {
+		var char int
+		if cm != nil {
+			c, ok := runes[rune(g.encoding)]
+			if !ok {
+				continue
+			}
+			char = int(c)
+		} else {
+			if g.encoding < 0 || g.encoding >= 0xffff {
+				continue
+			}
+			char = g.encoding
+			characters = max(characters, char+1)
+		}
+		if _, dupe := index[char]; dupe {
+			continue
+		}
+		index[char] = i
+		left = min(left, g.bounds.Min.X)
+		right = max(right, max(g.bounds.Max.X, g.advance))
+		ascent = max(ascent, g.bounds.Max.Y)
+		descent = max(descent, -g.bounds.Min.Y)
+	}
+	if len(index) == 0 {
+		return nil, ErrNoGlyphs
+	}
+
+	var (
+		size   = image.Pt(right-left, ascent+descent)
+		stride = size.X * size.Y
+	)
+	if int64(characters)*int64(stride) > maxGlyphData<<3 {
+		return nil, fmt.Errorf("chargen: font of %d %dx%d glyphs is too large", characters, size.X, size.Y)
+	}
+	data := make([]byte, (characters*stride+7)>>3)
+	for char, i := range index {
+		g := glyphs[i]
+		for y := range g.rows {
+			cy := ascent - g.bounds.Max.Y + y
+			for x := 0; x < g.bounds.Dx(); x++ {
+				if !g.bit(x, y) {
+					continue
+				}
+				cx := g.bounds.Min.X - left + x
+				if cx < 0 || cx >= size.X || cy < 0 || cy >= size.Y {
+					continue
+				}
+				bit := char*stride + cy*size.X + cx
+				data[bit>>3] |= 0x80 >> uint(bit&7)
+			}
+		}
+	}
+	font := New(NewBytesMask(data, MaskOptions{Size: size}))
+	font.Runes = runes
+	return font, nil
+}
+
+// ReadBDF reads a font in the X11 Bitmap Distribution Format. If cm is not
+// nil, the glyph encodings are interpreted as Unicode code points and mapped
+// to the characters of the code page, which is used for the Runes of the
+// font; glyphs that are not in the code page are skipped. If cm is nil, the
+// encoding is the character index.
+func ReadBDF(r io.Reader, cm *charmap.Charmap) (*Font, error) {
+	var (
+		s      = bufio.NewScanner(r)
+		glyphs []glyph
+		line   int
+		next   = func() ([]string, bool) {
+			for s.Scan() {
+				line++
+				if fields := strings.Fields(s.Text()); len(fields) > 0 {
+					return fields, true
+				}
+			}
+			return nil, false
+		}
+	)
+	if fields, ok := next(); !ok || fields[0] != "STARTFONT" {
+		return nil, ErrBDFHeader
+	}
+
+	for {
+		fields, ok := next()
+		if !ok {
+			if err := s.Err(); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("chargen: unexpected end of BDF font")
+		}
+		switch fields[0] {
+		case "ENDFONT":
+			return newGlyphFont(glyphs, cm)
+
+		case "STARTCHAR":
+			g := glyph{encoding: -1}
+		char:
+			for {
+				if fields, ok = next(); !ok {
+					return nil, errors.New("chargen: unexpected end of BDF glyph")
+				}
+				switch fields[0] {
+				case "ENCODING":
+					v, err := bdfInts(fields, 1, line)
+					if err != nil {
+						return nil, err
+					}
+					g.encoding = v[0]
+					if g.encoding == -1 && len(v) > 1 {
+						g.encoding = v[1]
+					}
+				case "DWIDTH":
+					v, err := bdfInts(fields, 2, line)
+					if err != nil {
+						return nil, err
+					}
+					if v[0] < -maxGlyphSize || v[0] > maxGlyphSize {
+						return nil, fmt.Errorf("chargen: invalid BDF glyph width on line %d", line)
+					}
+					g.advance = v[0]
+				case "BBX":
+					v, err := bdfInts(fields, 4, line)
+					if err != nil {
+						return nil, err
+					}
+					for i, n := range v[:4] {
+						if (i < 2 && n < 0) || n < -maxGlyphSize || n > maxGlyphSize {
+							return nil, fmt.Errorf("chargen: invalid BDF bounding box on line %d", line)
+						}
+					}
+					g.bounds = image.Rect(v[2], v[3], v[2]+v[0], v[3]+v[1])
+				case "BITMAP":
+					g.rows = make([][]byte, g.bounds.Dy())
+					for y := range g.rows {
+						if fields, ok = next(); !ok {
+							return nil, errors.New("chargen: unexpected end of BDF bitmap")
+						}
+						row := make([]byte, (g.bounds.Dx()+7)>>3)
+						hex := fields[0]
+						for i := range row {
+							if len(hex) < 2*(i+1) {
+								break
+							}
+							v, err := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+							if err != nil {
+								return nil, fmt.Errorf("chargen: invalid BDF bitmap on line %d: %v", line, err)
+							}
+							row[i] = byte(v)
+						}
+						g.rows[y] = row
+					}
+				case "ENDCHAR":
+					break char
+				}
+			}
+			if g.rows == nil {
+				g.rows = make([][]byte, g.bounds.Dy())
+				for y := range g.rows {
+					g.rows[y] = make([]byte, (g.bounds.Dx()+7)>>3)
+				}
+			}
+			if g.encoding >= 0 {
+				glyphs = append(glyphs, g)
+			}
+		}
+	}
+}
+
+func bdfInts(fields []string, n, line int) ([]int, error) {
+	if len(fields) < n+1 {
+		return nil, fmt.Errorf("chargen: expected %d values for %s on line %d", n, fields[0], line)
+	}
+	v := make([]int, len(fields)-1)
+	for i, field := range fields[1:] {
+		var err error
+		if v[i], err = strconv.Atoi(field); err != nil {
+			return nil, fmt.Errorf("chargen: invalid value for %s on line %d: %v", fields[0], line, err)
+		}
+	}
+	return v, nil
+}
+
+// WriteBDF writes the font in the X11 Bitmap Distribution Format. If cm is not
+// nil, the characters are encoded as Unicode code points using the code page,
+// otherwise the character index is used as encoding. The baseline is placed
+// at three quarters of the character height.
+func WriteBDF(w io.Writer, font *Font, name string, cm *charmap.Charmap) error {
+	if font == nil || font.Mask == nil {
+		return ErrNoGlyphs
+	}
+	var (
+		size       = font.Size
+		descent    = size.Y / 4
+		ascent     = size.Y - descent
+		characters = int(font.Mask.Characters())
+		encodings  = make([]int, characters)
+		seen       = make(map[int]bool)
+		count      int
+		registry   = "FontSpecific"
+		encoding   = "0"
+	)
+	if cm != nil {
+		registry, encoding = "ISO10646", "1"
+	}
+	var runes []rune
+	if cm != nil {
+		runes = CodePageRunes(cm)
+	}
+	for char := range encodings {
+		encodings[char] = -1
+		e := char
+		if cm != nil {
+			if char >= len(runes) || !isDefinedRune(runes[char]) {
+				continue
+			}
+			e = int(runes[char])
+		}
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		encodings[char] = e
+		count++
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "STARTFONT 2.1")
+	fmt.Fprintf(bw, "FONT %s\n", name)
+	fmt.Fprintf(bw, "SIZE %d 75 75\n", size.Y)
+	fmt.Fprintf(bw, "FONTBOUNDINGBOX %d %d 0 %d\n", size.X, size.Y, -descent)
+	fmt.Fprintln(bw, "STARTPROPERTIES 6")
+	fmt.Fprintf(bw, "FONT_ASCENT %d\n", ascent)
+	fmt.Fprintf(bw, "FONT_DESCENT %d\n", descent)
+	fmt.Fprintln(bw, `SPACING "C"`)
+	fmt.Fprintf(bw, "AVERAGE_WIDTH %d\n", size.X*10)
+	fmt.Fprintf(bw, "CHARSET_REGISTRY %q\n", registry)
+	fmt.Fprintf(bw, "CHARSET_ENCODING %q\n", encoding)
+	fmt.Fprintln(bw, "ENDPROPERTIES")
+	fmt.Fprintf(bw, "CHARS %d\n", count)
+
+	row := make([]byte, (size.X+7)>>3)
+	for char, e := range encodings {
+		if e == -1 {
+			continue
+		}
+		if cm != nil {
+			fmt.Fprintf(bw, "STARTCHAR uni%04X\n", e)
+		} else {
+			fmt.Fprintf(bw, "STARTCHAR char%d\n", e)
+		}
+		fmt.Fprintf(bw, "ENCODING %d\n", e)
+		fmt.Fprintf(bw, "SWIDTH %d 0\n", size.X*1000/size.Y)
+		fmt.Fprintf(bw, "DWIDTH %d 0\n", size.X)
+		fmt.Fprintf(bw, "BBX %d %d 0 %d\n", size.X, size.Y, -descent)
+		fmt.Fprintln(bw, "BITMAP")
+		mask, sp := font.CharMask(uint16(char))
+		for y := 0; y < size.Y; y++ {
+			for i := range row {
+				row[i] = 0
+			}
+			for x := 0; x < size.X; x++ {
+				if isOpaque(mask.At(sp.X+x, sp.Y+y)) {
+					row[x>>3] |= 0x80 >> uint(x&7)
+				}
+			}
+			fmt.Fprintf(bw, "%X\n", row)
+		}
+		fmt.Fprintln(bw, "ENDCHAR")
+	}
+	fmt.Fprintln(bw, "ENDFONT")
+	return bw.Flush()
+}
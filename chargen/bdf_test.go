@@ -0,0 +1,183 @@
+package chargen
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/textmodes/parser/data"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func testEqualFonts(t *testing.T, a, b *Font, chars int) {
+	t.Helper()
+	if a.Size != b.Size {
+		t.Fatalf("expected %s font, got %s", a.Size, b.Size)
+	}
+	for c := 0; c < chars; c++ {
+		am, ap := a.CharMask(uint16(c))
+		bm, bp := b.CharMask(uint16(c))
+		for y := 0; y < a.Size.Y; y++ {
+			for x := 0; x < a.Size.X; x++ {
+				if isOpaque(am.At(ap.X+x, ap.Y+y)) != isOpaque(bm.At(bp.X+x, bp.Y+y)) {
+					t.Fatalf("char %d differs at (%d,%d)", c, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestBDFRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Size image.Point
+		Map  *charmap.Charmap
+	}{
+		{"ibm_vga_437.bin", image.Pt(8, 16), charmap.CodePage437},
+		{"ibm_vga50_437.bin", image.Pt(8, 8), nil},
+		{"amiga_topaz_1.bin", image.Pt(8, 16), charmap.ISO8859_1},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			b, err := data.Bytes("font/chargen/" + test.Name)
+			if err != nil {
+				t.Skip(err)
+			}
+			var (
+				font = New(NewBytesMask(b, MaskOptions{Size: test.Size}))
+				o    = new(bytes.Buffer)
+			)
+			if err = WriteBDF(o, font, test.Name, test.Map); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(o.String(), "STARTFONT 2.1\n") {
+				t.Fatal("expected BDF header")
+			}
+			read, err := ReadBDF(o, test.Map)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testEqualFonts(t, font, read, 256)
+		})
+	}
+}
+
+func TestWriteBDFCodePage(t *testing.T) {
+	// Characters 0x01 and 0x7f are solid, the rest is blank
+	rom := make([]byte, 256*8)
+	for y := 0; y < 8; y++ {
+		rom[0x01*8+y] = 0xff
+		rom[0x7f*8+y] = 0xff
+	}
+	var (
+		font = New(NewBytesMask(rom, MaskOptions{Size: image.Pt(8, 8)}))
+		o    = new(bytes.Buffer)
+	)
+	if err := WriteBDF(o, font, "test", charmap.CodePage437); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"ENCODING 9786\n", "ENCODING 8962\n"} {
+		if !strings.Contains(o.String(), want) {
+			t.Fatalf("expected %q in BDF output", want)
+		}
+	}
+
+	read, err := ReadBDF(o, charmap.CodePage437)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqualFonts(t, font, read, 256)
+}
+
+func TestReadBDF(t *testing.T) {
+	const bdf = `STARTFONT 2.1
+FONT -test-fixed-medium-r-normal--6-60-75-75-c-40-iso10646-1
+SIZE 6 75 75
+FONTBOUNDINGBOX 4 6 0 -1
+CHARS 3
+STARTCHAR A
+ENCODING 65
+DWIDTH 4 0
+BBX 3 5 0 0
+BITMAP
+40
+A0
+E0
+A0
+A0
+ENDCHAR
+STARTCHAR comma
+ENCODING 44
+DWIDTH 4 0
+BBX 1 2 1 -1
+BITMAP
+80
+80
+ENDCHAR
+STARTCHAR uni00C9
+ENCODING 201
+DWIDTH 4 0
+BBX 3 6 0 0
+BITMAP
+20
+E0
+80
+C0
+80
+E0
+ENDCHAR
+ENDFONT
+`
+	font, err := ReadBDF(strings.NewReader(bdf), charmap.CodePage437)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.Size != image.Pt(4, 7) {
+		t.Fatalf("expected 4x7 font, got %s", font.Size)
+	}
+	for _, test := range []struct {
+		Char uint16
+		Want []string
+	}{
+		{'A', []string{"....", ".#..", "#.#.", "###.", "#.#.", "#.#.", "...."}},
+		{',', []string{"....", "....", "....", "....", "....", ".#..", ".#.."}},
+		{0x90, []string{"..#.", "###.", "#...", "##..", "#...", "###.", "...."}}, // É in CP437
+	} {
+		mask, sp := font.CharMask(test.Char)
+		for y, row := range test.Want {
+			for x, c := range row {
+				if got := isOpaque(mask.At(sp.X+x, sp.Y+y)); got != (c == '#') {
+					t.Fatalf("char %#02x pixel (%d,%d): expected %t", test.Char, x, y, c == '#')
+				}
+			}
+		}
+	}
+
+	if _, err = ReadBDF(strings.NewReader("STARTFONT 2.1\nENDFONT\n"), nil); err != ErrNoGlyphs {
+		t.Fatalf("expected %v, got %v", ErrNoGlyphs, err)
+	}
+	if _, err = ReadBDF(strings.NewReader("not a font"), nil); err != ErrBDFHeader {
+		t.Fatalf("expected %v, got %v", ErrBDFHeader, err)
+	}
+}
+
+func TestReadBDFLimits(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Char string
+	}{
+		{"width", "ENCODING 65\nBBX 100000 100000 0 0\n"},
+		{"offset", "ENCODING 65\nBBX 8 16 0 -100000\n"},
+		{"advance", "ENCODING 65\nDWIDTH 100000 0\nBBX 8 16 0 0\n"},
+		{"data", "ENCODING 65534\nBBX 1024 1024 0 0\n"},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			bdf := "STARTFONT 2.1\nSTARTCHAR test\n" + test.Char + "ENDCHAR\nENDFONT\n"
+			if _, err := ReadBDF(strings.NewReader(bdf), nil); err == nil {
+				t.Fatal("expected error")
+			} else {
+				t.Logf("expected error: %v", err)
+			}
+		})
+	}
+}
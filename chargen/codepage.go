@@ -0,0 +1,76 @@
+package chargen
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ibmGraphics are the symbols displayed by the IBM PC ROM fonts for the
+// control characters 0x00-0x1f.
+var ibmGraphics = [32]rune{
+	0x0000, 0x263a, 0x263b, 0x2665, 0x2666, 0x2663, 0x2660, 0x2022,
+	0x25d8, 0x25cb, 0x25d9, 0x2642, 0x2640, 0x266a, 0x266b, 0x263c,
+	0x25ba, 0x25c4, 0x2195, 0x203c, 0x00b6, 0x00a7, 0x25ac, 0x21a8,
+	0x2191, 0x2193, 0x2192, 0x2190, 0x221f, 0x2194, 0x25b2, 0x25bc,
+}
+
+// ibmCodePages are the code pages used by the IBM PC ROM fonts.
+var ibmCodePages = map[*charmap.Charmap]bool{
+	charmap.CodePage437: true,
+	charmap.CodePage850: true,
+	charmap.CodePage852: true,
+	charmap.CodePage855: true,
+	charmap.CodePage858: true,
+	charmap.CodePage860: true,
+	charmap.CodePage862: true,
+	charmap.CodePage863: true,
+	charmap.CodePage865: true,
+	charmap.CodePage866: true,
+}
+
+// CodePageRunes returns the rune for each of the 256 characters in the code
+// page, characters that are not defined map to utf8.RuneError. For the IBM PC
+// code pages, the control characters map to the symbols displayed by the PC
+// ROM fonts.
+func CodePageRunes(cm *charmap.Charmap) []rune {
+	runes := make([]rune, 256)
+	for i := range runes {
+		runes[i] = cm.DecodeByte(byte(i))
+	}
+	if ibmCodePages[cm] {
+		copy(runes[1:], ibmGraphics[1:])
+		runes[0x7f] = 0x2302
+	}
+	return runes
+}
+
+// isDefinedRune checks if r is a defined rune in a code page.
+func isDefinedRune(r rune) bool {
+	return r != utf8.RuneError
+}
+
+// atasciiRunes are the runes for the first 128 ATASCII characters, the other
+// 128 characters are the same in inverse video.
+var atasciiRunes = [128]rune{
+	0x2665, 0x2523, 0x2503, 0x251b, 0x252b, 0x2513, 0x2571, 0x2572,
+	0x25e2, 0x2597, 0x25e3, 0x259d, 0x2598, 0x1fb82, 0x2582, 0x2596,
+	0x2663, 0x250f, 0x2501, 0x254b, 0x25cf, 0x2584, 0x258e, 0x2533,
+	0x253b, 0x258c, 0x2517, 0x241b, 0x2191, 0x2193, 0x2190, 0x2192,
+	' ', '!', '"', '#', '$', '%', '&', '\'', '(', ')', '*', '+', ',', '-', '.', '/',
+	'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', ':', ';', '<', '=', '>', '?',
+	'@', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O',
+	'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '\\', ']', '^', '_',
+	0x2666, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o',
+	'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', 0x2660, '|', 0x21b0, 0x25c0, 0x25b6,
+}
+
+// ATASCIIRunes returns the rune for each of the 256 characters of the Atari 8-bit
+// character set, the inverse video characters map to the same runes as their
+// normal video counterparts.
+func ATASCIIRunes() []rune {
+	runes := make([]rune, 256)
+	copy(runes, atasciiRunes[:])
+	copy(runes[128:], atasciiRunes[:])
+	return runes
+}
@@ -262,16 +262,25 @@ func RoundCharacters(mask Mask) Mask {
 
 type columnAdder struct {
 	Mask
-	size   image.Point
-	bounds image.Rectangle
+	size         image.Point
+	bounds       image.Rectangle
+	lineGraphics bool
 }
 
 func (filter columnAdder) At(x, y int) color.Color {
-	if x%filter.size.X == filter.size.X-1 {
+	char, cx, cy, ok := charPoint(filter.size, x, y)
+	if !ok {
 		return Transparent
 	}
-	x = (x * filter.size.X) / (filter.size.X - 1)
-	return filter.Mask.At(x, y)
+	last := filter.size.X - 1
+	if cx == last {
+		if code := char & 0xff; !filter.lineGraphics || code < 0xc0 || code > 0xdf {
+			return Transparent
+		}
+		// Line graphics characters repeat the last column
+		cx--
+	}
+	return filter.Mask.At(char*last+cx, cy)
 }
 
 func (filter columnAdder) Bounds() image.Rectangle {
@@ -282,8 +291,38 @@ func (filter columnAdder) CharacterSize() image.Point {
 	return filter.size
 }
 
+func (filter columnAdder) SubMask(r image.Rectangle) Mask {
+	if r = r.Intersect(filter.bounds); r.Empty() {
+		return nil
+	}
+	filter.bounds = r
+	return filter
+}
+
 // AddColumn adds a blank column to the right hand side of each character.
 func AddColumn(mask Mask) Mask {
+	return addColumn(mask, false)
+}
+
+// AddLineGraphicsColumn adds a column to the right hand side of each
+// character, like VGA hardware does in 9 dot text modes. The column is blank,
+// except for the line graphics characters 0xC0 to 0xDF (in both halves of 512
+// character fonts), which repeat their last column so box drawing characters
+// connect.
+/*
+
+ 01234567      012345678
+0___##___  →  0___##____
+1___##___  →  1___##____
+2___#####  →  2___######
+3___##___  →  3___##____
+
+*/
+func AddLineGraphicsColumn(mask Mask) Mask {
+	return addColumn(mask, true)
+}
+
+func addColumn(mask Mask, lineGraphics bool) Mask {
 	var (
 		size   = mask.CharacterSize()
 		chars  = mask.Characters()
@@ -291,9 +330,259 @@ func AddColumn(mask Mask) Mask {
 	)
 	size.X++
 	bounds.Max.X += int(chars)
-	return &columnAdder{
+	return columnAdder{
+		Mask:         mask,
+		size:         size,
+		bounds:       bounds,
+		lineGraphics: lineGraphics,
+	}
+}
+
+// charOpaque checks if pixel (x, y) of char in the mask is opaque, where the
+// coordinates are relative to the character cell of the given size. Pixels
+// outside of the character cell are transparent.
+func charOpaque(mask Mask, size image.Point, char, x, y int) bool {
+	if x < 0 || y < 0 || x >= size.X || y >= size.Y {
+		return false
+	}
+	return isOpaque(mask.At(char*size.X+x, y))
+}
+
+// charPoint splits mask coordinates in a character and the coordinates in the
+// character cell, ok is false if the coordinates are outside of the mask.
+func charPoint(size image.Point, x, y int) (char, cx, cy int, ok bool) {
+	if x < 0 || y < 0 || y >= size.Y {
+		return 0, 0, 0, false
+	}
+	return x / size.X, x % size.X, y, true
+}
+
+type filterBold struct {
+	Mask
+	size   image.Point
+	bounds image.Rectangle
+	smear  int
+}
+
+func (filter filterBold) At(x, y int) color.Color {
+	char, cx, cy, ok := charPoint(filter.size, x, y)
+	if !ok {
+		return Transparent
+	}
+	for i := 0; i <= filter.smear; i++ {
+		if charOpaque(filter.Mask, filter.size, char, cx-i, cy) {
+			return Opaque
+		}
+	}
+	return Transparent
+}
+
+func (filter filterBold) Bounds() image.Rectangle {
+	return filter.bounds
+}
+
+func (filter filterBold) SubMask(r image.Rectangle) Mask {
+	if r = r.Intersect(filter.bounds); r.Empty() {
+		return nil
+	}
+	filter.bounds = r
+	return filter
+}
+
+// Bold smears the characters one pixel to the right, like AmigaOS renders
+// bold text.
+/*
+
+ 01234567      01234567
+0________  →  0________
+1__##____  →  1__###___
+2_####___  →  2_#####__
+3##__##__  →  3###_###_
+4##__##__  →  4###_###_
+5######__  →  5#######_
+6##__##__  →  6###_###_
+7________  →  7________
+
+*/
+func Bold(mask Mask) Mask {
+	return BoldSmear(mask, 1)
+}
+
+// BoldSmear smears the characters smear pixels to the right. Pixels smeared
+// beyond the character cell are lost.
+func BoldSmear(mask Mask, smear int) Mask {
+	return filterBold{
 		Mask:   mask,
-		size:   size,
-		bounds: bounds,
+		size:   mask.CharacterSize(),
+		bounds: mask.Bounds(),
+		smear:  smear,
+	}
+}
+
+type filterOutline struct {
+	Mask
+	size   image.Point
+	bounds image.Rectangle
+}
+
+func (filter filterOutline) At(x, y int) color.Color {
+	char, cx, cy, ok := charPoint(filter.size, x, y)
+	if !ok || charOpaque(filter.Mask, filter.size, char, cx, cy) {
+		return Transparent
+	}
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if charOpaque(filter.Mask, filter.size, char, cx+dx, cy+dy) {
+				return Opaque
+			}
+		}
+	}
+	return Transparent
+}
+
+func (filter filterOutline) Bounds() image.Rectangle {
+	return filter.bounds
+}
+
+func (filter filterOutline) SubMask(r image.Rectangle) Mask {
+	if r = r.Intersect(filter.bounds); r.Empty() {
+		return nil
+	}
+	filter.bounds = r
+	return filter
+}
+
+// Outline replaces the characters by their outline; all transparent pixels
+// surrounding an opaque pixel become opaque and the opaque pixels become
+// transparent.
+/*
+
+ 01234567      01234567
+0________  →  0_####___
+1__##____  →  1##__##__
+2_####___  →  2#____##_
+3##__##__  →  3__##__#_
+4##__##__  →  4__##__#_
+5######__  →  5______#_
+6##__##__  →  6__##__#_
+7________  →  7#######_
+
+*/
+func Outline(mask Mask) Mask {
+	return filterOutline{
+		Mask:   mask,
+		size:   mask.CharacterSize(),
+		bounds: mask.Bounds(),
+	}
+}
+
+type filterShadow struct {
+	Mask
+	size   image.Point
+	bounds image.Rectangle
+	offset image.Point
+}
+
+func (filter filterShadow) At(x, y int) color.Color {
+	char, cx, cy, ok := charPoint(filter.size, x, y)
+	if !ok {
+		return Transparent
+	}
+	if charOpaque(filter.Mask, filter.size, char, cx, cy) ||
+		charOpaque(filter.Mask, filter.size, char, cx-filter.offset.X, cy-filter.offset.Y) {
+		return Opaque
+	}
+	return Transparent
+}
+
+func (filter filterShadow) Bounds() image.Rectangle {
+	return filter.bounds
+}
+
+func (filter filterShadow) SubMask(r image.Rectangle) Mask {
+	if r = r.Intersect(filter.bounds); r.Empty() {
+		return nil
+	}
+	filter.bounds = r
+	return filter
+}
+
+// Shadow adds a drop shadow to the characters, the shadow is the character
+// moved by offset. Shadow pixels beyond the character cell are lost.
+/*
+
+Shadow with an offset of (1, 1):
+
+ 01234567      01234567
+0________  →  0________
+1__##____  →  1__##____
+2_####___  →  2_####___
+3##__##__  →  3######__
+4##__##__  →  4###_###_
+5######__  →  5#######_
+6##__##__  →  6#######_
+7________  →  7_##__##_
+
+*/
+func Shadow(mask Mask, offset image.Point) Mask {
+	return filterShadow{
+		Mask:   mask,
+		size:   mask.CharacterSize(),
+		bounds: mask.Bounds(),
+		offset: offset,
+	}
+}
+
+type filterFlip struct {
+	Mask
+	size       image.Point
+	bounds     image.Rectangle
+	horizontal bool
+}
+
+func (filter filterFlip) At(x, y int) color.Color {
+	char, cx, cy, ok := charPoint(filter.size, x, y)
+	if !ok {
+		return Transparent
+	}
+	if filter.horizontal {
+		cx = filter.size.X - 1 - cx
+	} else {
+		cy = filter.size.Y - 1 - cy
+	}
+	if charOpaque(filter.Mask, filter.size, char, cx, cy) {
+		return Opaque
+	}
+	return Transparent
+}
+
+func (filter filterFlip) Bounds() image.Rectangle {
+	return filter.bounds
+}
+
+func (filter filterFlip) SubMask(r image.Rectangle) Mask {
+	if r = r.Intersect(filter.bounds); r.Empty() {
+		return nil
+	}
+	filter.bounds = r
+	return filter
+}
+
+// FlipHorizontal mirrors each character left to right.
+func FlipHorizontal(mask Mask) Mask {
+	return filterFlip{
+		Mask:       mask,
+		size:       mask.CharacterSize(),
+		bounds:     mask.Bounds(),
+		horizontal: true,
+	}
+}
+
+// FlipVertical mirrors each character top to bottom.
+func FlipVertical(mask Mask) Mask {
+	return filterFlip{
+		Mask:   mask,
+		size:   mask.CharacterSize(),
+		bounds: mask.Bounds(),
 	}
 }
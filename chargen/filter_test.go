@@ -306,3 +306,138 @@ func testMaskDump(t *testing.T, mask Mask, bounds image.Rectangle) {
 		t.Log(string(row))
 	}
 }
+
+// testMaskRows returns a single character mask from rows of '#' and '_'.
+func testMaskRows(rows ...string) Mask {
+	var (
+		size = image.Pt(len(rows[0]), len(rows))
+		data = make([]byte, (size.X*size.Y+7)>>3)
+	)
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '#' {
+				bit := y*size.X + x
+				data[bit>>3] |= 0x80 >> uint(bit&7)
+			}
+		}
+	}
+	return NewBytesMask(data, MaskOptions{Size: size})
+}
+
+func testFilterGlyph() Mask {
+	return testMaskRows(
+		"________",
+		"__##____",
+		"_####___",
+		"##__##__",
+		"##__##__",
+		"######__",
+		"##__##__",
+		"________",
+	)
+}
+
+func TestFilterStyles(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Mask Mask
+		Want Mask
+	}{
+		{"bold", Bold(testFilterGlyph()), testMaskRows(
+			"________",
+			"__###___",
+			"_#####__",
+			"###_###_",
+			"###_###_",
+			"#######_",
+			"###_###_",
+			"________",
+		)},
+		{"outline", Outline(testFilterGlyph()), testMaskRows(
+			"_####___",
+			"##__##__",
+			"#____##_",
+			"__##__#_",
+			"__##__#_",
+			"______#_",
+			"__##__#_",
+			"#######_",
+		)},
+		{"shadow", Shadow(testFilterGlyph(), image.Pt(1, 1)), testMaskRows(
+			"________",
+			"__##____",
+			"_####___",
+			"######__",
+			"###_###_",
+			"#######_",
+			"#######_",
+			"_##__##_",
+		)},
+		{"flip horizontal", FlipHorizontal(testFilterGlyph()), testMaskRows(
+			"________",
+			"____##__",
+			"___####_",
+			"__##__##",
+			"__##__##",
+			"__######",
+			"__##__##",
+			"________",
+		)},
+		{"flip vertical", FlipVertical(testFilterGlyph()), testMaskRows(
+			"________",
+			"##__##__",
+			"######__",
+			"##__##__",
+			"##__##__",
+			"_####___",
+			"__##____",
+			"________",
+		)},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if size := test.Mask.CharacterSize(); size != image.Pt(8, 8) {
+				t.Fatalf("expected size of (8, 8) got %s", size)
+			}
+			testMaskEqual(t, test.Mask, test.Want)
+			testMaskEqual(t, New(test.Mask).Mask.SubMask(image.Rect(0, 0, 8, 8)), test.Want)
+		})
+	}
+}
+
+func TestFilterAddLineGraphicsColumn(t *testing.T) {
+	// 512 characters of 2x1 pixels, all opaque
+	data := make([]byte, 512*2/8)
+	for i := range data {
+		data[i] = 0xff
+	}
+	mask := NewBytesMask(data, MaskOptions{Size: image.Pt(2, 1)})
+	font := New(AddLineGraphicsColumn(mask))
+	if font.Size != image.Pt(3, 1) {
+		t.Fatalf("expected size of (3, 1) got %s", font.Size)
+	}
+	for _, test := range []struct {
+		Char uint16
+		Want bool
+	}{
+		{0x41, false},
+		{0xbf, false},
+		{0xc0, true},
+		{0xc4, true},
+		{0xdf, true},
+		{0xe0, false},
+		{0x1bf, false},
+		{0x1c0, true},
+		{0x1e0, false},
+	} {
+		sub, sp := font.CharMask(test.Char)
+		if !isOpaque(sub.At(sp.X, sp.Y)) {
+			t.Errorf("char %#02x: expected first column to be opaque", test.Char)
+		}
+		if got := isOpaque(sub.At(sp.X+2, sp.Y)); got != test.Want {
+			t.Errorf("char %#02x: expected ninth column opaque %t, got %t", test.Char, test.Want, got)
+		}
+		if r := sub.Bounds(); r != image.Rect(sp.X, 0, sp.X+3, 1) {
+			t.Errorf("char %#02x: unexpected sub mask bounds %s", test.Char, r)
+		}
+	}
+}
@@ -4,10 +4,20 @@ Package chargen implements bitmapped monospaced "character generator" fonts.
 package chargen
 
 import (
+	"errors"
 	"image"
 	"image/draw"
+
+	"golang.org/x/text/encoding/charmap"
 )
 
+// ErrFontWidth is returned by Bytes for fonts wider than 8 pixels.
+var ErrFontWidth = errors.New("chargen: font is wider than 8 pixels")
+
+// DefaultFallback is the rune drawn for runes that are not in the font, if no
+// fallback is configured.
+const DefaultFallback = '?'
+
 // Font can draw characters from a Mask.
 type Font struct {
 	// Mask of the font.
@@ -15,6 +25,14 @@ type Font struct {
 
 	// Size of each character in the mask.
 	Size image.Point
+
+	// Runes maps runes to characters in the mask, used when drawing Unicode
+	// text. If nil, runes are used as character index.
+	Runes map[rune]uint16
+
+	// Fallback is the rune drawn for runes that are not in the font, if left
+	// empty DefaultFallback is used.
+	Fallback rune
 }
 
 // New Font from an image, where the width of the font must be specified and
@@ -40,6 +58,33 @@ func (font Font) CharMask(char uint16) (Mask, image.Point) {
 	return mask, rect.Min
 }
 
+// Bytes returns the bitmap of the font with one byte per character row, most
+// significant bit first, as used by VGA fonts and NewBytesMask.
+func (font Font) Bytes() ([]byte, error) {
+	if font.Mask == nil || font.Mask.Characters() == 0 {
+		return nil, ErrNoGlyphs
+	}
+	if font.Size.X > 8 {
+		return nil, ErrFontWidth
+	}
+	var (
+		characters = font.Mask.Characters()
+		data       = make([]byte, int(characters)*font.Size.Y)
+	)
+	for char := uint16(0); char < characters; char++ {
+		mask, sp := font.CharMask(char)
+		for y := 0; y < font.Size.Y; y++ {
+			row := &data[int(char)*font.Size.Y+y]
+			for x := 0; x < font.Size.X; x++ {
+				if isOpaque(mask.At(sp.X+x, sp.Y+y)) {
+					*row |= 0x80 >> uint(x)
+				}
+			}
+		}
+	}
+	return data, nil
+}
+
 // Draw a character from the font onto dst with mask applied to src.
 func (font Font) Draw(dst draw.Image, p image.Point, src image.Image, char uint16) {
 	if char >= font.Mask.Characters() {
@@ -65,3 +110,58 @@ func (font Font) DrawString(dst draw.Image, p image.Point, src image.Image, s st
 		p.X += int(font.Size.X)
 	}
 }
+
+// RuneMap returns a mapping of runes to characters for a font with characters
+// in the code page.
+func RuneMap(cm *charmap.Charmap) map[rune]uint16 {
+	runes := make(map[rune]uint16, 256)
+	for char, r := range CodePageRunes(cm) {
+		if _, dupe := runes[r]; !dupe && isDefinedRune(r) {
+			runes[r] = uint16(char)
+		}
+	}
+	return runes
+}
+
+// Glyph returns the character for rune r, ok is false if the font has no
+// character for r.
+func (font Font) Glyph(r rune) (char uint16, ok bool) {
+	if font.Runes != nil {
+		char, ok = font.Runes[r]
+		return char, ok && char < font.Mask.Characters()
+	}
+	if r < 0 || r >= rune(font.Mask.Characters()) {
+		return 0, false
+	}
+	return uint16(r), true
+}
+
+// fallback returns the character drawn for runes that are not in the font.
+func (font Font) fallback() (uint16, bool) {
+	if font.Fallback == 0 {
+		return font.Glyph(DefaultFallback)
+	}
+	return font.Glyph(font.Fallback)
+}
+
+// DrawRunes draws runes using characters from the font onto dst with mask
+// applied to src. Runes are mapped to characters using the Runes map of the
+// font; runes that are not in the font are drawn using the fallback rune.
+func (font Font) DrawRunes(dst draw.Image, p image.Point, src image.Image, runes []rune) {
+	for _, r := range runes {
+		char, ok := font.Glyph(r)
+		if !ok {
+			char, ok = font.fallback()
+		}
+		if ok {
+			font.Draw(dst, p, src, char)
+		}
+		p.X += int(font.Size.X)
+	}
+}
+
+// DrawUnicodeString draws an UTF-8 encoded string using characters from the
+// font onto dst with mask applied to src, see DrawRunes.
+func (font Font) DrawUnicodeString(dst draw.Image, p image.Point, src image.Image, s string) {
+	font.DrawRunes(dst, p, src, []rune(s))
+}
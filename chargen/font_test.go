@@ -10,6 +10,9 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+
+	"github.com/textmodes/parser/data"
+	"golang.org/x/text/encoding/charmap"
 )
 
 func TestFont(t *testing.T) {
@@ -70,3 +73,71 @@ func testFont(t *testing.T, mask Mask, size image.Point, name string) {
 		}
 	}
 }
+
+func TestFontRunes(t *testing.T) {
+	rom, err := data.Bytes("font/chargen/ibm_vga50_437.bin")
+	if err != nil {
+		t.Skip(err)
+	}
+	font := New(NewBytesMask(rom, MaskOptions{Size: image.Pt(8, 8)}))
+	if char, ok := font.Glyph('A'); !ok || char != 'A' {
+		t.Fatalf("expected identity mapping without runes, got %d", char)
+	}
+	if _, ok := font.Glyph(0x2500); ok {
+		t.Fatal("expected rune outside of the font to be missing")
+	}
+
+	font.Runes = RuneMap(charmap.CodePage437)
+	for r, want := range map[rune]uint16{'A': 0x41, '☺': 0x01, 'é': 0x82, '█': 0xdb, '⌂': 0x7f} {
+		if char, ok := font.Glyph(r); !ok || char != want {
+			t.Errorf("rune %q: expected %#02x, got %#02x", r, want, char)
+		}
+	}
+
+	var (
+		size = image.Rect(0, 0, 8*3, 8)
+		bg   = image.NewUniform(color.Black)
+		fg   = image.NewUniform(color.White)
+		a    = image.NewRGBA(size)
+		b    = image.NewRGBA(size)
+	)
+	draw.Draw(a, size, bg, image.ZP, draw.Src)
+	draw.Draw(b, size, bg, image.ZP, draw.Src)
+	font.DrawUnicodeString(a, image.ZP, fg, "é☺€")
+	font.DrawString(b, image.ZP, fg, "\x82\x01?")
+	if !bytes.Equal(a.Pix, b.Pix) {
+		t.Fatal("expected Unicode string to render as code page 437 with fallback")
+	}
+
+	font.Fallback = '☺'
+	draw.Draw(a, size, bg, image.ZP, draw.Src)
+	draw.Draw(b, size, bg, image.ZP, draw.Src)
+	font.DrawRunes(a, image.ZP, fg, []rune{'€'})
+	font.Draw(b, image.ZP, fg, 0x01)
+	if !bytes.Equal(a.Pix, b.Pix) {
+		t.Fatal("expected custom fallback rune")
+	}
+}
+
+func TestFontBytes(t *testing.T) {
+	rom, err := data.Bytes("font/chargen/ibm_vga50_437.bin")
+	if err != nil {
+		t.Skip(err)
+	}
+	font := New(NewBytesMask(rom, MaskOptions{Size: image.Pt(8, 8)}))
+	b, err := font.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, rom) {
+		t.Fatal("expected bitmap to match the font ROM")
+	}
+
+	font = New(NewBytesMask(rom, MaskOptions{Size: image.Pt(16, 8)}))
+	if _, err = font.Bytes(); err != ErrFontWidth {
+		t.Fatalf("expected %v, got %v", ErrFontWidth, err)
+	}
+	if _, err = new(Font).Bytes(); err != ErrNoGlyphs {
+		t.Fatalf("expected %v, got %v", ErrNoGlyphs, err)
+	}
+}
@@ -290,3 +290,40 @@ func (mask *bitmap) SubMask(r image.Rectangle) Mask {
 }
 
 var _ image.Image = (*bitmap)(nil)
+
+// newRowMask returns a mask from glyph data where each row of a glyph is
+// padded to rowBytes bytes, as is common in font files. The glyphs are packed
+// in the layout used by the bitmap mask.
+func newRowMask(data []byte, characters int, size image.Point, rowBytes int) Mask {
+	var (
+		glyphBytes = rowBytes * size.Y
+		packed     = make([]byte, (characters*size.X*size.Y+7)>>3)
+		bits       uint
+	)
+	for c := 0; c < characters; c++ {
+		for y := 0; y < size.Y; y++ {
+			row := c*glyphBytes + y*rowBytes
+			for x := 0; x < size.X; x++ {
+				if i := row + x>>3; i < len(data) && data[i]&(0x80>>uint(x&7)) != 0 {
+					packed[bits>>3] |= 0x80 >> (bits & 7)
+				}
+				bits++
+			}
+		}
+	}
+	return NewBytesMask(packed, MaskOptions{Size: size})
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
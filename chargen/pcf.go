@@ -0,0 +1,335 @@
+package chargen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// PCF errors.
+var (
+	ErrPCFHeader = errors.New("chargen: not a PCF font")
+	ErrPCFTable  = errors.New("chargen: missing PCF table")
+)
+
+var pcfMagic = []byte("\x01fcp")
+
+// PCF table types.
+const (
+	pcfProperties      = 1 << 0
+	pcfAccelerators    = 1 << 1
+	pcfMetrics         = 1 << 2
+	pcfBitmaps         = 1 << 3
+	pcfInkMetrics      = 1 << 4
+	pcfBDFEncodings    = 1 << 5
+	pcfSWidths         = 1 << 6
+	pcfGlyphNames      = 1 << 7
+	pcfBDFAccelerators = 1 << 8
+)
+
+// PCF table formats.
+const (
+	pcfCompressedMetrics = 0x00000100
+	pcfGlyphPadMask      = 3 << 0
+	pcfByteMask          = 1 << 2 // Most significant byte first
+	pcfBitMask           = 1 << 3 // Most significant bit first
+	pcfScanUnitMask      = 3 << 4
+)
+
+type pcfTable struct {
+	Type   uint32
+	Format uint32
+	Size   uint32
+	Offset uint32
+}
+
+// pcfSection is the data of a table, following the format field.
+type pcfSection struct {
+	*bytes.Reader
+	order  binary.ByteOrder
+	format uint32
+}
+
+type pcfMetric struct {
+	LeftSideBearing  int16
+	RightSideBearing int16
+	CharacterWidth   int16
+	Ascent           int16
+	Descent          int16
+	Attributes       uint16
+}
+
+// ReadPCF reads a font in the X11 Portable Compiled Format. If cm is not nil,
+// the glyph encodings are interpreted as Unicode code points and mapped to the
+// characters of the code page, which is used for the Runes of the font; glyphs
+// that are not in the code page are skipped. If cm is nil, the encoding is the
+// character index.
+func ReadPCF(r io.Reader, cm *charmap.Charmap) (*Font, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 8 || !bytes.Equal(b[:4], pcfMagic) {
+		return nil, ErrPCFHeader
+	}
+
+	var (
+		count  = binary.LittleEndian.Uint32(b[4:])
+		tables = make(map[uint32]pcfTable)
+	)
+	if int(count) > (len(b)-8)/16 {
+		return nil, ErrPCFHeader
+	}
+	for i := 0; i < int(count); i++ {
+		var (
+			o     = 8 + i*16
+			table = pcfTable{
+				Type:   binary.LittleEndian.Uint32(b[o:]),
+				Format: binary.LittleEndian.Uint32(b[o+4:]),
+				Size:   binary.LittleEndian.Uint32(b[o+8:]),
+				Offset: binary.LittleEndian.Uint32(b[o+12:]),
+			}
+		)
+		if uint64(table.Offset)+uint64(table.Size) > uint64(len(b)) {
+			return nil, fmt.Errorf("chargen: PCF table %#x out of bounds", table.Type)
+		}
+		tables[table.Type] = table
+	}
+	section := func(kind uint32) (*pcfSection, error) {
+		table, ok := tables[kind]
+		if !ok {
+			return nil, ErrPCFTable
+		}
+		data := b[table.Offset : table.Offset+table.Size]
+		if len(data) < 4 {
+			return nil, ErrPCFTable
+		}
+		s := &pcfSection{
+			Reader: bytes.NewReader(data[4:]),
+			order:  binary.LittleEndian,
+			format: binary.LittleEndian.Uint32(data),
+		}
+		if s.format&pcfByteMask != 0 {
+			s.order = binary.BigEndian
+		}
+		return s, nil
+	}
+
+	var (
+		metrics   []pcfMetric
+		bitmaps   *pcfBitmapTable
+		encodings []pcfEncoding
+		s         *pcfSection
+	)
+	if s, err = section(pcfMetrics); err != nil {
+		return nil, err
+	}
+	if metrics, err = readPCFMetrics(s); err != nil {
+		return nil, err
+	}
+	if s, err = section(pcfBitmaps); err != nil {
+		return nil, err
+	}
+	if bitmaps, err = readPCFBitmaps(s, len(metrics)); err != nil {
+		return nil, err
+	}
+	if s, err = section(pcfBDFEncodings); err != nil {
+		return nil, err
+	}
+	if encodings, err = readPCFEncodings(s); err != nil {
+		return nil, err
+	}
+
+	var glyphs []glyph
+	for _, e := range encodings {
+		if e.index >= len(metrics) {
+			continue
+		}
+		var (
+			m      = metrics[e.index]
+			width  = int(m.RightSideBearing) - int(m.LeftSideBearing)
+			height = int(m.Ascent) + int(m.Descent)
+			g      = glyph{
+				encoding: e.encoding,
+				bounds: image.Rect(
+					int(m.LeftSideBearing), -int(m.Descent),
+					int(m.RightSideBearing), int(m.Ascent),
+				),
+				advance: int(m.CharacterWidth),
+			}
+		)
+		if width < 0 || height < 0 {
+			continue
+		}
+		var (
+			data     = bitmaps.data[e.index]
+			rowBytes = ((width+7)>>3 + bitmaps.pad - 1) / bitmaps.pad * bitmaps.pad
+		)
+		g.rows = make([][]byte, height)
+		for y := range g.rows {
+			if o := y * rowBytes; o < len(data) {
+				g.rows[y] = data[o:min(len(data), o+rowBytes)]
+			}
+		}
+		glyphs = append(glyphs, g)
+	}
+	return newGlyphFont(glyphs, cm)
+}
+
+func readPCFMetrics(s *pcfSection) ([]pcfMetric, error) {
+	var err error
+	if s.format&pcfCompressedMetrics != 0 {
+		var count int16
+		if err = binary.Read(s, s.order, &count); err != nil {
+			return nil, err
+		}
+		if count < 0 || int(count) > s.Len()/5 {
+			return nil, errors.New("chargen: invalid number of PCF metrics")
+		}
+		compressed := make([][5]uint8, count)
+		if err = binary.Read(s, s.order, compressed); err != nil {
+			return nil, err
+		}
+		metrics := make([]pcfMetric, count)
+		for i, c := range compressed {
+			metrics[i] = pcfMetric{
+				LeftSideBearing:  int16(c[0]) - 0x80,
+				RightSideBearing: int16(c[1]) - 0x80,
+				CharacterWidth:   int16(c[2]) - 0x80,
+				Ascent:           int16(c[3]) - 0x80,
+				Descent:          int16(c[4]) - 0x80,
+			}
+		}
+		return metrics, nil
+	}
+
+	var count int32
+	if err = binary.Read(s, s.order, &count); err != nil {
+		return nil, err
+	}
+	if count < 0 || int(count) > s.Len()/12 {
+		return nil, errors.New("chargen: invalid number of PCF metrics")
+	}
+	metrics := make([]pcfMetric, count)
+	if err = binary.Read(s, s.order, metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+type pcfBitmapTable struct {
+	data [][]byte
+	pad  int
+}
+
+func readPCFBitmaps(s *pcfSection, glyphs int) (*pcfBitmapTable, error) {
+	var count int32
+	if err := binary.Read(s, s.order, &count); err != nil {
+		return nil, err
+	}
+	if int(count) != glyphs || int(count) > s.Len()/4 {
+		return nil, errors.New("chargen: PCF bitmap count does not match metrics")
+	}
+	offsets := make([]uint32, count)
+	if err := binary.Read(s, s.order, offsets); err != nil {
+		return nil, err
+	}
+	var sizes [4]uint32
+	if err := binary.Read(s, s.order, &sizes); err != nil {
+		return nil, err
+	}
+	var (
+		pad      = 1 << (s.format & pcfGlyphPadMask)
+		unit     = 1 << ((s.format & pcfScanUnitMask) >> 4)
+		size     = sizes[s.format&pcfGlyphPadMask]
+		bitmaps  = &pcfBitmapTable{data: make([][]byte, count), pad: pad}
+		msbBit   = s.format&pcfBitMask != 0
+		msbByte  = s.format&pcfByteMask != 0
+		swapUnit = msbBit != msbByte && unit > 1
+	)
+	if int(size) > s.Len() {
+		return nil, errors.New("chargen: PCF bitmap size out of bounds")
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(s, data); err != nil {
+		return nil, err
+	}
+
+	// Convert to most significant bit first, single byte scan units.
+	if !msbBit {
+		for i, v := range data {
+			data[i] = reverseBits(v)
+		}
+	}
+	if swapUnit {
+		for i := 0; i+unit <= len(data); i += unit {
+			for j := 0; j < unit/2; j++ {
+				data[i+j], data[i+unit-1-j] = data[i+unit-1-j], data[i+j]
+			}
+		}
+	}
+
+	for i, offset := range offsets {
+		if offset > size {
+			return nil, errors.New("chargen: PCF bitmap offset out of bounds")
+		}
+		bitmaps.data[i] = data[offset:]
+	}
+	return bitmaps, nil
+}
+
+type pcfEncoding struct {
+	encoding int
+	index    int
+}
+
+// readPCFEncodings returns the glyph index for each encoding, ordered by
+// encoding.
+func readPCFEncodings(s *pcfSection) ([]pcfEncoding, error) {
+	var header struct {
+		MinCharOrByte2 int16
+		MaxCharOrByte2 int16
+		MinByte1       int16
+		MaxByte1       int16
+		DefaultChar    int16
+	}
+	if err := binary.Read(s, s.order, &header); err != nil {
+		return nil, err
+	}
+	var (
+		cols = int(header.MaxCharOrByte2) - int(header.MinCharOrByte2) + 1
+		rows = int(header.MaxByte1) - int(header.MinByte1) + 1
+	)
+	if cols <= 0 || rows <= 0 || cols*rows > s.Len()/2 {
+		return nil, errors.New("chargen: invalid PCF encoding table")
+	}
+	indices := make([]uint16, cols*rows)
+	if err := binary.Read(s, s.order, indices); err != nil {
+		return nil, err
+	}
+	var encodings []pcfEncoding
+	for i, index := range indices {
+		if index == 0xffff {
+			continue
+		}
+		var (
+			byte1 = int(header.MinByte1) + i/cols
+			byte2 = int(header.MinCharOrByte2) + i%cols
+		)
+		encodings = append(encodings, pcfEncoding{byte1<<8 | byte2, int(index)})
+	}
+	return encodings, nil
+}
+
+func reverseBits(v byte) byte {
+	v = v>>4 | v<<4
+	v = (v&0xcc)>>2 | (v&0x33)<<2
+	v = (v&0xaa)>>1 | (v&0x55)<<1
+	return v
+}
@@ -0,0 +1,125 @@
+package chargen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+
+	"github.com/textmodes/parser/data"
+)
+
+// testPCF encodes a font as PCF with the given format for the bitmaps table.
+func testPCF(font *Font, format uint32) []byte {
+	var (
+		size       = font.Size
+		characters = int(font.Mask.Characters())
+		pad        = 1 << (format & pcfGlyphPadMask)
+		rowBytes   = ((size.X+7)>>3 + pad - 1) / pad * pad
+		order      binary.ByteOrder
+	)
+	order = binary.LittleEndian
+	if format&pcfByteMask != 0 {
+		order = binary.BigEndian
+	}
+
+	// Metrics table
+	metrics := new(bytes.Buffer)
+	binary.Write(metrics, binary.LittleEndian, uint32(format&pcfByteMask))
+	binary.Write(metrics, order, int32(characters))
+	for i := 0; i < characters; i++ {
+		binary.Write(metrics, order, pcfMetric{
+			RightSideBearing: int16(size.X),
+			CharacterWidth:   int16(size.X),
+			Ascent:           int16(size.Y - 2),
+			Descent:          2,
+		})
+	}
+
+	// Bitmaps table
+	bitmaps := new(bytes.Buffer)
+	binary.Write(bitmaps, binary.LittleEndian, format)
+	binary.Write(bitmaps, order, int32(characters))
+	for i := 0; i < characters; i++ {
+		binary.Write(bitmaps, order, uint32(i*rowBytes*size.Y))
+	}
+	var sizes [4]uint32
+	sizes[format&pcfGlyphPadMask] = uint32(characters * rowBytes * size.Y)
+	binary.Write(bitmaps, order, sizes)
+	for c := 0; c < characters; c++ {
+		mask, sp := font.CharMask(uint16(c))
+		for y := 0; y < size.Y; y++ {
+			row := make([]byte, rowBytes)
+			for x := 0; x < size.X; x++ {
+				if isOpaque(mask.At(sp.X+x, sp.Y+y)) {
+					row[x>>3] |= 0x80 >> uint(x&7)
+				}
+			}
+			if format&pcfBitMask == 0 {
+				for i, v := range row {
+					row[i] = reverseBits(v)
+				}
+			}
+			bitmaps.Write(row)
+		}
+	}
+
+	// Encodings table
+	encodings := new(bytes.Buffer)
+	binary.Write(encodings, binary.LittleEndian, uint32(format&pcfByteMask))
+	binary.Write(encodings, order, []int16{0, int16(characters - 1), 0, 0, 0})
+	for c := 0; c < characters; c++ {
+		binary.Write(encodings, order, uint16(c))
+	}
+
+	var (
+		b      = new(bytes.Buffer)
+		tables = []*bytes.Buffer{metrics, bitmaps, encodings}
+		types  = []uint32{pcfMetrics, pcfBitmaps, pcfBDFEncodings}
+		offset = 8 + 16*len(tables)
+	)
+	b.Write(pcfMagic)
+	binary.Write(b, binary.LittleEndian, uint32(len(tables)))
+	for i, table := range tables {
+		binary.Write(b, binary.LittleEndian, pcfTable{
+			Type:   types[i],
+			Format: format,
+			Size:   uint32(table.Len()),
+			Offset: uint32(offset),
+		})
+		offset += table.Len()
+	}
+	for _, table := range tables {
+		b.Write(table.Bytes())
+	}
+	return b.Bytes()
+}
+
+func TestReadPCF(t *testing.T) {
+	b, err := data.Bytes("font/chargen/ibm_vga_437.bin")
+	if err != nil {
+		t.Skip(err)
+	}
+	font := New(NewBytesMask(b, MaskOptions{Size: image.Pt(8, 16)}))
+
+	for _, test := range []struct {
+		Name   string
+		Format uint32
+	}{
+		{"msb", pcfByteMask | pcfBitMask | 2},
+		{"lsb", 0},
+		{"lsb-bit", pcfByteMask | 1},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			read, err := ReadPCF(bytes.NewReader(testPCF(font, test.Format)), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testEqualFonts(t, font, read, 256)
+		})
+	}
+
+	if _, err = ReadPCF(bytes.NewReader([]byte("not a font")), nil); err != ErrPCFHeader {
+		t.Fatalf("expected %v, got %v", ErrPCFHeader, err)
+	}
+}
@@ -0,0 +1,196 @@
+package chargen
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"unicode/utf8"
+)
+
+// PSF errors.
+var (
+	ErrPSFMagic     = errors.New("chargen: not a PSF font")
+	ErrPSFMode      = errors.New("chargen: unsupported PSF1 mode")
+	ErrPSFGlyphSize = errors.New("chargen: invalid PSF glyph size")
+)
+
+// PSF magic numbers.
+var (
+	psf1Magic = []byte{0x36, 0x04}
+	psf2Magic = []byte{0x72, 0xb5, 0x4a, 0x86}
+)
+
+// PSF1 mode flags.
+const (
+	psf1Mode512    = 0x01
+	psf1ModeHasTab = 0x02
+	psf1ModeHasSeq = 0x04
+	psf1ModeMax    = 0x05
+
+	psf1Separator = 0xffff
+	psf1StartSeq  = 0xfffe
+)
+
+// PSF2 header flags.
+const (
+	psf2HasUnicodeTable = 0x01
+
+	psf2Separator = 0xff
+	psf2StartSeq  = 0xfe
+
+	// Limits of the glyph size, well beyond any console font
+	psf2MaxWidth  = 64
+	psf2MaxHeight = 128
+)
+
+type psf2Header struct {
+	Version    uint32
+	HeaderSize uint32
+	Flags      uint32
+	Length     uint32 // Number of glyphs
+	CharSize   uint32 // Number of bytes per glyph
+	Height     uint32
+	Width      uint32
+}
+
+// ReadPSF reads a Linux console font in PSF1 or PSF2 format. If the font has a
+// Unicode table, the mapping of runes to glyph indexes is returned as well and
+// set as the Runes of the font; character sequences in the table are ignored.
+func ReadPSF(r io.Reader) (*Font, map[rune]uint16, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(psf2Magic))
+	if err != nil && len(magic) < len(psf1Magic) {
+		return nil, nil, ErrPSFMagic
+	}
+	switch {
+	case len(magic) == len(psf2Magic) && string(magic) == string(psf2Magic):
+		return readPSF2(br)
+	case string(magic[:len(psf1Magic)]) == string(psf1Magic):
+		return readPSF1(br)
+	default:
+		return nil, nil, ErrPSFMagic
+	}
+}
+
+func readPSF1(r io.Reader) (*Font, map[rune]uint16, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, nil, err
+	}
+	var (
+		mode       = header[2]
+		height     = int(header[3])
+		characters = 256
+	)
+	if mode > psf1ModeMax {
+		return nil, nil, ErrPSFMode
+	}
+	if height == 0 {
+		return nil, nil, ErrPSFGlyphSize
+	}
+	if mode&psf1Mode512 != 0 {
+		characters = 512
+	}
+
+	data := make([]byte, characters*height)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, nil, err
+	}
+	font := New(newRowMask(data, characters, image.Pt(8, height), 1))
+
+	if mode&(psf1ModeHasTab|psf1ModeHasSeq) == 0 {
+		return font, nil, nil
+	}
+
+	runes := make(map[rune]uint16)
+	for glyph := 0; glyph < characters; glyph++ {
+		var inSequence bool
+		for {
+			var v uint16
+			if err := binary.Read(r, binary.LittleEndian, &v); err != nil {
+				return nil, nil, fmt.Errorf("chargen: error reading PSF1 unicode table: %v", err)
+			}
+			if v == psf1Separator {
+				break
+			} else if v == psf1StartSeq {
+				inSequence = true
+			} else if !inSequence {
+				if _, ok := runes[rune(v)]; !ok {
+					runes[rune(v)] = uint16(glyph)
+				}
+			}
+		}
+	}
+	font.Runes = runes
+	return font, runes, nil
+}
+
+func readPSF2(r *bufio.Reader) (*Font, map[rune]uint16, error) {
+	if _, err := r.Discard(len(psf2Magic)); err != nil {
+		return nil, nil, err
+	}
+	var header psf2Header
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, nil, err
+	}
+
+	if header.Width == 0 || header.Width > psf2MaxWidth || header.Height == 0 || header.Height > psf2MaxHeight {
+		return nil, nil, ErrPSFGlyphSize
+	}
+	var (
+		rowBytes = (int(header.Width) + 7) >> 3
+		size     = image.Pt(int(header.Width), int(header.Height))
+	)
+	if int64(header.CharSize) != int64(rowBytes)*int64(size.Y) {
+		return nil, nil, ErrPSFGlyphSize
+	}
+	if header.Length == 0 || header.Length > 0xffff {
+		return nil, nil, fmt.Errorf("chargen: invalid number of PSF glyphs %d", header.Length)
+	}
+	if skip := int(header.HeaderSize) - 32; skip > 0 {
+		if _, err := r.Discard(skip); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The glyphs are read as they come, truncated fonts never allocate the
+	// full size from the header
+	length := int64(header.Length) * int64(header.CharSize)
+	data, err := ioutil.ReadAll(io.LimitReader(r, length))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(data)) < length {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	font := New(newRowMask(data, int(header.Length), size, rowBytes))
+
+	if header.Flags&psf2HasUnicodeTable == 0 {
+		return font, nil, nil
+	}
+
+	runes := make(map[rune]uint16)
+	for glyph := 0; glyph < int(header.Length); glyph++ {
+		entry, err := r.ReadBytes(psf2Separator)
+		if err != nil {
+			return nil, nil, fmt.Errorf("chargen: error reading PSF2 unicode table: %v", err)
+		}
+		entry = entry[:len(entry)-1]
+		for len(entry) > 0 && entry[0] != psf2StartSeq {
+			v, n := utf8.DecodeRune(entry)
+			if v == utf8.RuneError && n <= 1 {
+				return nil, nil, errors.New("chargen: invalid UTF-8 in PSF2 unicode table")
+			}
+			if _, ok := runes[v]; !ok {
+				runes[v] = uint16(glyph)
+			}
+			entry = entry[n:]
+		}
+	}
+	font.Runes = runes
+	return font, runes, nil
+}
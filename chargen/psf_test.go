@@ -0,0 +1,196 @@
+package chargen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+	"testing"
+)
+
+func testPSF1(mode byte, characters, height int) []byte {
+	b := new(bytes.Buffer)
+	b.Write([]byte{0x36, 0x04, mode, byte(height)})
+	for c := 0; c < characters; c++ {
+		for y := 0; y < height; y++ {
+			// Each glyph has its character code in the first row, and the
+			// left most pixel set in all other rows.
+			if y == 0 {
+				b.WriteByte(byte(c))
+			} else {
+				b.WriteByte(0x80)
+			}
+		}
+	}
+	if mode&psf1ModeHasTab != 0 {
+		for c := 0; c < characters; c++ {
+			binary.Write(b, binary.LittleEndian, uint16(0x2500+c))
+			if c == 'A' {
+				// Sequence that should be ignored
+				binary.Write(b, binary.LittleEndian, []uint16{psf1StartSeq, 'x', 0x0301})
+			}
+			binary.Write(b, binary.LittleEndian, uint16(psf1Separator))
+		}
+	}
+	return b.Bytes()
+}
+
+func testPSF2(characters, width, height int, table bool) []byte {
+	var (
+		b        = new(bytes.Buffer)
+		rowBytes = (width + 7) / 8
+		flags    uint32
+	)
+	if table {
+		flags = psf2HasUnicodeTable
+	}
+	b.Write(psf2Magic)
+	binary.Write(b, binary.LittleEndian, psf2Header{
+		HeaderSize: 32,
+		Flags:      flags,
+		Length:     uint32(characters),
+		CharSize:   uint32(rowBytes * height),
+		Height:     uint32(height),
+		Width:      uint32(width),
+	})
+	for c := 0; c < characters; c++ {
+		for y := 0; y < height; y++ {
+			row := make([]byte, rowBytes)
+			if y == c%height {
+				// Set the right most pixel
+				row[(width-1)/8] |= 0x80 >> uint((width-1)%8)
+			}
+			b.Write(row)
+		}
+	}
+	if table {
+		for c := 0; c < characters; c++ {
+			b.WriteString(string(rune(0x4e00 + c)))
+			if c == 0 {
+				b.WriteString("\xfeÅ")
+			}
+			b.WriteByte(psf2Separator)
+		}
+	}
+	return b.Bytes()
+}
+
+func TestReadPSF1(t *testing.T) {
+	for _, test := range []struct {
+		Name       string
+		Mode       byte
+		Characters int
+	}{
+		{"256", 0, 256},
+		{"256+table", psf1ModeHasTab, 256},
+		{"512+table", psf1Mode512 | psf1ModeHasTab, 512},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			font, runes, err := ReadPSF(bytes.NewReader(testPSF1(test.Mode, test.Characters, 14)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := font.Mask.Characters(); int(n) != test.Characters {
+				t.Fatalf("expected %d characters, got %d", test.Characters, n)
+			}
+			if font.Size != image.Pt(8, 14) {
+				t.Fatalf("expected 8x14 font, got %s", font.Size)
+			}
+			// 'A' = 0x41 = 01000001
+			mask, sp := font.CharMask('A')
+			for x, want := range []bool{false, true, false, false, false, false, false, true} {
+				if got := isOpaque(mask.At(sp.X+x, sp.Y)); got != want {
+					t.Errorf("pixel (%d,0): expected %t, got %t", x, want, got)
+				}
+			}
+			if !isOpaque(mask.At(sp.X, sp.Y+13)) {
+				t.Error("expected pixel (0,13) to be set")
+			}
+
+			if test.Mode&psf1ModeHasTab == 0 {
+				if runes != nil {
+					t.Fatal("expected no unicode table")
+				}
+				return
+			}
+			if len(runes) != test.Characters {
+				t.Fatalf("expected %d runes, got %d", test.Characters, len(runes))
+			}
+			if glyph, ok := runes[0x2500+'A']; !ok || glyph != 'A' {
+				t.Fatalf("expected rune to map to glyph %d, got %d", 'A', glyph)
+			}
+			if _, ok := runes['x']; ok {
+				t.Fatal("expected sequence to be ignored")
+			}
+		})
+	}
+}
+
+func TestReadPSF2(t *testing.T) {
+	for _, size := range []image.Point{{8, 16}, {12, 24}, {5, 7}} {
+		t.Run(size.String(), func(t *testing.T) {
+			font, runes, err := ReadPSF(bytes.NewReader(testPSF2(300, size.X, size.Y, true)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := font.Mask.Characters(); n != 300 {
+				t.Fatalf("expected 300 characters, got %d", n)
+			}
+			if font.Size != size {
+				t.Fatalf("expected %s font, got %s", size, font.Size)
+			}
+			for _, c := range []uint16{0, 1, 42, 299} {
+				mask, sp := font.CharMask(c)
+				for y := 0; y < size.Y; y++ {
+					for x := 0; x < size.X; x++ {
+						want := x == size.X-1 && y == int(c)%size.Y
+						if got := isOpaque(mask.At(sp.X+x, sp.Y+y)); got != want {
+							t.Fatalf("char %d pixel (%d,%d): expected %t, got %t", c, x, y, want, got)
+						}
+					}
+				}
+			}
+			if len(runes) != 300 {
+				t.Fatalf("expected 300 runes, got %d", len(runes))
+			}
+			if glyph := runes[0x4e00+42]; glyph != 42 {
+				t.Fatalf("expected rune to map to glyph 42, got %d", glyph)
+			}
+			if _, ok := runes['A']; ok {
+				t.Fatal("expected sequence to be ignored")
+			}
+		})
+	}
+}
+
+func TestReadPSFErrors(t *testing.T) {
+	if _, _, err := ReadPSF(bytes.NewReader([]byte("not a font"))); err != ErrPSFMagic {
+		t.Fatalf("expected %v, got %v", ErrPSFMagic, err)
+	}
+	if _, _, err := ReadPSF(bytes.NewReader([]byte{0x36, 0x04, 0x10, 0x10})); err != ErrPSFMode {
+		t.Fatalf("expected %v, got %v", ErrPSFMode, err)
+	}
+	b := testPSF1(0, 256, 8)
+	if _, _, err := ReadPSF(bytes.NewReader(b[:100])); err == nil {
+		t.Fatal("expected error for truncated font")
+	}
+
+	for _, test := range []struct {
+		Name   string
+		Header psf2Header
+		Want   error
+	}{
+		{"huge", psf2Header{HeaderSize: 32, Length: 0xffff, CharSize: 0x10000000, Height: 0x10000000, Width: 8}, ErrPSFGlyphSize},
+		{"wide", psf2Header{HeaderSize: 32, Length: 1, CharSize: 9 * 16, Height: 16, Width: 72}, ErrPSFGlyphSize},
+		{"char size", psf2Header{HeaderSize: 32, Length: 1, CharSize: 32, Height: 16, Width: 8}, ErrPSFGlyphSize},
+		{"truncated", psf2Header{HeaderSize: 32, Length: 0xffff, CharSize: 128 * 8, Height: 128, Width: 64}, io.ErrUnexpectedEOF},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			b := bytes.NewBuffer(append([]byte(nil), psf2Magic...))
+			binary.Write(b, binary.LittleEndian, test.Header)
+			if _, _, err := ReadPSF(b); err != test.Want {
+				t.Fatalf("expected %v, got %v", test.Want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package chargen
+
+import (
+	"image"
+	"image/color"
+)
+
+// ScaleMode is the algorithm used for scaling a Mask.
+type ScaleMode int
+
+// Scale modes.
+const (
+	// ScaleNearest uses nearest neighbour sampling.
+	ScaleNearest ScaleMode = iota
+
+	// ScaleEPX uses the Scale2x/EPX rules, where each quarter of a source
+	// pixel takes the value of its neighbours if they both differ from the
+	// pixel and form a corner. At twice the size, this is identical to
+	// Scale2x.
+	ScaleEPX
+
+	// ScaleSmooth uses the EPX rules, but fills (or cuts) half a source pixel
+	// along the diagonal in stead of a quarter. This gives hq2x-like smooth
+	// diagonals at higher scale factors.
+	ScaleSmooth
+)
+
+// corners of a pixel, as direction from the pixel.
+var corners = [4]image.Point{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}}
+
+type filterScale struct {
+	mask   Mask
+	src    image.Point
+	size   image.Point
+	bounds image.Rectangle
+	mode   ScaleMode
+}
+
+func (filter filterScale) At(x, y int) color.Color {
+	char, cx, cy, ok := charPoint(filter.size, x, y)
+	if !ok {
+		return Transparent
+	}
+
+	// Map the center of the pixel to the source, the fractions are in units
+	// of 1/du and 1/dv of a source pixel.
+	var (
+		du     = 2 * filter.size.X
+		dv     = 2 * filter.size.Y
+		u      = (2*cx + 1) * filter.src.X
+		v      = (2*cy + 1) * filter.src.Y
+		sx, fx = u / du, u % du
+		sy, fy = v / dv, v % dv
+		opaque = filter.opaque(char, sx, sy)
+	)
+
+	switch filter.mode {
+	case ScaleEPX:
+		// Only the corner of the quarter the pixel is in
+		d := image.Pt(-1, -1)
+		if 2*fx >= du {
+			d.X = 1
+		}
+		if 2*fy >= dv {
+			d.Y = 1
+		}
+		if filter.corner(char, sx, sy, d, opaque) {
+			opaque = !opaque
+		}
+
+	case ScaleSmooth:
+		// Any corner whose diagonal half contains the pixel
+		for _, d := range corners {
+			ax, ay := fx, fy
+			if d.X == 1 {
+				ax = du - fx
+			}
+			if d.Y == 1 {
+				ay = dv - fy
+			}
+			if ax*dv+ay*du < du*dv && filter.corner(char, sx, sy, d, opaque) {
+				opaque = !opaque
+				break
+			}
+		}
+	}
+
+	if opaque {
+		return Opaque
+	}
+	return Transparent
+}
+
+func (filter filterScale) opaque(char, x, y int) bool {
+	return charOpaque(filter.mask, filter.src, char, x, y)
+}
+
+// corner checks if the corner of source pixel (x, y) in direction d is to be
+// flipped: the neighbours towards the corner both differ from the pixel and
+// the neighbours away from the corner both equal the pixel.
+func (filter filterScale) corner(char, x, y int, d image.Point, opaque bool) bool {
+	return filter.opaque(char, x+d.X, y) != opaque &&
+		filter.opaque(char, x, y+d.Y) != opaque &&
+		filter.opaque(char, x-d.X, y) == opaque &&
+		filter.opaque(char, x, y-d.Y) == opaque
+}
+
+func (filter filterScale) Characters() uint16 {
+	return filter.mask.Characters()
+}
+
+func (filter filterScale) CharacterSize() image.Point {
+	return filter.size
+}
+
+func (filter filterScale) ColorModel() color.Model {
+	return filter.mask.ColorModel()
+}
+
+func (filter filterScale) Bounds() image.Rectangle {
+	return filter.bounds
+}
+
+func (filter filterScale) SubMask(r image.Rectangle) Mask {
+	if r = r.Intersect(filter.bounds); r.Empty() {
+		return nil
+	}
+	filter.bounds = r
+	return filter
+}
+
+// Scale scales each character of the mask to the new character size using
+// the scale mode. The scale factor doesn't have to be an integer and may be
+// different for each axis.
+/*
+
+Scaling to twice the size with ScaleEPX:
+
+ 0123      01234567
+0#___  →  0##______
+1_#__  →  1###_____
+2__#_  →  2_###____
+3____  →  3__###___
+          4___###__
+          5____##__
+          6________
+          7________
+
+*/
+func Scale(mask Mask, size image.Point, mode ScaleMode) Mask {
+	if size.X < 1 || size.Y < 1 {
+		return nil
+	}
+	var (
+		src    = mask.CharacterSize()
+		chars  = int(mask.Characters())
+		bounds = image.Rect(0, 0, chars*size.X, size.Y)
+	)
+	return filterScale{
+		mask:   mask,
+		src:    src,
+		size:   size,
+		bounds: bounds,
+		mode:   mode,
+	}
+}
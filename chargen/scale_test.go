@@ -0,0 +1,93 @@
+package chargen
+
+import (
+	"image"
+	"testing"
+)
+
+func testScaleMask() Mask {
+	return testMaskRows(
+		"#___",
+		"_#__",
+		"__#_",
+		"____",
+	)
+}
+
+func TestScale(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Size image.Point
+		Mode ScaleMode
+		Want Mask
+	}{
+		{"nearest", image.Pt(8, 8), ScaleNearest, testMaskRows(
+			"##______",
+			"##______",
+			"__##____",
+			"__##____",
+			"____##__",
+			"____##__",
+			"________",
+			"________",
+		)},
+		{"nearest 1.5x", image.Pt(6, 6), ScaleNearest, testMaskRows(
+			"#_____",
+			"_##___",
+			"_##___",
+			"___#__",
+			"______",
+			"______",
+		)},
+		{"epx", image.Pt(8, 8), ScaleEPX, testMaskRows(
+			"##______",
+			"###_____",
+			"_###____",
+			"__###___",
+			"___###__",
+			"____##__",
+			"________",
+			"________",
+		)},
+		{"smooth", image.Pt(8, 8), ScaleSmooth, testMaskRows(
+			"##______",
+			"###_____",
+			"_###____",
+			"__###___",
+			"___###__",
+			"____##__",
+			"________",
+			"________",
+		)},
+		{"smooth 4x", image.Pt(16, 16), ScaleSmooth, testMaskRows(
+			"####____________",
+			"#####___________",
+			"######__________",
+			"#######_________",
+			"_#######________",
+			"__#######_______",
+			"___#######______",
+			"____#######_____",
+			"_____#######____",
+			"______######____",
+			"_______#####____",
+			"________####____",
+			"________________",
+			"________________",
+			"________________",
+			"________________",
+		)},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			mask := Scale(testScaleMask(), test.Size, test.Mode)
+			if size := mask.CharacterSize(); size != test.Size {
+				t.Fatalf("expected size of %s got %s", test.Size, size)
+			}
+			testMaskEqual(t, mask, test.Want)
+		})
+	}
+
+	if Scale(testScaleMask(), image.Point{}, ScaleNearest) != nil {
+		t.Fatal("expected nil mask for empty size")
+	}
+}
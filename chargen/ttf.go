@@ -0,0 +1,107 @@
+package chargen
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// TrueTypeOptions are the options for rasterising a TrueType font.
+type TrueTypeOptions struct {
+	// Size of each character cell in pixels.
+	Size image.Point
+
+	// FontSize is the size of the font in pixels, if left empty it defaults to
+	// the cell height. Pixel fonts render crisp at their design size.
+	FontSize float64
+
+	// Baseline is the distance from the top of the cell to the baseline in
+	// pixels, if left empty it is derived from the ascent of the font.
+	Baseline int
+
+	// Threshold is the minimum coverage for a pixel to be opaque, if left
+	// empty it defaults to 50%.
+	Threshold uint8
+
+	// Charmap maps the characters to runes, if left empty it defaults to
+	// code page 437.
+	Charmap *charmap.Charmap
+
+	// Runes maps the characters to runes, if set it takes precedence over
+	// Charmap and the number of runes is the number of characters.
+	Runes []rune
+}
+
+// NewTrueTypeMask rasterises a TrueType font in to a mask. Each character is
+// rendered in a cell of the configured size; characters that have no glyph in
+// the font are left empty.
+func NewTrueTypeMask(ttf []byte, opts TrueTypeOptions) (Mask, error) {
+	if opts.Size.X < 1 || opts.Size.Y < 1 {
+		return nil, errors.New("chargen: invalid TrueType cell size")
+	}
+	f, err := truetype.Parse(ttf)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.FontSize <= 0 {
+		opts.FontSize = float64(opts.Size.Y)
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = 0x80
+	}
+	runes := opts.Runes
+	if runes == nil {
+		if opts.Charmap == nil {
+			opts.Charmap = charmap.CodePage437
+		}
+		runes = CodePageRunes(opts.Charmap)
+	}
+	if len(runes) > 0xffff {
+		runes = runes[:0xffff]
+	}
+
+	face := truetype.NewFace(f, &truetype.Options{
+		Size:    opts.FontSize,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	defer face.Close()
+	if opts.Baseline == 0 {
+		opts.Baseline = face.Metrics().Ascent.Ceil()
+	}
+
+	var (
+		size   = opts.Size
+		stride = size.X * size.Y
+		data   = make([]byte, (len(runes)*stride+7)>>3)
+		cell   = image.NewAlpha(image.Rectangle{Max: size})
+		dot    = fixed.P(0, opts.Baseline)
+	)
+	for char, r := range runes {
+		if !isDefinedRune(r) || f.Index(r) == 0 {
+			continue
+		}
+		dr, mask, mp, _, ok := face.Glyph(dot, r)
+		if !ok {
+			continue
+		}
+		draw.Draw(cell, cell.Bounds(), image.Transparent, image.ZP, draw.Src)
+		draw.Draw(cell, dr, mask, mp, draw.Src)
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				if cell.AlphaAt(x, y).A >= opts.Threshold {
+					bit := char*stride + y*size.X + x
+					data[bit>>3] |= 0x80 >> uint(bit&7)
+				}
+			}
+		}
+	}
+
+	return NewBytesMask(data, MaskOptions{Size: size}), nil
+}
@@ -0,0 +1,87 @@
+package chargen
+
+import (
+	"image"
+	"testing"
+
+	"github.com/textmodes/parser/data"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func countOpaque(font *Font, char uint16) (n int) {
+	mask, sp := font.CharMask(char)
+	for y := 0; y < font.Size.Y; y++ {
+		for x := 0; x < font.Size.X; x++ {
+			if isOpaque(mask.At(sp.X+x, sp.Y+y)) {
+				n++
+			}
+		}
+	}
+	return
+}
+
+func TestTrueTypeMask(t *testing.T) {
+	ttf, err := data.Bytes("font/ttf/MODE7GX3.TTF")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	mask, err := NewTrueTypeMask(ttf, TrueTypeOptions{Size: image.Pt(16, 20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := mask.Characters(); n != 256 {
+		t.Fatalf("expected 256 characters, got %d", n)
+	}
+	font := New(mask)
+	if font.Size != image.Pt(16, 20) {
+		t.Fatalf("expected 16x20 font, got %s", font.Size)
+	}
+	if n := countOpaque(font, ' '); n != 0 {
+		t.Errorf("expected empty space, got %d pixels", n)
+	}
+	a := countOpaque(font, 'A')
+	if a == 0 {
+		t.Fatal("expected A to be rendered")
+	}
+
+	// A higher threshold yields fewer pixels
+	mask, err = NewTrueTypeMask(ttf, TrueTypeOptions{Size: image.Pt(16, 20), Threshold: 0xff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countOpaque(New(mask), 'A'); n > a {
+		t.Errorf("expected at most %d pixels with a higher threshold, got %d", a, n)
+	}
+
+	// Explicit rune table
+	mask, err = NewTrueTypeMask(ttf, TrueTypeOptions{Size: image.Pt(16, 20), Runes: []rune{' ', 'A'}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := mask.Characters(); n != 2 {
+		t.Fatalf("expected 2 characters, got %d", n)
+	}
+	if n := countOpaque(New(mask), 1); n != a {
+		t.Errorf("expected %d pixels, got %d", a, n)
+	}
+
+	if _, err = NewTrueTypeMask(ttf, TrueTypeOptions{}); err == nil {
+		t.Error("expected error for empty cell size")
+	}
+	if _, err = NewTrueTypeMask([]byte("not a font"), TrueTypeOptions{Size: image.Pt(8, 8)}); err == nil {
+		t.Error("expected error for invalid font")
+	}
+}
+
+func TestCodePageRunes(t *testing.T) {
+	runes := CodePageRunes(charmap.CodePage437)
+	for char, want := range map[int]rune{0x01: '☺', 0x41: 'A', 0x7f: '⌂', 0xdb: '█'} {
+		if runes[char] != want {
+			t.Errorf("character %#02x: expected %q, got %q", char, want, runes[char])
+		}
+	}
+	if runes = CodePageRunes(charmap.ISO8859_1); runes[0x01] != 0x01 {
+		t.Errorf("expected control character, got %q", runes[0x01])
+	}
+}
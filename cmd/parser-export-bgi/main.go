@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/textmodes/parser/image/bgi"
+)
+
+func main() {
+	format := flag.String("format", "svg", "output format (svg, json or chr)")
+	output := flag.String("output", ".", "output directory")
+	flag.Parse()
+
+	for _, arg := range flag.Args() {
+		if err := export(arg, *output, *format); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+func export(name, output, format string) error {
+	switch format {
+	case "svg", "json", "chr":
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+
+	f, err := readFont(name)
+	if err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	o, err := os.Create(filepath.Join(output, base+"."+format))
+	if err != nil {
+		return err
+	}
+	defer o.Close()
+
+	log.Printf("exporting %s to %s", name, o.Name())
+	switch format {
+	case "svg":
+		return f.WriteSVG(o)
+	case "json":
+		return f.WriteJSON(o)
+	default:
+		_, err = f.WriteTo(o)
+		return err
+	}
+}
+
+func readFont(name string) (*bgi.Font, error) {
+	r, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		return bgi.ReadJSON(r)
+	}
+	return bgi.NewFont(r)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"image"
+	"os"
+
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/image/aspect"
+)
+
+var aspectFilters = map[string]aspect.Filter{
+	"nearest": aspect.Nearest,
+	"area":    aspect.Area,
+}
+
+// correctAspect stretches the image to the pixel aspect ratio of the font, if
+// the SAUCE record of the input asks for stretching or has no preference, as
+// is the case for pieces made for legacy displays.
+func correctAspect(im image.Image, name, font, filter string) image.Image {
+	if filter == "" {
+		return im
+	}
+	f, ok := aspectFilters[filter]
+	if !ok {
+		fatalf("unknown aspect ratio filter %q", filter)
+	}
+
+	var record *sauce.Record
+	if r, err := os.Open(name); err == nil {
+		record, _ = sauce.Parse(r)
+		r.Close()
+	}
+	if record == nil {
+		record = new(sauce.Record)
+	}
+	switch {
+	case record.Stretch():
+		infof("SAUCE record requests stretching")
+	case record.Flags != nil && record.Flags.AspectRatio != sauce.AspectRatioLegacy:
+		infof("SAUCE record requests %s aspect ratio, not stretching", record.Flags.AspectRatio)
+		return im
+	}
+	if font != "" {
+		record.Info = font
+	}
+
+	ratio, ok := record.PixelRatio()
+	if !ok {
+		infof("no pixel aspect ratio known for font %q, not stretching", record.Info)
+		return im
+	}
+	infof("stretching to pixel aspect ratio %d:%d", ratio.X, ratio.Y)
+	return aspect.Correct(im, image.Pt(ratio.X, ratio.Y), f)
+}
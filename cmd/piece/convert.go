@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/text/convert"
+)
+
+// convertMain handles the convert command, which transcodes between text mode
+// formats.
+func convertMain(args []string) {
+	flags := flag.NewFlagSet(program+" convert", flag.ExitOnError)
+	to := flags.String("to", "", `target format ("list" for a list)`)
+	kind := flags.String("type", "auto", `parser type ("list" for a list)`)
+	output := flags.String("o", "", `output file name, "-" for standard output (default replace extension of input file name)`)
+	flags.BoolVar(&quiet, "q", false, "be quiet")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s convert -to <format> [<options>] <input>\n\nOptions:\n", program)
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	flags.Parse(args)
+
+	if *to == "list" {
+		listtargets()
+		os.Exit(0)
+	}
+	if *kind == "list" {
+		listtypes()
+		os.Exit(0)
+	}
+	if *to == "" || flags.NArg() != 1 {
+		flags.Usage()
+	}
+
+	target, err := convert.Lookup(*to)
+	if err == convert.ErrUnknownTarget {
+		if alias, ok := typeAlias[*to]; ok {
+			target, err = convert.Lookup(alias)
+		}
+	}
+	if err != nil {
+		fatalf(`unknown target format %q (try "list" ?)`, *to)
+	}
+
+	name := flags.Arg(0)
+	r, err := readInput(name)
+	if err != nil {
+		fatalf("error reading %s: %v", name, err)
+	}
+
+	decoder, err := parseFor(*kind, name, "", r)
+	if err != nil {
+		fatalf("unable to find parser for %s: %v", name, err)
+	}
+	var parsed parser.Parser
+	timer("decoding", func() {
+		if parsed, err = decoder(r); err != nil {
+			fatalf("error decoding %s: %v", name, err)
+		}
+	})
+
+	var b bytes.Buffer
+	warnings, err := convert.Convert(&b, parsed, target)
+	if err != nil {
+		fatalf("error converting %s to %s: %v", name, target.Name, err)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "%s: warning: %s\n", program, warning)
+	}
+
+	if *output == "-" {
+		if _, err = b.WriteTo(os.Stdout); err != nil {
+			fatalf("error writing: %v", err)
+		}
+		os.Exit(0)
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(name, filepath.Ext(name)) + target.Extension
+		if *output == name {
+			fatalf("output would overwrite %s, use -o", name)
+		}
+		fmt.Fprintf(os.Stderr, "%s: no output given, using %s\n", program, *output)
+	}
+	if err = ioutil.WriteFile(*output, b.Bytes(), 0644); err != nil {
+		fatalf("error writing %s: %v", *output, err)
+	}
+	infof("%s: wrote %d bytes\n", *output, b.Len())
+	os.Exit(0)
+}
+
+// readInput reads the input file, or standard input for "-".
+func readInput(name string) (io.ReadSeeker, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if name == "-" || filepath.Clean(name) == "/dev/stdin" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+func listtargets() {
+	fmt.Println("Supported target formats:")
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 20, 8, 0, '\t', 0)
+	for _, target := range convert.Targets() {
+		fmt.Fprintf(w, "   %s\t%s\n", target.Name, target.Extension)
+	}
+	w.Flush()
+}
@@ -3,11 +3,14 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"text/tabwriter"
 
+	"github.com/textmodes/parser/chargen"
 	"github.com/textmodes/parser/format/sauce"
+	"golang.org/x/text/encoding/charmap"
 )
 
 var fontAlias = map[string]string{
@@ -80,3 +83,36 @@ func listfonts() {
 	}
 	w.Flush()
 }
+
+// loadFont loads a font by SAUCE name, or from a font file. Unicode encoded
+// BDF and PCF fonts are mapped to code page 437.
+func loadFont(name string) (*chargen.Font, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch ext {
+	case ".font":
+		font, err := chargen.OpenDiskFont(name, 0)
+		if err != nil {
+			return nil, err
+		}
+		return font.Font, nil
+	case ".psf", ".psfu", ".bdf", ".pcf":
+	default:
+		return sauce.Font(name)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch ext {
+	case ".bdf":
+		return chargen.ReadBDF(f, charmap.CodePage437)
+	case ".pcf":
+		return chargen.ReadPCF(f, charmap.CodePage437)
+	default:
+		font, _, err := chargen.ReadPSF(f)
+		return font, err
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/textmodes/parser"
 	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/image/crt"
 	"github.com/textmodes/parser/text/ansi"
 )
 
@@ -27,10 +28,12 @@ var program = filepath.Base(os.Args[0])
 var (
 	stdout = bufio.NewWriter(os.Stdout)
 	quiet  bool
+	crtOpt string
 )
 
 func usage() {
 	fmt.Fprintf(os.Stderr, "%s [<options>] <input>\n", program)
+	fmt.Fprintf(os.Stderr, "%s convert -to <format> [<options>] <input>\n", program)
 
 	opts := func(s ...string) {
 		m := make(map[string]bool)
@@ -65,7 +68,7 @@ func usage() {
 	opts("o", "q")
 
 	fmt.Fprintln(os.Stderr, "\nRender options:")
-	opts("animate", "scroll")
+	opts("animate", "aspect", "crt", "scroll")
 
 	fmt.Fprintln(os.Stderr, "\nANSi specific options:")
 	opts("blink", "font", "noblink")
@@ -90,15 +93,22 @@ func isZeroValue(f *flag.Flag, value string) bool {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "convert" {
+		convertMain(os.Args[2:])
+		return
+	}
+
 	kind := flag.String("type", "auto", `parser type ("list" for a list)`)
 	output := flag.String("o", "", "output file name (default append extension to input file name)")
 	flag.BoolVar(&quiet, "q", false, "be quiet")
 
 	animate := flag.Duration("animate", 0, "create a animated GIF (default false)")
 	scroll := flag.Duration("scroll", 0, "create a scrolling GIF (default false)")
+	flag.StringVar(&crtOpt, "crt", "", `CRT display emulation preset ("vga", "amiga" or "teletext")`)
+	aspectFilter := flag.String("aspect", "", `stretch images to the display aspect ratio ("nearest" or "area")`)
 
 	blink := flag.Bool("blink", true, "blink toggle")
-	font := flag.String("font", "", `font name (default use SAUCE) ("list" for a list)`)
+	font := flag.String("font", "", `font name or PSF, BDF, PCF or Amiga font file (default use SAUCE) ("list" for a list)`)
 	ignoreTab := flag.Bool("notab", false, "replace tabs by spaces (default: off)")
 
 	flag.Usage = usage
@@ -124,7 +134,12 @@ func main() {
 		err  error
 	)
 	if name == "-" || filepath.Clean(name) == "/dev/stdin" {
-		f = os.Stdin
+		// Buffer standard input, format detection needs to seek
+		var b []byte
+		if b, err = ioutil.ReadAll(os.Stdin); err != nil {
+			fatalf("error reading %s: %v", name, err)
+		}
+		f = nullCloser{bytes.NewReader(b)}
 	} else if f, err = os.Open(name); err != nil {
 		fatalf("error opening %s: %v", name, err)
 		if *font == "" {
@@ -150,7 +165,7 @@ func main() {
 		*font = alias
 	}
 
-	decoder, err := parseFor(*kind, name, *font)
+	decoder, err := parseFor(*kind, name, *font, f.(io.ReadSeeker))
 	if err != nil {
 		fatalf("unable to find parser for %s: %v", name, err)
 	}
@@ -227,6 +242,7 @@ func main() {
 					fatalf("error: %v", err)
 				}
 			})
+			im = correctAspect(im, name, *font, *aspectFilter)
 			writePNG(im, name, *output)
 		}
 		if i, ok := parsed.(parser.Image); ok {
@@ -235,6 +251,7 @@ func main() {
 					fatalf("error: %v", err)
 				}
 			})
+			im = correctAspect(im, name, *font, *aspectFilter)
 			writePNG(im, name, *output)
 		}
 		fatalf("%T does not support rendering images", parsed)
@@ -258,6 +275,10 @@ func writeGIF(im *gif.GIF, name, output string) {
 		fmt.Fprintf(os.Stderr, "%s: no output given, using %s\n", program, output)
 	}
 
+	if opts, ok := crtPreset(); ok {
+		timer("CRT emulation", func() { im = crt.ApplyGIF(im, opts) })
+	}
+
 	f, err := os.Create(output)
 	if err != nil {
 		fatalf("error creating %s: %v", output, err)
@@ -285,6 +306,10 @@ func writePNG(im image.Image, name, output string) {
 		fmt.Fprintf(os.Stderr, "%s: no output given, using %s\n", program, output)
 	}
 
+	if opts, ok := crtPreset(); ok {
+		timer("CRT emulation", func() { im = crt.Apply(im, opts) })
+	}
+
 	f, err := os.Create(output)
 	if err != nil {
 		fatalf("error creating %s: %v", output, err)
@@ -302,8 +327,20 @@ func writePNG(im image.Image, name, output string) {
 	os.Exit(0)
 }
 
+// crtPreset returns the selected CRT emulation preset, ok is false if CRT
+// emulation is disabled.
+func crtPreset() (opts crt.Options, ok bool) {
+	if crtOpt == "" {
+		return
+	}
+	if opts, ok = crt.Presets[strings.ToLower(crtOpt)]; !ok {
+		fatalf("unknown CRT preset %q", crtOpt)
+	}
+	return
+}
+
 type nullCloser struct {
-	io.Reader
+	io.ReadSeeker
 }
 
 func (nullCloser) Close() error { return nil }
@@ -5,16 +5,21 @@ import (
 	"image"
 	"io"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/textmodes/parser"
 	"github.com/textmodes/parser/format/sauce"
 	"github.com/textmodes/parser/text/ansi"
-	"github.com/textmodes/parser/text/binarytext"
-	"github.com/textmodes/parser/text/teletext"
-	"github.com/textmodes/parser/text/xbin"
+
+	// Formats
+	_ "github.com/textmodes/parser/image/ilbm"
+	_ "github.com/textmodes/parser/image/pcx"
+	_ "github.com/textmodes/parser/text/binarytext"
+	_ "github.com/textmodes/parser/text/teletext"
+	_ "github.com/textmodes/parser/text/tundradraw"
+	_ "github.com/textmodes/parser/text/xbin"
 )
 
 type imagerWithBlink interface {
@@ -57,7 +62,7 @@ func ansiParser(font string) func(io.Reader) (parser.Parser, error) {
 			fmt.Fprintf(os.Stderr, "%s: using font %q\n", program, font)
 		}
 
-		f, err := sauce.Font(font)
+		f, err := loadFont(font)
 		if err != nil {
 			return nil, err
 		}
@@ -71,62 +76,59 @@ func ansiParser(font string) func(io.Reader) (parser.Parser, error) {
 	}
 }
 
-var types = map[string]string{
-	"ansi":       ".ans",
-	"ascii":      ".asc",
-	"xbin":       ".xb",
-	"bin":        ".bin",
-	"binarytext": ".bin",
-	"ep1":        ".ep1",
-	"tti":        ".tti",
+// typeAlias are alternative names for the registered formats.
+var typeAlias = map[string]string{
+	"ascii": "ansi",
+	"bin":   "binarytext",
 }
 
 func listtypes() {
-	names := make([]string, 0, len(types))
-	for name := range types {
-		names = append(names, name)
-	}
-	sort.Strings(names)
-
 	fmt.Println("Supported types:")
-	for _, name := range names {
-		fmt.Println("  ", name)
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 20, 8, 0, '\t', 0)
+	for _, format := range parser.Formats() {
+		fmt.Fprintf(w, "   %s\t%s", format.Name, strings.Join(format.Extensions, " "))
+		var aliases []string
+		for aka, name := range typeAlias {
+			if name == format.Name {
+				aliases = append(aliases, aka)
+			}
+		}
+		if len(aliases) > 0 {
+			sort.Strings(aliases)
+			fmt.Fprintf(w, "\t(aka %s)", strings.Join(aliases, ", "))
+		}
+		fmt.Fprintln(w, "")
 	}
+	w.Flush()
 }
 
-func parseFor(kind, name, font string) (func(io.Reader) (parser.Parser, error), error) {
+func parseFor(kind, name, font string, r io.ReadSeeker) (func(io.Reader) (parser.Parser, error), error) {
+	var format parser.Format
 	if kind == "auto" {
-		switch org := strings.ToLower(filepath.Ext(name)); org {
-		case ".ans", ".asc", ".diz":
-			infof("parsing as ANSi")
+		detected, confidence, err := parser.Detect(r, name)
+		if err == parser.ErrUnknownFormat {
+			fmt.Fprintf(os.Stderr, "%s: no parser detected for %s; assuming it's ANSi\n",
+				program, name)
 			return ansiParser(font), nil
-
-		case ".ep1":
-			infof("parsing as TeleText (EP1)")
-			return func(r io.Reader) (parser.Parser, error) { return teletext.DecodeEP1(r) }, nil
-
-		case ".tti":
-			infof("parsing as TeleText (TTI)")
-			return func(r io.Reader) (parser.Parser, error) { return teletext.DecodeTTI(r) }, nil
-
-		case ".bin":
-			infof("parsing as BinaryText")
-			return func(r io.Reader) (parser.Parser, error) { return binarytext.Decode(r) }, nil
-
-		case ".xb":
-			infof("parsing as XBin")
-			return func(r io.Reader) (parser.Parser, error) { return xbin.Decode(r) }, nil
+		} else if err != nil {
+			return nil, err
+		}
+		infof("parsing as %s (confidence %d)", detected.Name, confidence)
+		format = detected
+	} else {
+		if alias, ok := typeAlias[kind]; ok {
+			kind = alias
+		}
+		var ok bool
+		if format, ok = parser.Lookup(kind); !ok {
+			return nil, fmt.Errorf(`unknown type %q (try "list" ?)`, kind)
 		}
-
-		fmt.Fprintf(os.Stderr, "%s: no parser detected for %s; assuming it's ANSi\n",
-			program, name)
-
-		return ansiParser(font), nil
 	}
 
-	if ext, ok := types[kind]; ok {
-		return parseFor("auto", ext, font)
+	if format.Name == "ansi" {
+		// Handles font selection and SAUCE width
+		return ansiParser(font), nil
 	}
-
-	return nil, fmt.Errorf(`unknown type %q (try "list" ?)`, kind)
+	return format.Decode, nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/textmodes/parser/chargen"
 	"github.com/textmodes/parser/data"
+	"golang.org/x/text/encoding/charmap"
 )
 
 // Fonts are the supported fonts (and their chargen file name).
@@ -15,48 +16,56 @@ var Fonts = map[string]FontInfo{
 	"Amiga MicroKnight": FontInfo{
 		ROM:          "amiga_microknight.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   AmigaPixelRatio,
 	},
 	"Amiga MicroKnight+": FontInfo{
 		ROM:          "amiga_microknight+.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   AmigaPixelRatio,
 	},
 	"Amiga mOsOul": FontInfo{
 		ROM:          "amiga_mosoul.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   AmigaPixelRatio,
 	},
 	"Amiga P0T-NOoDLE": FontInfo{
 		ROM:          "amiga_p0t-noodle.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   AmigaPixelRatio,
 	},
 	"Amiga Topaz 1": FontInfo{
 		ROM:          "amiga_topaz_1.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   AmigaPixelRatio,
 	},
 	"Amiga Topaz 1+": FontInfo{
 		ROM:          "amiga_topaz_1+.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   AmigaPixelRatio,
 	},
 	"Amiga Topaz 2": FontInfo{
 		ROM:          "amiga_topaz_2.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   AmigaPixelRatio,
 	},
 	"Amiga Topaz 2+": FontInfo{
 		ROM:          "amiga_topaz_2+.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   AmigaPixelRatio,
 	},
@@ -64,6 +73,7 @@ var Fonts = map[string]FontInfo{
 	"Atari ATASCII": FontInfo{
 		ROM:          "atari_atascii.bin",
 		Size:         image.Pt(8, 8),
+		Screen:       image.Pt(40, 24),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   Ratio{4, 5},
 	},
@@ -71,163 +81,190 @@ var Fonts = map[string]FontInfo{
 	// IBM PC fonts copyright IBM corporation
 	"IBM EGA": FontInfo{
 		ROM:          "ibm_ega43.bin",
-		Size:         image.Pt(8, 16),
+		Size:         image.Pt(8, 14),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   Ratio{35, 48},
 	},
 	"IBM EGA43": FontInfo{
 		ROM:          "ibm_ega43.bin",
-		Size:         image.Pt(8, 16),
+		Size:         image.Pt(8, 14),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   Ratio{35, 48},
 	},
 	"IBM VGA": FontInfo{
 		ROM:          "ibm_vga.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 437": FontInfo{
 		ROM:          "ibm_vga_437.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 737": FontInfo{
 		ROM:          "ibm_vga_737.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 775": FontInfo{
 		ROM:          "ibm_vga_775.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 850": FontInfo{
 		ROM:          "ibm_vga_850.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 852": FontInfo{
 		ROM:          "ibm_vga_852.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 855": FontInfo{
 		ROM:          "ibm_vga_855.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 857": FontInfo{
 		ROM:          "ibm_vga_857.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 860": FontInfo{
 		ROM:          "ibm_vga_860.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 861": FontInfo{
 		ROM:          "ibm_vga_861.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 862": FontInfo{
 		ROM:          "ibm_vga_862.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 863": FontInfo{
 		ROM:          "ibm_vga_863.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 865": FontInfo{
 		ROM:          "ibm_vga_865.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 866": FontInfo{
 		ROM:          "ibm_vga_866.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 866b": FontInfo{
 		ROM:          "ibm_vga_866b.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 866c": FontInfo{
 		ROM:          "ibm_vga_866c.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 866u": FontInfo{
 		ROM:          "ibm_vga_866u.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 869": FontInfo{
 		ROM:          "ibm_vga_869.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA 1251": FontInfo{
 		ROM:          "ibm_vga_1251.bin",
 		Size:         image.Pt(8, 16),
+		Screen:       image.Pt(80, 25),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA50": FontInfo{
 		ROM:          "ibm_vga50.bin",
 		Size:         image.Pt(8, 8),
+		Screen:       image.Pt(80, 50),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA50 437": FontInfo{
 		ROM:          "ibm_vga50_437.bin",
 		Size:         image.Pt(8, 8),
+		Screen:       image.Pt(80, 50),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA50 850": FontInfo{
 		ROM:          "ibm_vga50_450.bin",
 		Size:         image.Pt(8, 8),
+		Screen:       image.Pt(80, 50),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA50 865": FontInfo{
 		ROM:          "ibm_vga50_865.bin",
 		Size:         image.Pt(8, 8),
+		Screen:       image.Pt(80, 50),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA50 866": FontInfo{
 		ROM:          "ibm_vga50_866.bin",
 		Size:         image.Pt(8, 8),
+		Screen:       image.Pt(80, 50),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
 	"IBM VGA50 1251": FontInfo{
 		ROM:          "ibm_vga50_1251.bin",
 		Size:         image.Pt(8, 8),
+		Screen:       image.Pt(80, 50),
 		DisplayRatio: CRTDisplayRatio,
 		PixelRatio:   IBMPCPixelRatio,
 	},
@@ -258,6 +295,10 @@ type FontInfo struct {
 	// Size of the characters.
 	Size image.Point
 
+	// Screen is the size of the text mode screen in characters, the display
+	// ratio applies to a screen of this size.
+	Screen image.Point
+
 	// DisplayRatio is the aspect ratio of the display device the font was
 	// intended for. Up until around 2003 the common display device was either a
 	// CRT computer monitor or a CRT TV which usually had a display aspect ratio
@@ -269,7 +310,9 @@ type FontInfo struct {
 	// LED and plasma) tend to have square pixels or at least as near square as
 	// is technically feasible, because square pixels make it easy to draw squares
 	// and circles. For various technical reasons square pixels have not always
-	// been the norm however.
+	// been the norm however. This is the ratio of the pixels of the original
+	// hardware; the ROMs may scale the characters, see Record.PixelRatio for
+	// the ratio of the rendered pixels.
 	PixelRatio Ratio
 }
 
@@ -279,19 +322,89 @@ func Font(name string) (*chargen.Font, error) {
 		name = "ibm_vga"
 	}
 
+	other, info, ok := lookupFont(name)
+	if !ok {
+		return nil, fmt.Errorf("sauce: font %q not supported", name)
+	}
+	data, err := data.Bytes(fmt.Sprintf("font/chargen/%s", info.ROM))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		opts = chargen.MaskOptions{Size: info.Size}
+		font = chargen.New(chargen.NewBytesMask(data, opts))
+	)
+	if cm := fontCodePage(other); cm != nil {
+		font.Runes = chargen.RuneMap(cm)
+	}
+	return font, nil
+}
+
+// Runes returns the rune for each character of a SAUCE font name, or nil if
+// the character set of the font is unknown.
+func Runes(name string) []rune {
+	if strings.TrimSpace(name) == "" {
+		name = "ibm_vga"
+	}
+
+	other, _, ok := lookupFont(name)
+	switch {
+	case !ok:
+		return nil
+	case strings.HasPrefix(other, "Atari "):
+		return chargen.ATASCIIRunes()
+	}
+	if cm := fontCodePage(other); cm != nil {
+		return chargen.CodePageRunes(cm)
+	}
+	return nil
+}
+
+// LookupFont returns the information for a SAUCE font name.
+func LookupFont(name string) (FontInfo, bool) {
+	_, info, ok := lookupFont(name)
+	return info, ok
+}
+
+func lookupFont(name string) (string, FontInfo, bool) {
 	clean := cleanFontName(name)
 	for other, info := range Fonts {
 		if cleanFontName(other) == clean {
-			data, err := data.Bytes(fmt.Sprintf("font/chargen/%s", info.ROM))
-			if err != nil {
-				return nil, err
-			}
-			opts := chargen.MaskOptions{Size: info.Size}
-			return chargen.New(chargen.NewBytesMask(data, opts)), nil
+			return other, info, true
 		}
 	}
+	return "", FontInfo{}, false
+}
+
+// fontCodePages are the code pages for the IBM fonts, by suffix.
+var fontCodePages = map[string]*charmap.Charmap{
+	"437":  charmap.CodePage437,
+	"850":  charmap.CodePage850,
+	"852":  charmap.CodePage852,
+	"855":  charmap.CodePage855,
+	"860":  charmap.CodePage860,
+	"862":  charmap.CodePage862,
+	"863":  charmap.CodePage863,
+	"865":  charmap.CodePage865,
+	"866":  charmap.CodePage866,
+	"1251": charmap.Windows1251,
+}
 
-	return nil, fmt.Errorf("sauce: font %q not supported", name)
+// fontCodePage returns the code page of a font, or nil if it is unknown.
+func fontCodePage(name string) *charmap.Charmap {
+	switch {
+	case strings.HasPrefix(name, "Amiga "):
+		return charmap.ISO8859_1
+	case strings.HasPrefix(name, "IBM "):
+		fields := strings.Fields(name)
+		if len(fields) == 2 {
+			// IBM fonts without code page use the default code page 437
+			return charmap.CodePage437
+		}
+		return fontCodePages[fields[len(fields)-1]]
+	default:
+		return nil
+	}
 }
 
 func cleanFontName(name string) string {
@@ -0,0 +1,88 @@
+package sauce
+
+import "testing"
+
+func TestFontRunes(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Rune rune
+		Want uint16
+	}{
+		{"IBM VGA", '☺', 0x01},
+		{"IBM VGA 850", 'ø', 0x9b},
+		{"IBM VGA50 866", 'Ж', 0x86},
+		{"Amiga Topaz 1", 'é', 0xe9},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			font, err := Font(test.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if char, ok := font.Glyph(test.Rune); !ok || char != test.Want {
+				t.Fatalf("rune %q: expected %#02x, got %#02x", test.Rune, test.Want, char)
+			}
+		})
+	}
+
+	if cm := fontCodePage("Atari ATASCII"); cm != nil {
+		t.Fatal("expected no code page for ATASCII")
+	}
+}
+
+func TestRunes(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Char int
+		Want rune
+	}{
+		{"", 0xdb, '█'},
+		{"IBM VGA 866", 0x86, 'Ж'},
+		{"Amiga Topaz 1+", 0xe9, 'é'},
+		{"Atari ATASCII", 0x14, '●'},
+		{"Atari ATASCII", 0xc1, 'A'},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			runes := Runes(test.Name)
+			if len(runes) != 256 {
+				t.Fatalf("expected 256 runes, got %d", len(runes))
+			}
+			if r := runes[test.Char]; r != test.Want {
+				t.Fatalf("character %#02x: expected %q, got %q", test.Char, test.Want, r)
+			}
+		})
+	}
+
+	if runes := Runes("Comic Sans"); runes != nil {
+		t.Fatal("expected no runes for unknown font")
+	}
+}
+
+func TestPixelRatio(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Record *Record
+		Want   Ratio
+	}{
+		{"nil", nil, Ratio{5, 6}},
+		{"vga 9 dot", &Record{Info: "IBM VGA", Flags: &ANSiFlags{LetterSpacing: LetterSpacing9Pixel}}, IBMPCPixelRatio},
+		{"vga 8 dot", &Record{Info: "IBM VGA 437", Flags: &ANSiFlags{LetterSpacing: LetterSpacing8Pixel}}, Ratio{5, 6}},
+		{"ega", &Record{Info: "IBM EGA"}, Ratio{35, 48}},
+		{"vga50", &Record{Info: "IBM VGA50", Flags: &ANSiFlags{LetterSpacing: LetterSpacing9Pixel}}, IBMPCPixelRatio},
+		{"amiga", &Record{Info: "Amiga Topaz 1"}, Ratio{5, 6}},
+		{"atari", &Record{Info: "Atari ATASCII"}, Ratio{4, 5}},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			ratio, ok := test.Record.PixelRatio()
+			if !ok {
+				t.Fatal("expected pixel ratio")
+			}
+			if ratio != test.Want {
+				t.Fatalf("expected %v, got %v", test.Want, ratio)
+			}
+		})
+	}
+
+	if _, ok := (&Record{Info: "Comic Sans"}).PixelRatio(); ok {
+		t.Fatal("expected no pixel ratio for unknown font")
+	}
+}
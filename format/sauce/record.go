@@ -285,13 +285,65 @@ func hasANSiFlags(record *Record) bool {
 	switch record.DataType {
 	case Character:
 		return record.FileType < 3
-	case BinaryText:
+	case BinaryText, XBIN:
+		// XBin uses the letter spacing, see package xbin
 		return true
 	default:
 		return false
 	}
 }
 
+// NineDot checks if the record requests 9 pixel letter spacing.
+func (record *Record) NineDot() bool {
+	return record != nil && record.Flags != nil && record.Flags.LetterSpacing == LetterSpacing9Pixel
+}
+
+// Stretch checks if the record requests stretching the pixels to the aspect
+// ratio of a legacy display, on displays with square pixels.
+func (record *Record) Stretch() bool {
+	return record != nil && record.Flags != nil && record.Flags.AspectRatio == AspectRatioStretch
+}
+
+// PixelRatio returns the aspect ratio of the pixels of a piece rendered with
+// the font in the record, ok is false if the font is unknown. The ratio follows
+// from the display ratio and the size of the screen in rendered pixels, so
+// line doubled ROMs like the Amiga fonts get the ratio of the doubled pixels.
+// The VGA fonts are rendered 9 pixels wide with 9 pixel letter spacing.
+func (record *Record) PixelRatio() (ratio Ratio, ok bool) {
+	var name string
+	if record != nil {
+		name = record.Info
+	}
+	if strings.TrimSpace(name) == "" {
+		name = "IBM VGA"
+	}
+	name, info, ok := lookupFont(name)
+	if !ok {
+		return Ratio{}, false
+	}
+	size := info.Size
+	if strings.HasPrefix(name, "IBM VGA") && record.NineDot() {
+		size.X = 9
+	}
+	var (
+		width  = info.Screen.X * size.X
+		height = info.Screen.Y * size.Y
+	)
+	ratio = Ratio{info.DisplayRatio.X * height, info.DisplayRatio.Y * width}
+	if d := gcd(ratio.X, ratio.Y); d > 1 {
+		ratio.X /= d
+		ratio.Y /= d
+	}
+	return ratio, ratio.X > 0 && ratio.Y > 0
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
 // Font for the SAUCE record, based on the Info attribute.
 func (record *Record) Font() (*chargen.Font, error) {
 	if record == nil {
@@ -6,49 +6,34 @@ import (
 )
 
 // BlankCharacter is a space with black background and white foreground and no
-// attributes set.
-var BlankCharacter = MakeCharacter(' ', RGB(0xaaaaaa), RGB(0x000000))
+// attributes set; the colors are palette indexes 0 and 7.
+var BlankCharacter = MakeIndexedCharacter(' ', 7, 0)
 
 const (
 	bgMask   = 0xffffff0000000000
 	fgMask   = 0x000000ffffff0000
 	attrMask = 0x000000000000ff00
 	charMask = 0x00000000000000ff
+
+	// Extended word
+	bgIndexMask  = 0xff00000000000000
+	fgIndexMask  = 0x00ff000000000000
+	glyphMask    = 0x0000ff0000000000
+	lineMask     = 0x000000ffffff0000
+	bgIndexSet   = 0x0000000000000400
+	fgIndexSet   = 0x0000000000000200
+	lineColorSet = 0x0000000000000100
+	extAttrMask  = 0x00000000000000ff
 )
 
 // Attribute for Character memory.
-type Attribute uint8
+type Attribute uint16
 
 func (attr Attribute) String() string {
 	var s []string
-	for _, a := range []Attribute{
-		Bold,
-		Faint,
-		Standout,
-		Underline,
-		Blink,
-		Reverse,
-		Conceal,
-	} {
-		if attr&a == a {
-			switch a {
-			case Bold:
-				s = append(s, "bold")
-			case Faint:
-				s = append(s, "faint")
-			case Standout:
-				s = append(s, "standout")
-			case Underline:
-				s = append(s, "underline")
-			case Blink:
-				s = append(s, "blink")
-			case CrossedOut:
-				s = append(s, "crossed out")
-			case Reverse:
-				s = append(s, "reverse")
-			case Conceal:
-				s = append(s, "conceal")
-			}
+	for _, a := range attributeNames {
+		if attr&a.Attribute == a.Attribute {
+			s = append(s, a.Name)
 		}
 	}
 	if len(s) == 0 {
@@ -57,6 +42,23 @@ func (attr Attribute) String() string {
 	return strings.Join(s, ",")
 }
 
+// attributeNames are the names of the attributes, in String order.
+var attributeNames = []struct {
+	Attribute
+	Name string
+}{
+	{Bold, "bold"},
+	{Faint, "faint"},
+	{Standout, "standout"},
+	{Underline, "underline"},
+	{DoubleUnderline, "double underline"},
+	{Overline, "overline"},
+	{Blink, "blink"},
+	{CrossedOut, "crossed out"},
+	{Reverse, "reverse"},
+	{Conceal, "conceal"},
+}
+
 // Attributes
 const (
 	Bold Attribute = 1 << iota
@@ -67,12 +69,15 @@ const (
 	CrossedOut
 	Reverse
 	Conceal
+	DoubleUnderline
+	Overline
 	Invisible = Conceal
 )
 
 /*
 Character is screen character; unlike 16-bit VGA screen characters we also
-support 24-bit colors and extended attributes. The layout is as follows:
+support 24-bit colors and extended attributes. The character consists of two
+64-bit words, the layout of the first word is as follows:
 
    76543210 76543210 76543210 76543210 76543210 76543210 76543210 76543210
 	+--------+--------+--------+--------+--------+--------+--------+--------+
@@ -92,78 +97,173 @@ support 24-bit colors and extended attributes. The layout is as follows:
 						 C = conceal
 	fore:      foreground color (24-bit RGB)
 	back:      background color (24-bit RGB)
+
+The layout of the second word is as follows:
+
+   76543210 76543210 76543210 76543210 76543210 76543210 76543210 76543210
+	+--------+--------+--------+--------+--------+--------+--------+--------+
+	|  back  |  fore  | glyph  |  line  |  line  |  line  | flags  |  attr  |
+	| index  | index  |  high  |  red   |  green |  blue  |     BFL|      OD|
+	+--------+--------+--------+--------+--------+--------+--------+--------+
+
+	attr:      extended attributes
+						 D = double underline
+						 O = overline
+	flags:     L = underline color is set
+						 F = foreground color is a palette index
+						 B = background color is a palette index
+	line:      underline color (24-bit RGB)
+	glyph:     high byte of the glyph index, for fonts with more than 256
+	           characters
+	fore:      foreground palette index
+	back:      background palette index
+
+Colors set by palette index keep their 24-bit RGB value as well, which is the
+palette color at the time the index was set. The palette index takes
+precedence when rendering, so the colors follow changes to the palette of the
+Text. Setting an RGB color clears the palette index.
 */
-type Character uint64
+type Character struct {
+	cell, ext uint64
+}
 
 // MakeCharacter returns a Character with colors.
 func MakeCharacter(cp uint8, fg, bg color.Color) Character {
-	char := Character(cp)
-	char |= color24Bit(fg) << 16
-	char |= color24Bit(bg) << 40
+	return Character{cell: uint64(cp) | color24Bit(fg)<<16 | color24Bit(bg)<<40}
+}
+
+// MakeIndexedCharacter returns a Character with colors by index in Palette.
+func MakeIndexedCharacter(cp uint8, fg, bg uint8) Character {
+	char := MakeCharacter(cp, Palette[fg], Palette[bg])
+	char.SetForegroundIndex(fg)
+	char.SetBackgroundIndex(bg)
 	return char
 }
 
 // Reset the character to space with selected colors.
 func (char *Character) Reset(fg, bg color.Color) {
-	*char = Character(' ') | color24Bit(fg)<<16 | color24Bit(bg)<<40
+	*char = MakeCharacter(' ', fg, bg)
 }
 
 // BackgroundColor returns the current background color.
 func (char Character) BackgroundColor() color.Color {
-	return RGB((char & bgMask) >> 40)
+	return RGB((char.cell & bgMask) >> 40)
 }
 
 // SetBackgroundColor updates the background color.
 func (char *Character) SetBackgroundColor(c color.Color) {
-	*char &= ^Character(bgMask) // clear
-	*char |= color24Bit(c) << 40
+	char.cell &^= bgMask // clear
+	char.cell |= color24Bit(c) << 40
+	char.ext &^= bgIndexMask | bgIndexSet
+}
+
+// BackgroundIndex returns the palette index of the background color, ok is
+// false if the background color is an RGB color.
+func (char Character) BackgroundIndex() (i uint8, ok bool) {
+	return uint8(char.ext >> 56), char.ext&bgIndexSet != 0
+}
+
+// SetBackgroundIndex updates the background color to palette index i, the
+// RGB background color is left as is; see Text.SetBackgroundIndex.
+func (char *Character) SetBackgroundIndex(i uint8) {
+	char.ext &^= bgIndexMask // clear
+	char.ext |= uint64(i)<<56 | bgIndexSet
 }
 
 // ForegroundColor returns the current foreground color.
 func (char Character) ForegroundColor() color.Color {
-	return RGB((char & fgMask) >> 16)
+	return RGB((char.cell & fgMask) >> 16)
 }
 
 // SetForegroundColor updates the foreground color.
 func (char *Character) SetForegroundColor(c color.Color) {
-	*char &= ^Character(fgMask) // clear
-	*char |= color24Bit(c) << 16
+	char.cell &^= fgMask // clear
+	char.cell |= color24Bit(c) << 16
+	char.ext &^= fgIndexMask | fgIndexSet
+}
+
+// ForegroundIndex returns the palette index of the foreground color, ok is
+// false if the foreground color is an RGB color.
+func (char Character) ForegroundIndex() (i uint8, ok bool) {
+	return uint8(char.ext >> 48), char.ext&fgIndexSet != 0
+}
+
+// SetForegroundIndex updates the foreground color to palette index i, the
+// RGB foreground color is left as is; see Text.SetForegroundIndex.
+func (char *Character) SetForegroundIndex(i uint8) {
+	char.ext &^= fgIndexMask // clear
+	char.ext |= uint64(i)<<48 | fgIndexSet
+}
+
+// UnderlineColor returns the underline color, ok is false if the underline
+// has the foreground color.
+func (char Character) UnderlineColor() (c color.Color, ok bool) {
+	if char.ext&lineColorSet == 0 {
+		return nil, false
+	}
+	return RGB((char.ext & lineMask) >> 16), true
+}
+
+// SetUnderlineColor updates the underline color.
+func (char *Character) SetUnderlineColor(c color.Color) {
+	char.ext &^= lineMask // clear
+	char.ext |= color24Bit(c)<<16 | lineColorSet
+}
+
+// ResetUnderlineColor makes the underline use the foreground color.
+func (char *Character) ResetUnderlineColor() {
+	char.ext &^= lineMask | lineColorSet
 }
 
 // Attributes returns all attributes.
 func (char Character) Attributes() Attribute {
-	return Attribute((char & attrMask) >> 8)
+	return Attribute((char.cell&attrMask)>>8 | (char.ext&extAttrMask)<<8)
 }
 
 // ClearAttributes clears all attributes.
 func (char *Character) ClearAttributes() {
-	*char &= ^Character(attrMask)
+	char.cell &^= attrMask
+	char.ext &^= extAttrMask
 }
 
 // ClearAttribute clears attribute a.
 func (char *Character) ClearAttribute(a Attribute) {
-	*char &= ^Character(a) << 8
+	char.SetAttributes(char.Attributes() &^ a)
 }
 
 // SetAttribute sets attribute a.
 func (char *Character) SetAttribute(a Attribute) {
-	*char |= Character(a) << 8
+	char.SetAttributes(char.Attributes() | a)
 }
 
 // SetAttributes updates all attributes with value a.
 func (char *Character) SetAttributes(a Attribute) {
-	*char &= ^Character(attrMask)
-	*char |= Character(a) << 8
+	char.ClearAttributes()
+	char.cell |= uint64(a&0xff) << 8
+	char.ext |= uint64(a >> 8)
 }
 
-// CodePoint of the character.
+// CodePoint of the character, this is the low byte of the glyph index.
 func (char Character) CodePoint() uint8 {
-	return uint8(char)
+	return uint8(char.cell)
 }
 
-// SetCodePoint updates the code point of the character.
+// SetCodePoint updates the code point of the character, selecting a glyph
+// from the first 256 characters of the font.
 func (char *Character) SetCodePoint(v uint8) {
-	*char = (*char & 0xff00) | Character(v)
+	char.SetGlyph(uint16(v))
+}
+
+// Glyph is the index of the glyph in the font, for fonts with more than 256
+// characters such as 512 character VGA fonts.
+func (char Character) Glyph() uint16 {
+	return uint16(char.cell&charMask) | uint16((char.ext&glyphMask)>>32)
+}
+
+// SetGlyph updates the index of the glyph in the font.
+func (char *Character) SetGlyph(v uint16) {
+	char.cell = char.cell&^charMask | uint64(v&0xff)
+	char.ext = char.ext&^glyphMask | uint64(v>>8)<<40
 }
 
 // TextBuffer is a slice of Character.
@@ -177,10 +277,10 @@ func newCharacters(size uint) TextBuffer {
 	return buffer
 }
 
-func color24Bit(c color.Color) (o Character) {
+func color24Bit(c color.Color) (o uint64) {
 	r, g, b, _ := c.RGBA()
-	o |= (Character(r>>8) & 0xff) << 16
-	o |= (Character(g>>8) & 0xff) << 8
-	o |= (Character(b>>8) & 0xff)
+	o |= (uint64(r>>8) & 0xff) << 16
+	o |= (uint64(g>>8) & 0xff) << 8
+	o |= (uint64(b>>8) & 0xff)
 	return
 }
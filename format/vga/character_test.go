@@ -42,6 +42,10 @@ func TestAttribute(t *testing.T) {
 	if s := a.String(); s != "underline,blink,reverse,conceal" {
 		t.Fatalf(`expected "underline,blink,reverse,conceal", got %q`, s)
 	}
+	a = DoubleUnderline | Overline | CrossedOut
+	if s := a.String(); s != "double underline,overline,crossed out" {
+		t.Fatalf(`expected "double underline,overline,crossed out", got %q`, s)
+	}
 }
 
 func TestCharacter(t *testing.T) {
@@ -94,4 +98,60 @@ func TestCharacter(t *testing.T) {
 	if p := c.CodePoint(); p != 0x2a {
 		t.Fatalf("expected code point %#02x, got %#02x", 0x2a, p)
 	}
+	if v := c.ForegroundColor(); !colorEqual(v, color.White) {
+		t.Fatalf("expected white foreground after setting code point, got %v", v)
+	}
+
+	c.SetAttributes(Bold | Overline | DoubleUnderline)
+	c.ClearAttribute(DoubleUnderline)
+	if a := c.Attributes(); a != Bold|Overline {
+		t.Fatalf("expected %s, got %s", Bold|Overline, a)
+	}
+	if _, ok := c.UnderlineColor(); ok {
+		t.Fatal("expected no underline color")
+	}
+	c.SetUnderlineColor(Red)
+	if v, ok := c.UnderlineColor(); !ok || !colorEqual(v, Red) {
+		t.Fatalf("expected red underline color, got %v", v)
+	}
+	if v := c.ForegroundColor(); !colorEqual(v, color.White) {
+		t.Fatalf("expected white foreground after setting underline color, got %v", v)
+	}
+	c.ResetUnderlineColor()
+	if _, ok := c.UnderlineColor(); ok {
+		t.Fatal("expected no underline color after reset")
+	}
+	if a := c.Attributes(); a != Bold|Overline {
+		t.Fatalf("expected %s, got %s", Bold|Overline, a)
+	}
+}
+
+func TestCharacterIndex(t *testing.T) {
+	c := BlankCharacter
+	if i, ok := c.ForegroundIndex(); !ok || i != 7 {
+		t.Fatalf("expected foreground index 7, got %d (%t)", i, ok)
+	}
+	if i, ok := c.BackgroundIndex(); !ok || i != 0 {
+		t.Fatalf("expected background index 0, got %d (%t)", i, ok)
+	}
+
+	c.SetForegroundIndex(12)
+	c.SetBackgroundIndex(200)
+	if i, ok := c.ForegroundIndex(); !ok || i != 12 {
+		t.Fatalf("expected foreground index 12, got %d (%t)", i, ok)
+	}
+	if i, ok := c.BackgroundIndex(); !ok || i != 200 {
+		t.Fatalf("expected background index 200, got %d (%t)", i, ok)
+	}
+	if v := c.ForegroundColor(); !colorEqual(v, White) {
+		t.Fatalf("expected RGB foreground to be kept, got %v", v)
+	}
+
+	c.SetForegroundColor(Red)
+	if _, ok := c.ForegroundIndex(); ok {
+		t.Fatal("expected RGB foreground to clear the index")
+	}
+	if i, ok := c.BackgroundIndex(); !ok || i != 200 {
+		t.Fatalf("expected background index 200, got %d (%t)", i, ok)
+	}
 }
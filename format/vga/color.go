@@ -89,6 +89,14 @@ var Palette = color.Palette{
 	BrightWhite,
 }
 
+// AttributeIndex converts the 4-bit color of a VGA attribute byte to the index
+// in Palette. VGA attributes have blue in the lowest bit and red in the third,
+// where the ANSI order of Palette is the other way around. The conversion is
+// its own inverse, so it also converts an index to the attribute color.
+func AttributeIndex(v uint8) uint8 {
+	return v&0xfa | (v&0x01)<<2 | (v&0x04)>>2
+}
+
 // ColorIndex returns the index in the palette.
 func ColorIndex(c color.Color, p color.Palette) int {
 	for i, o := range p {
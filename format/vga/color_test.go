@@ -34,3 +34,18 @@ func testColorEqual(t *testing.T, a, b color.Color) {
 		t.Fatalf("expected %v to equal %v", a, b)
 	}
 }
+
+func TestAttributeIndex(t *testing.T) {
+	for v, want := range []RGB{
+		Black, Blue, Green, Cyan, Red, Magenta, Yellow, White,
+		BrightBlack, BrightBlue, BrightGreen, BrightCyan, BrightRed, BrightMagenta, BrightYellow, BrightWhite,
+	} {
+		i := AttributeIndex(uint8(v))
+		if c := ToRGB(Palette[i]); c != want {
+			t.Errorf("attribute color %d: expected %s, got %s", v, want, c)
+		}
+		if j := AttributeIndex(i); j != uint8(v) {
+			t.Errorf("attribute color %d: expected inverse %d, got %d", v, v, j)
+		}
+	}
+}
@@ -26,11 +26,24 @@ type Text struct {
 	// DisableBlink disables blinking and enabled high intensity background colors.
 	DisableBlink bool
 
+	// NineDot renders characters 9 pixels wide, like VGA hardware does in
+	// 9 dot text modes, see chargen.AddLineGraphicsColumn.
+	NineDot bool
+
+	// BoldSmear renders bold characters smeared one pixel to the right, like
+	// AmigaOS does, in addition to using the high intensity color.
+	BoldSmear bool
+
+	// UndoLimit is the maximum number of edits kept for Undo, if zero, all
+	// edits are kept.
+	UndoLimit int
+
 	width, height       uint
 	scrollRegion        [2]uint
 	scrollRegionActive  bool
 	cursor, savedCursor *textCursor
 	progressFunc        func(float64)
+	journal             journal
 }
 
 // NewText allocates a new (width * height) buffer. You may also call
@@ -110,7 +123,8 @@ func (text *Text) Crop(r image.Rectangle) *Text {
 // expanded area may disappear.
 //
 // If the cursor is outside of the new buffer, it will be moved up and left
-// until it is within the buffer's bounding box.
+// until it is within the buffer's bounding box. Resizing clears the edits
+// recorded for Undo and Redo.
 func (text *Text) Resize(width, height uint) {
 	if width < 1 || height < 1 || (width == text.width && height == text.height) {
 		// fast path
@@ -143,13 +157,22 @@ func (text *Text) Resize(width, height uint) {
 	// swap buffer
 	text.Buffer = buffer
 	text.width, text.height = width, height
+	text.ClearJournal()
 
-	// move the cursor (if required)
-	if text.cursor.Y > height {
-		text.cursor.Y = height - 1
+	// move the cursors (if required)
+	for _, cursor := range []*textCursor{text.cursor, text.savedCursor} {
+		if cursor == nil {
+			continue
+		}
+		if cursor.Y > height {
+			cursor.Y = height - 1
+		}
+		if cursor.X >= width {
+			cursor.X = width - 1
+		}
 	}
-	if text.cursor.X > width {
-		text.cursor.X = width - 1
+	if text.scrollRegionActive && text.scrollRegion[1] >= height {
+		text.SetScrollRegion(text.scrollRegion[0], text.scrollRegion[1])
 	}
 }
 
@@ -202,8 +225,12 @@ func (text *Text) SetScrollRegion(top, bot uint) {
 		return
 	}
 
-	if bot > text.height {
-		bot = text.height
+	if bot >= text.height {
+		bot = text.height - 1
+	}
+	if top > bot || text.height == 0 {
+		text.scrollRegionActive = false
+		return
 	}
 
 	text.scrollRegion[0] = top
@@ -235,6 +262,18 @@ func (text *Text) SetAttribute(a Attribute) {
 	tracef("attr to %s", text.cursor.Attributes())
 }
 
+// SetUnderlineColor sets the cursor underline color.
+func (text *Text) SetUnderlineColor(c color.Color) {
+	tracef("underline to %#+v", c)
+	text.cursor.SetUnderlineColor(c)
+}
+
+// ResetUnderlineColor makes the cursor underline use the foreground color.
+func (text *Text) ResetUnderlineColor() {
+	tracef("underline to foreground")
+	text.cursor.ResetUnderlineColor()
+}
+
 // SetForegroundColor sets the cursor foreground color.
 func (text *Text) SetForegroundColor(c color.Color) {
 	tracef("fg to %#+v", c)
@@ -247,6 +286,90 @@ func (text *Text) SetBackgroundColor(c color.Color) {
 	text.cursor.SetBackgroundColor(c)
 }
 
+// SetForegroundIndex sets the cursor foreground color to palette index i.
+func (text *Text) SetForegroundIndex(i uint8) {
+	tracef("fg to index %d", i)
+	if palette := text.palette(); int(i) < len(palette) {
+		text.cursor.SetForegroundColor(palette[i])
+	}
+	text.cursor.SetForegroundIndex(i)
+}
+
+// SetBackgroundIndex sets the cursor background color to palette index i.
+func (text *Text) SetBackgroundIndex(i uint8) {
+	tracef("bg to index %d", i)
+	if palette := text.palette(); int(i) < len(palette) {
+		text.cursor.SetBackgroundColor(palette[i])
+	}
+	text.cursor.SetBackgroundIndex(i)
+}
+
+// CellColors returns the foreground and background colors of a character,
+// using the current palette for palette indexed colors.
+func (text *Text) CellColors(char Character) (fg, bg color.Color) {
+	palette := text.palette()
+	fg, bg = char.ForegroundColor(), char.BackgroundColor()
+	if i, ok := char.ForegroundIndex(); ok && int(i) < len(palette) {
+		fg = palette[i]
+	}
+	if i, ok := char.BackgroundIndex(); ok && int(i) < len(palette) {
+		bg = palette[i]
+	}
+	return
+}
+
+// CellAttribute returns the 8-bit VGA attribute of a character as rendered:
+// reverse video swaps the colors, bold and blink select the bright colors and
+// conceal hides the foreground. Colors outside the first 16 are mapped to the
+// nearest of them. Bit 7 is the blink bit, or the bright background bit if
+// blink is disabled.
+func (text *Text) CellAttribute(char Character) uint8 {
+	palette := text.palette()
+	if len(palette) > 16 {
+		palette = palette[:16]
+	}
+	var (
+		attr    = char.Attributes()
+		fi, fok = char.ForegroundIndex()
+		bi, bok = char.BackgroundIndex()
+	)
+	if !fok || int(fi) >= len(palette) {
+		fi = uint8(palette.Index(char.ForegroundColor()))
+	}
+	if !bok || int(bi) >= len(palette) {
+		bi = uint8(palette.Index(char.BackgroundColor()))
+	}
+	if attr&Reverse == Reverse {
+		fi, bi = bi, fi
+	}
+	if attr&Bold == Bold {
+		fi |= 8
+	}
+	if attr&Blink == Blink {
+		bi |= 8
+	}
+	if attr&Conceal == Conceal {
+		if fi = bi; !text.DisableBlink {
+			fi &= 7
+		}
+	}
+	return AttributeIndex(fi) | AttributeIndex(bi)<<4
+}
+
+// palette is the palette of the buffer, or the VGA palette if none is set.
+func (text *Text) palette() color.Palette {
+	if text.Palette == nil {
+		return Palette
+	}
+	return text.Palette
+}
+
+// ActivePalette returns the palette used for rendering, which is the VGA
+// palette if none is set.
+func (text *Text) ActivePalette() color.Palette {
+	return text.palette()
+}
+
 // Goto moves the cursor to (x, y).
 func (text *Text) Goto(x, y uint) {
 	var ox, oy = text.cursor.X, text.cursor.Y
@@ -288,10 +411,11 @@ func (text *Text) LoadCursor() {
 	text.cursor.X, text.cursor.Y = text.savedCursor.X, text.savedCursor.Y
 }
 
-// WriteCodePoint writes a code point byte to the screen and advance the
-// cursor. If the cursor would move beyond the screen buffer and AutoExpand is
-// enabled, a new row is added to the buffer; if not enabled, the buffer will
-// scroll up a line before adding the code point.
+// WriteCodePoint writes a code point to the screen and advance the cursor; code
+// points above 0xff select glyphs of fonts with more than 256 characters. If
+// the cursor would move beyond the screen buffer and AutoExpand is enabled, a
+// new row is added to the buffer; if not enabled, the buffer will scroll up a
+// line before adding the code point.
 func (text *Text) WriteCodePoint(cp uint16) {
 	offset := text.cursor.Offset(text.width)
 	tracef("write %d/%d", offset, len(text.Buffer))
@@ -312,8 +436,7 @@ func (text *Text) WriteCodePoint(cp uint16) {
 	}
 
 	text.Buffer[offset] = text.cursor.Character // copy attributes
-	text.Buffer[offset] &= ^Character(charMask) // clear char
-	text.Buffer[offset] |= Character(cp)        // set char
+	text.Buffer[offset].SetGlyph(cp)
 	tracef("text at (%d, %d) [%d]: %q fg=%s bg=%s attr=%s",
 		text.cursor.X, text.cursor.Y, offset, cp,
 		text.Buffer[offset].ForegroundColor(),
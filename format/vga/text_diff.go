@@ -0,0 +1,293 @@
+package vga
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+)
+
+// Errors.
+var (
+	ErrDiffFormat = errors.New("vga: invalid diff format")
+	ErrDiffBounds = errors.New("vga: diff run out of bounds")
+)
+
+// diffMagic starts an encoded Diff, followed by the format version.
+const diffMagic = "VGAD\x01"
+
+// Run is a run of changed cells on a line of the buffer.
+type Run struct {
+	// X and Y are the position of the first cell.
+	X, Y int
+
+	// Cells are the new characters.
+	Cells []Character
+}
+
+// Bounds of the cells in the run.
+func (run Run) Bounds() image.Rectangle {
+	return image.Rect(run.X, run.Y, run.X+len(run.Cells), run.Y+1)
+}
+
+// Diff is the cell level difference between two buffers.
+type Diff struct {
+	// Width and Height are the size of the new buffer.
+	Width, Height int
+
+	// Runs of changed cells, in buffer order.
+	Runs []Run
+}
+
+// DiffText computes the difference from buffer a to buffer b. If the buffers
+// differ in size, cells outside of a are compared to BlankCharacter, the way
+// Resize expands a buffer.
+func DiffText(a, b *Text) *Diff {
+	var (
+		diff = &Diff{Width: b.Width(), Height: b.Height()}
+		run  *Run
+	)
+	for y := 0; y < diff.Height; y++ {
+		run = nil
+		for x := 0; x < diff.Width; x++ {
+			char := b.Buffer[y*diff.Width+x]
+			old, ok := a.Cell(x, y)
+			if !ok {
+				old = BlankCharacter
+			}
+			if char == old {
+				run = nil
+				continue
+			}
+			if run == nil {
+				diff.Runs = append(diff.Runs, Run{X: x, Y: y})
+				run = &diff.Runs[len(diff.Runs)-1]
+			}
+			run.Cells = append(run.Cells, char)
+		}
+	}
+	return diff
+}
+
+// Empty checks if there are no changes.
+func (diff *Diff) Empty() bool {
+	return len(diff.Runs) == 0
+}
+
+// Bounds is the smallest rectangle containing all changed cells.
+func (diff *Diff) Bounds() (r image.Rectangle) {
+	for _, run := range diff.Runs {
+		r = r.Union(run.Bounds())
+	}
+	return
+}
+
+// Apply the changes to text, resizing it if required. The changes are a single
+// edit for Undo, resizing clears the edits before it.
+func (diff *Diff) Apply(text *Text) error {
+	if diff.Width < 1 || diff.Height < 1 {
+		return ErrDiffBounds
+	}
+	bounds := image.Rect(0, 0, diff.Width, diff.Height)
+	for _, run := range diff.Runs {
+		if !run.Bounds().In(bounds) {
+			return ErrDiffBounds
+		}
+	}
+
+	text.Resize(uint(diff.Width), uint(diff.Height))
+	text.BeginEdit()
+	for _, run := range diff.Runs {
+		offset := run.Y*diff.Width + run.X
+		for i, char := range run.Cells {
+			text.setCell(offset+i, char)
+		}
+	}
+	text.EndEdit()
+	return nil
+}
+
+/*
+Bytes encodes the diff as a patch. All integers are unsigned varints:
+
+	"VGAD" 0x01 width height runs
+
+Followed by the runs, where skip is the number of cells since the end of the
+previous run:
+
+	skip length cell...
+
+Every cell starts with a flags byte, telling which parts of the character
+differ from the previous cell, followed by those parts in little endian:
+
+	0x01 code point and attributes, 2 bytes
+	0x02 foreground and background colors, 6 bytes
+	0x04 extended attributes and colors, 8 bytes
+*/
+func (diff *Diff) Bytes() []byte {
+	var (
+		b    = bytes.NewBufferString(diffMagic)
+		v    = make([]byte, binary.MaxVarintLen64)
+		last int
+		prev Character
+	)
+	putUvarint := func(x int) {
+		b.Write(v[:binary.PutUvarint(v, uint64(x))])
+	}
+	putUvarint(diff.Width)
+	putUvarint(diff.Height)
+	putUvarint(len(diff.Runs))
+	for _, run := range diff.Runs {
+		offset := run.Y*diff.Width + run.X
+		putUvarint(offset - last)
+		putUvarint(len(run.Cells))
+		last = offset + len(run.Cells)
+
+		for _, char := range run.Cells {
+			writeCell(b, char, prev)
+			prev = char
+		}
+	}
+	return b.Bytes()
+}
+
+// WriteTo writes the patch bytes to writer w.
+func (diff *Diff) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(diff.Bytes())
+	return int64(n), err
+}
+
+// DecodeDiff decodes a patch encoded by Diff.Bytes.
+func DecodeDiff(r io.Reader) (*Diff, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(diffMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, err
+	} else if string(magic) != diffMagic {
+		return nil, ErrDiffFormat
+	}
+
+	var (
+		diff = new(Diff)
+		runs int
+		err  error
+	)
+	readUvarint := func() int {
+		if err != nil {
+			return 0
+		}
+		var x uint64
+		if x, err = binary.ReadUvarint(br); err == nil && x > 1<<31 {
+			err = ErrDiffFormat
+		}
+		return int(x)
+	}
+	diff.Width = readUvarint()
+	diff.Height = readUvarint()
+	runs = readUvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		size    = diff.Width * diff.Height
+		last    int
+		prev    Character
+		scratch [8]byte
+	)
+	for i := 0; i < runs; i++ {
+		var (
+			offset = last + readUvarint()
+			length = readUvarint()
+		)
+		if err != nil {
+			return nil, err
+		}
+		if length < 1 || offset+length > size || offset%diff.Width+length > diff.Width {
+			return nil, ErrDiffBounds
+		}
+		last = offset + length
+
+		// Cells are appended as they are read, the length is not trusted
+		run := Run{X: offset % diff.Width, Y: offset / diff.Width}
+		for j := 0; j < length; j++ {
+			if prev, err = readCell(br, prev, &scratch, ErrDiffFormat); err != nil {
+				return nil, err
+			}
+			run.Cells = append(run.Cells, prev)
+		}
+		diff.Runs = append(diff.Runs, run)
+	}
+	return diff, nil
+}
+
+// writeCell encodes char as a flags byte followed by the parts that differ
+// from prev, see Diff.Bytes.
+func writeCell(b *bytes.Buffer, char, prev Character) {
+	var (
+		flags byte
+		v     [8]byte
+	)
+	if (char.cell^prev.cell)&0xffff != 0 {
+		flags |= 0x01
+	}
+	if (char.cell^prev.cell)>>16 != 0 {
+		flags |= 0x02
+	}
+	if char.ext != prev.ext {
+		flags |= 0x04
+	}
+	b.WriteByte(flags)
+	binary.LittleEndian.PutUint64(v[:], char.cell)
+	if flags&0x01 != 0 {
+		b.Write(v[:2])
+	}
+	if flags&0x02 != 0 {
+		b.Write(v[2:8])
+	}
+	if flags&0x04 != 0 {
+		binary.LittleEndian.PutUint64(v[:], char.ext)
+		b.Write(v[:])
+	}
+}
+
+// cellReader is satisfied by bufio.Reader and bytes.Reader.
+type cellReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// readCell decodes a character encoded by writeCell using the scratch buffer
+// v, invalid flags are reported as errFormat.
+func readCell(r cellReader, prev Character, v *[8]byte, errFormat error) (char Character, err error) {
+	flags, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	if flags&^0x07 != 0 {
+		return char, errFormat
+	}
+	char = prev
+	binary.LittleEndian.PutUint64(v[:], char.cell)
+	if flags&0x01 != 0 {
+		if _, err = io.ReadFull(r, v[:2]); err != nil {
+			return
+		}
+	}
+	if flags&0x02 != 0 {
+		if _, err = io.ReadFull(r, v[2:8]); err != nil {
+			return
+		}
+	}
+	char.cell = binary.LittleEndian.Uint64(v[:])
+	if flags&0x04 != 0 {
+		if _, err = io.ReadFull(r, v[:]); err != nil {
+			return
+		}
+		char.ext = binary.LittleEndian.Uint64(v[:])
+	}
+	return char, nil
+}
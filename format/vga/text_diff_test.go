@@ -0,0 +1,73 @@
+package vga
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"testing"
+)
+
+func TestDiffText(t *testing.T) {
+	var (
+		a = testEditText()
+		b = testEditText()
+	)
+	b.SetCell(1, 0, MakeIndexedCharacter('X', 1, 4))
+	b.SetCell(2, 0, MakeIndexedCharacter('Y', 1, 4))
+	b.SetCell(3, 2, MakeCharacter('Z', Red, Blue))
+	b.Resize(5, 3)
+
+	diff := DiffText(a, b)
+	for i, want := range []image.Rectangle{
+		image.Rect(1, 0, 3, 1),
+		image.Rect(3, 2, 4, 3),
+	} {
+		if i >= len(diff.Runs) {
+			t.Fatalf("expected %d runs, got %d", i+1, len(diff.Runs))
+		}
+		if got := diff.Runs[i].Bounds(); got != want {
+			t.Errorf("run %d: expected %s, got %s", i, want, got)
+		}
+	}
+	if want, got := image.Rect(1, 0, 4, 3), diff.Bounds(); got != want {
+		t.Errorf("expected bounds %s, got %s", want, got)
+	}
+
+	patch := diff.Bytes()
+	decoded, err := DecodeDiff(bytes.NewReader(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.Bytes(), patch) {
+		t.Fatal("expected decoded patch to encode the same")
+	}
+	if err = decoded.Apply(a); err != nil {
+		t.Fatal(err)
+	}
+	if !DiffText(a, b).Empty() {
+		t.Fatal("expected patched buffer to equal the new buffer")
+	}
+	if !a.Undo() || a.CanUndo() {
+		t.Fatal("expected patch to be the only edit after resize")
+	}
+}
+
+func TestDecodeDiffError(t *testing.T) {
+	for _, test := range []struct {
+		Name  string
+		Patch string
+		Want  error
+	}{
+		{"Magic", "VGAD\x02\x01\x01\x00", ErrDiffFormat},
+		{"Run bounds", "VGAD\x01\x02\x02\x01\x01\x02\x00", ErrDiffBounds},
+		{"Empty run", "VGAD\x01\x02\x02\x01\x00\x00", ErrDiffBounds},
+		{"Flags", "VGAD\x01\x02\x02\x01\x00\x01\x08", ErrDiffFormat},
+		{"Truncated run", "VGAD\x01\x80\x80\x80\x80\x08\x80\x80\x80\x80\x08\x01\x00\x80\x80\x80\x80\x08", io.EOF},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if _, err := DecodeDiff(bytes.NewReader([]byte(test.Patch))); err != test.Want {
+				t.Fatalf("expected error %v, got %v", test.Want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package vga
+
+import "image"
+
+// Drawing functions treat the buffer as a canvas of half block "pixels", every
+// cell is two pixels high using the upper half (0xdf), lower half (0xdc) and
+// full block (0xdb) glyphs. Pixel colors are palette indexes.
+
+// HalfBlockBounds are the bounds of the buffer in half block pixels.
+func (text *Text) HalfBlockBounds() image.Rectangle {
+	return image.Rect(0, 0, int(text.width), int(text.height)*2)
+}
+
+// HalfBlock returns the color of the half block pixel at (x, y), cells not
+// drawn with half blocks are seen as their background color.
+func (text *Text) HalfBlock(x, y int) (i uint8, ok bool) {
+	if y < 0 {
+		return 0, false
+	}
+	char, ok := text.Cell(x, y/2)
+	if !ok {
+		return 0, false
+	}
+	top, bottom := text.halfBlocks(char)
+	if y&1 == 0 {
+		return top, true
+	}
+	return bottom, true
+}
+
+// SetHalfBlock sets the color of the half block pixel at (x, y).
+func (text *Text) SetHalfBlock(x, y int, i uint8) {
+	text.BeginEdit()
+	text.setHalfBlock(x, y, i)
+	text.EndEdit()
+}
+
+// DrawLine draws a line from p0 to p1, including both points.
+func (text *Text) DrawLine(p0, p1 image.Point, i uint8) {
+	var (
+		dx, sx = abs(p1.X - p0.X), sign(p1.X - p0.X)
+		dy, sy = -abs(p1.Y - p0.Y), sign(p1.Y - p0.Y)
+		e      = dx + dy
+	)
+	text.BeginEdit()
+	for {
+		text.setHalfBlock(p0.X, p0.Y, i)
+		if p0 == p1 {
+			break
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			p0.X += sx
+		}
+		if e2 <= dx {
+			e += dx
+			p0.Y += sy
+		}
+	}
+	text.EndEdit()
+}
+
+// DrawRectangle draws the outline of r, or the whole of r if fill is set.
+func (text *Text) DrawRectangle(r image.Rectangle, i uint8, fill bool) {
+	r = r.Canon()
+	text.drawShape(r, i, fill, func(x, y int) bool {
+		return image.Pt(x, y).In(r)
+	})
+}
+
+// DrawEllipse draws the outline of the ellipse that fits in r, or the whole
+// ellipse if fill is set.
+func (text *Text) DrawEllipse(r image.Rectangle, i uint8, fill bool) {
+	r = r.Canon()
+	var (
+		cx, cy = float64(r.Min.X+r.Max.X-1) / 2, float64(r.Min.Y+r.Max.Y-1) / 2
+		rx, ry = float64(r.Dx()) / 2, float64(r.Dy()) / 2
+	)
+	text.drawShape(r, i, fill, func(x, y int) bool {
+		var (
+			dx = (float64(x) - cx) / rx
+			dy = (float64(y) - cy) / ry
+		)
+		return dx*dx+dy*dy <= 1
+	})
+}
+
+// drawShape draws the pixels in r for which inside returns true; for outlines
+// only the pixels with a 4-connected neighbour outside of the shape are drawn.
+func (text *Text) drawShape(r image.Rectangle, i uint8, fill bool, inside func(x, y int) bool) {
+	text.BeginEdit()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if !inside(x, y) {
+				continue
+			}
+			if fill || !inside(x-1, y) || !inside(x+1, y) || !inside(x, y-1) || !inside(x, y+1) {
+				text.setHalfBlock(x, y, i)
+			}
+		}
+	}
+	text.EndEdit()
+}
+
+// FloodFill replaces the color of the area of 4-connected half block pixels
+// around p that have the same color as p.
+func (text *Text) FloodFill(p image.Point, i uint8) {
+	target, ok := text.HalfBlock(p.X, p.Y)
+	if !ok || target == i {
+		return
+	}
+
+	text.BeginEdit()
+	for stack := []image.Point{p}; len(stack) > 0; {
+		p, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if c, ok := text.HalfBlock(p.X, p.Y); !ok || c != target {
+			continue
+		}
+		text.setHalfBlock(p.X, p.Y, i)
+		stack = append(stack,
+			image.Pt(p.X-1, p.Y), image.Pt(p.X+1, p.Y),
+			image.Pt(p.X, p.Y-1), image.Pt(p.X, p.Y+1))
+	}
+	text.EndEdit()
+}
+
+func (text *Text) setHalfBlock(x, y int, i uint8) {
+	if !image.Pt(x, y).In(text.HalfBlockBounds()) {
+		return
+	}
+	offset := y/2*int(text.width) + x
+	top, bottom := text.halfBlocks(text.Buffer[offset])
+	if y&1 == 0 {
+		if top == i {
+			return
+		}
+		top = i
+	} else {
+		if bottom == i {
+			return
+		}
+		bottom = i
+	}
+	text.setCell(offset, text.makeHalfBlock(top, bottom))
+}
+
+// halfBlocks returns the colors of the top and bottom half of a cell.
+func (text *Text) halfBlocks(char Character) (top, bottom uint8) {
+	fg, bg := text.cellIndexes(char)
+	switch char.Glyph() {
+	case 0xdb:
+		return fg, fg
+	case 0xdf:
+		return fg, bg
+	case 0xdc:
+		return bg, fg
+	default:
+		return bg, bg
+	}
+}
+
+// makeHalfBlock returns a cell with the top and bottom half colored. If
+// blinking is enabled, bright colors are kept out of the background if
+// possible.
+func (text *Text) makeHalfBlock(top, bottom uint8) Character {
+	switch {
+	case top == bottom:
+		return text.indexedCharacter(0xdb, top, bottom)
+	case bottom >= 8 && top < 8 && !text.DisableBlink:
+		return text.indexedCharacter(0xdc, bottom, top)
+	default:
+		return text.indexedCharacter(0xdf, top, bottom)
+	}
+}
+
+// cellIndexes returns the palette indexes of the colors of a character,
+// colors without an index are matched to the closest color in the palette.
+func (text *Text) cellIndexes(char Character) (fg, bg uint8) {
+	palette := text.palette()
+	if i, ok := char.ForegroundIndex(); ok {
+		fg = i
+	} else {
+		fg = uint8(palette.Index(char.ForegroundColor()))
+	}
+	if i, ok := char.BackgroundIndex(); ok {
+		bg = i
+	} else {
+		bg = uint8(palette.Index(char.BackgroundColor()))
+	}
+	return
+}
+
+// indexedCharacter is like MakeIndexedCharacter, using the buffer palette.
+func (text *Text) indexedCharacter(cp uint8, fg, bg uint8) Character {
+	var (
+		char    = MakeIndexedCharacter(cp, fg, bg)
+		palette = text.palette()
+	)
+	if int(fg) < len(palette) {
+		char.SetForegroundColor(palette[fg])
+		char.SetForegroundIndex(fg)
+	}
+	if int(bg) < len(palette) {
+		char.SetBackgroundColor(palette[bg])
+		char.SetBackgroundIndex(bg)
+	}
+	return char
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	default:
+		return 0
+	}
+}
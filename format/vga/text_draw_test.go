@@ -0,0 +1,99 @@
+package vga
+
+import (
+	"image"
+	"testing"
+)
+
+// halfBlockString renders the half block pixels of text, using the hex digit
+// of the color index for every pixel.
+func halfBlockString(text *Text) string {
+	var s []byte
+	bounds := text.HalfBlockBounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i, _ := text.HalfBlock(x, y)
+			s = append(s, "0123456789abcdef"[i&0x0f])
+		}
+		s = append(s, '\n')
+	}
+	return string(s)
+}
+
+func TestTextHalfBlock(t *testing.T) {
+	text := NewText(2, 1)
+	text.SetHalfBlock(0, 0, 4)
+	text.SetHalfBlock(1, 1, 12)
+	text.SetHalfBlock(1, 0, 12)
+	if want, got := "4c\n0c\n", halfBlockString(text); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if want, got := "\xdf\xdb\n", string(text.Bytes()); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	// Bright colors stay out of the background while blinking is enabled
+	text.SetHalfBlock(0, 1, 9)
+	if char := text.Buffer[0]; char.CodePoint() != 0xdc {
+		t.Fatalf("expected lower half block, got %#02x", char.CodePoint())
+	}
+	if i, _ := text.HalfBlock(0, 0); i != 4 {
+		t.Fatalf("expected top to be 4, got %d", i)
+	}
+}
+
+func TestTextDraw(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Draw func(*Text)
+		Want string
+	}{
+		{
+			"Line",
+			func(text *Text) { text.DrawLine(image.Pt(0, 0), image.Pt(4, 2), 1) },
+			"10000\n01100\n00011\n00000\n",
+		},
+		{
+			"Rectangle",
+			func(text *Text) { text.DrawRectangle(image.Rect(1, 0, 5, 3), 2, false) },
+			"02222\n02002\n02222\n00000\n",
+		},
+		{
+			"Filled rectangle",
+			func(text *Text) { text.DrawRectangle(image.Rect(0, 1, 2, 3), 2, true) },
+			"00000\n22000\n22000\n00000\n",
+		},
+		{
+			"Ellipse",
+			func(text *Text) { text.DrawEllipse(image.Rect(0, 0, 5, 4), 3, false) },
+			"03330\n30003\n30003\n03330\n",
+		},
+		{
+			"Filled ellipse",
+			func(text *Text) { text.DrawEllipse(image.Rect(0, 0, 5, 4), 3, true) },
+			"03330\n33333\n33333\n03330\n",
+		},
+		{
+			"Flood fill",
+			func(text *Text) {
+				text.DrawRectangle(image.Rect(0, 0, 4, 4), 1, false)
+				text.FloodFill(image.Pt(1, 1), 5)
+				text.FloodFill(image.Pt(4, 0), 6)
+			},
+			"11116\n15516\n15516\n11116\n",
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			text := NewText(5, 2)
+			test.Draw(text)
+			if got := halfBlockString(text); got != test.Want {
+				t.Fatalf("expected\n%s\ngot\n%s", test.Want, got)
+			}
+			for text.Undo() {
+			}
+			if got := halfBlockString(text); got != "00000\n00000\n00000\n00000\n" {
+				t.Fatalf("expected drawing to be undone, got\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package vga
+
+import "image"
+
+// Editing functions update the cells of the buffer without moving the cursor
+// or using the cursor attributes. Every change is recorded, so it can be
+// reverted with Undo, see BeginEdit for grouping edits.
+
+// Bounds of the buffer in cells.
+func (text *Text) Bounds() image.Rectangle {
+	return image.Rect(0, 0, int(text.width), int(text.height))
+}
+
+// Cell returns the character at (x, y), ok is false if (x, y) is outside of
+// the buffer.
+func (text *Text) Cell(x, y int) (char Character, ok bool) {
+	if !image.Pt(x, y).In(text.Bounds()) {
+		return
+	}
+	return text.Buffer[y*int(text.width)+x], true
+}
+
+// SetCell updates the character at (x, y), positions outside of the buffer
+// are ignored.
+func (text *Text) SetCell(x, y int, char Character) {
+	if !image.Pt(x, y).In(text.Bounds()) {
+		return
+	}
+	text.BeginEdit()
+	text.setCell(y*int(text.width)+x, char)
+	text.EndEdit()
+}
+
+// Fill the cells in r with char.
+func (text *Text) Fill(r image.Rectangle, char Character) {
+	r = r.Intersect(text.Bounds())
+	text.BeginEdit()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			text.setCell(y*int(text.width)+x, char)
+		}
+	}
+	text.EndEdit()
+}
+
+// Copy the cells in r to a new buffer, using the same palette.
+func (text *Text) Copy(r image.Rectangle) *Text {
+	block := text.Crop(r)
+	block.Palette = text.Palette
+	return block
+}
+
+// Paste the cells of src with the top left corner at p. If transparent is not
+// nil, the cells of src for which it returns true are skipped.
+func (text *Text) Paste(src *Text, p image.Point, transparent func(Character) bool) {
+	r := src.Bounds().Add(p).Intersect(text.Bounds())
+	text.BeginEdit()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			char := src.Buffer[(y-p.Y)*int(src.width)+x-p.X]
+			if transparent != nil && transparent(char) {
+				continue
+			}
+			text.setCell(y*int(text.width)+x, char)
+		}
+	}
+	text.EndEdit()
+}
+
+// Transparent checks if a character shows nothing but a black background, it
+// can be used as the transparency function for Paste.
+func Transparent(char Character) bool {
+	switch char.Glyph() {
+	case 0x00, ' ', 0xff:
+	default:
+		return false
+	}
+	if char.Attributes()&(Underline|DoubleUnderline|Overline|CrossedOut|Reverse) != 0 {
+		return false
+	}
+	if i, ok := char.BackgroundIndex(); ok {
+		return i == 0
+	}
+	return ToRGB(char.BackgroundColor()) == Black
+}
+
+// FlipHorizontal mirrors the cells in r left to right, directional glyphs such
+// as half blocks and box drawing characters are replaced by their mirror image.
+func (text *Text) FlipHorizontal(r image.Rectangle) {
+	r = r.Intersect(text.Bounds())
+	text.BeginEdit()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for l, h := r.Min.X, r.Max.X-1; l <= h; l, h = l+1, h-1 {
+			var (
+				lo = y*int(text.width) + l
+				ho = y*int(text.width) + h
+				lc = mirror(text.Buffer[ho], &mirrorHorizontal)
+				hc = mirror(text.Buffer[lo], &mirrorHorizontal)
+			)
+			text.setCell(lo, lc)
+			text.setCell(ho, hc)
+		}
+	}
+	text.EndEdit()
+}
+
+// FlipVertical mirrors the cells in r top to bottom, directional glyphs such
+// as half blocks and box drawing characters are replaced by their mirror image.
+func (text *Text) FlipVertical(r image.Rectangle) {
+	r = r.Intersect(text.Bounds())
+	text.BeginEdit()
+	for l, h := r.Min.Y, r.Max.Y-1; l <= h; l, h = l+1, h-1 {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			var (
+				lo = l*int(text.width) + x
+				ho = h*int(text.width) + x
+				lc = mirror(text.Buffer[ho], &mirrorVertical)
+				hc = mirror(text.Buffer[lo], &mirrorVertical)
+			)
+			text.setCell(lo, lc)
+			text.setCell(ho, hc)
+		}
+	}
+	text.EndEdit()
+}
+
+// mirror replaces the glyph of char using a mirror table, only glyphs in the
+// first 256 characters of the font are mirrored.
+func mirror(char Character, table *[256]uint8) Character {
+	if glyph := char.Glyph(); glyph < 256 {
+		char.SetCodePoint(table[glyph])
+	}
+	return char
+}
+
+// Mirror tables for code page 437 glyphs.
+var (
+	mirrorHorizontal = mirrorTable([][2]uint8{
+		{'(', ')'}, {'<', '>'}, {'[', ']'}, {'{', '}'}, {'/', '\\'},
+		{0x10, 0x11}, // ► ◄
+		{0x1a, 0x1b}, // → ←
+		{0xa9, 0xaa}, // ⌐ ¬
+		{0xae, 0xaf}, // « »
+		{0xb4, 0xc3}, // ┤ ├
+		{0xb5, 0xc6}, // ╡ ╞
+		{0xb6, 0xc7}, // ╢ ╟
+		{0xb7, 0xd6}, // ╖ ╓
+		{0xb8, 0xd5}, // ╕ ╒
+		{0xb9, 0xcc}, // ╣ ╠
+		{0xbb, 0xc9}, // ╗ ╔
+		{0xbc, 0xc8}, // ╝ ╚
+		{0xbd, 0xd3}, // ╜ ╙
+		{0xbe, 0xd4}, // ╛ ╘
+		{0xbf, 0xda}, // ┐ ┌
+		{0xc0, 0xd9}, // └ ┘
+		{0xdd, 0xde}, // ▌ ▐
+		{0xf2, 0xf3}, // ≥ ≤
+	})
+	mirrorVertical = mirrorTable([][2]uint8{
+		{'/', '\\'},
+		{0x18, 0x19}, // ↑ ↓
+		{0x1e, 0x1f}, // ▲ ▼
+		{0xb7, 0xbd}, // ╖ ╜
+		{0xb8, 0xbe}, // ╕ ╛
+		{0xbb, 0xbc}, // ╗ ╝
+		{0xbf, 0xd9}, // ┐ ┘
+		{0xc0, 0xda}, // └ ┌
+		{0xc1, 0xc2}, // ┴ ┬
+		{0xc8, 0xc9}, // ╚ ╔
+		{0xca, 0xcb}, // ╩ ╦
+		{0xcf, 0xd1}, // ╧ ╤
+		{0xd0, 0xd2}, // ╨ ╥
+		{0xd3, 0xd6}, // ╙ ╓
+		{0xd4, 0xd5}, // ╘ ╒
+		{0xdc, 0xdf}, // ▄ ▀
+		{0xf4, 0xf5}, // ⌠ ⌡
+	})
+)
+
+func mirrorTable(pairs [][2]uint8) (table [256]uint8) {
+	for i := range table {
+		table[i] = uint8(i)
+	}
+	for _, pair := range pairs {
+		table[pair[0]], table[pair[1]] = pair[1], pair[0]
+	}
+	return
+}
@@ -0,0 +1,114 @@
+package vga
+
+import (
+	"image"
+	"testing"
+)
+
+func testEditText() *Text {
+	text := NewText(4, 3)
+	text.WriteString("abcdefghijkl")
+	return text
+}
+
+func TestTextCell(t *testing.T) {
+	text := testEditText()
+	if char, ok := text.Cell(1, 2); !ok || char.CodePoint() != 'j' {
+		t.Fatalf("expected j at (1, 2), got %q", char.CodePoint())
+	}
+	if _, ok := text.Cell(4, 0); ok {
+		t.Fatal("expected (4, 0) to be outside of the buffer")
+	}
+
+	text.SetCell(1, 2, MakeIndexedCharacter('X', 1, 0))
+	text.SetCell(-1, 0, MakeIndexedCharacter('X', 1, 0))
+	text.Fill(image.Rect(2, 0, 8, 2), BlankCharacter)
+	if want, got := "ab  \nef  \niXkl\n", string(text.Bytes()); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTextPaste(t *testing.T) {
+	var (
+		text  = testEditText()
+		block = text.Copy(image.Rect(0, 0, 2, 2))
+	)
+	block.Buffer[1] = BlankCharacter
+	block.Buffer[2].SetBackgroundIndex(1)
+
+	for _, test := range []struct {
+		Name        string
+		Transparent func(Character) bool
+		Want        string
+	}{
+		{"Opaque", nil, "abcd\nefa \nijef\n"},
+		{"Transparent", Transparent, "abcd\nefah\nijef\n"},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			text := testEditText()
+			text.Paste(block, image.Pt(2, 1), test.Transparent)
+			if got := string(text.Bytes()); got != test.Want {
+				t.Fatalf("expected %q, got %q", test.Want, got)
+			}
+		})
+	}
+}
+
+func TestTextFlip(t *testing.T) {
+	text := NewText(3, 2)
+	text.WriteString("\xdd\xc9a\xdcb\xbf")
+
+	text.FlipHorizontal(text.Bounds())
+	if want, got := "a\xbb\xde\n\xdab\xdc\n", string(text.Bytes()); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	text.FlipVertical(image.Rect(0, 0, 2, 2))
+	if want, got := "\xc0b\xde\na\xbc\xdc\n", string(text.Bytes()); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTextUndo(t *testing.T) {
+	text := testEditText()
+	text.SetCell(0, 0, MakeIndexedCharacter('X', 7, 0))
+	text.BeginEdit()
+	text.Fill(image.Rect(0, 1, 4, 2), MakeIndexedCharacter('Y', 7, 0))
+	text.SetCell(1, 1, MakeIndexedCharacter('Z', 7, 0))
+	text.EndEdit()
+
+	steps := []string{
+		"Xbcd\nYZYY\nijkl\n",
+		"Xbcd\nefgh\nijkl\n",
+		"abcd\nefgh\nijkl\n",
+	}
+	for i, want := range steps {
+		if got := string(text.Bytes()); got != want {
+			t.Fatalf("undo %d: expected %q, got %q", i, want, got)
+		}
+		if ok := text.Undo(); ok != (i < len(steps)-1) {
+			t.Fatalf("undo %d: expected %t, got %t", i, !ok, ok)
+		}
+	}
+	for i := len(steps) - 1; i >= 0; i-- {
+		if got := string(text.Bytes()); got != steps[i] {
+			t.Fatalf("redo %d: expected %q, got %q", i, steps[i], got)
+		}
+		if ok := text.Redo(); ok != (i > 0) {
+			t.Fatalf("redo %d: expected %t, got %t", i, !ok, ok)
+		}
+	}
+
+	// A new edit drops the undone edits
+	text.Undo()
+	text.SetCell(3, 2, MakeIndexedCharacter('W', 7, 0))
+	if text.CanRedo() {
+		t.Fatal("expected nothing to redo after edit")
+	}
+
+	text.UndoLimit = 1
+	text.SetCell(3, 2, BlankCharacter)
+	if text.Undo(); text.Undo() {
+		t.Fatal("expected undo to be limited to 1 edit")
+	}
+}
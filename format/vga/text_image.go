@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
+	"runtime"
+	"sync"
 
 	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/image/aspect"
 )
 
 // Progress callback.
@@ -16,126 +18,411 @@ func (text *Text) Progress(fn func(float64)) {
 
 // Image renders an RGBA image of the buffer with the specified chargen font,
 // if blink is true, blinking character will be rendered and otherwise omitted.
-func (text *Text) Image(regular *chargen.Font, blink bool) (*image.Paletted, error) {
-	if regular == nil {
+func (text *Text) Image(font *chargen.Font, blink bool) (*image.Paletted, error) {
+	r, err := text.NewRenderer(font)
+	if err != nil {
+		return nil, err
+	}
+	im := image.NewPaletted(r.Bounds(), r.palette)
+	r.drawCells(im, image.Point{}, r.cellBounds(), blink, false, text.progressFunc)
+	return im, nil
+}
+
+// BlinkFrames renders both blink states of the buffer with the specified
+// chargen font; off omits the blinking characters and on renders them. The
+// second frame is a copy of the first, where only the blinking cells are
+// drawn again.
+func (text *Text) BlinkFrames(font *chargen.Font) (off, on *image.Paletted, err error) {
+	r, err := text.NewRenderer(font)
+	if err != nil {
+		return nil, nil, err
+	}
+	off = image.NewPaletted(r.Bounds(), r.palette)
+	r.drawCells(off, image.Point{}, r.cellBounds(), false, false, text.progressFunc)
+	on = image.NewPaletted(off.Rect, off.Palette)
+	copy(on.Pix, off.Pix)
+	r.drawCells(on, image.Point{}, r.cellBounds(), true, true, nil)
+	return off, on, nil
+}
+
+// ImageAspect renders an image of the buffer like Image, stretched so the
+// pixels with aspect ratio pixel (width:height) look like they did on the
+// original display, see aspect.Correct.
+func (text *Text) ImageAspect(font *chargen.Font, blink bool, pixel image.Point, filter aspect.Filter) (image.Image, error) {
+	im, err := text.Image(font, blink)
+	if err != nil {
+		return nil, err
+	}
+	return aspect.Correct(im, pixel, filter), nil
+}
+
+// Font variants.
+const (
+	fontRegular = iota
+	fontItalics
+	fontBold
+	fontVariants
+)
+
+// cellStyle are the rendering attributes of a cell.
+type cellStyle uint8
+
+// Cell styles.
+const (
+	styleUnderline cellStyle = 1 << iota
+	styleCrossedOut
+	styleBlink
+	styleHidden
+	styleDoubleUnderline
+	styleOverline
+)
+
+// cellKey identifies a pre-rasterised cell in the glyph atlas.
+type cellKey struct {
+	char    uint16
+	fg, bg  uint8
+	line    uint8 // underline color
+	variant uint8
+	style   cellStyle
+}
+
+// Renderer renders the cells of a buffer using a glyph atlas; each unique
+// combination of glyph, colors and attributes is rasterised once and kept for
+// subsequent draws. The colors of all cells are resolved when the Renderer is
+// created, so every image drawn uses the same palette; changes to the buffer
+// after that are not rendered. A Renderer is not safe for concurrent use.
+type Renderer struct {
+	text    *Text
+	fonts   [fontVariants]*chargen.Font
+	glyphs  [fontVariants]map[uint16][]bool
+	size    image.Point // glyph size
+	cell    image.Point // cell size, including padding
+	palette color.Palette
+	base    int // number of colors of the buffer palette
+	index   map[RGB]uint8
+	cells   []cellKey
+	atlas   map[cellKey][]uint8
+}
+
+// NewRenderer returns a Renderer for the buffer with the specified chargen font.
+func (text *Text) NewRenderer(font *chargen.Font) (*Renderer, error) {
+	if font == nil {
 		return nil, fmt.Errorf("vga: font can't be nil")
 	}
+	if text.NineDot && font.Size.X == 8 {
+		// Only VGA hardware draws the ninth column, for 8 pixel wide fonts
+		font = chargen.New(chargen.AddLineGraphicsColumn(font.Mask))
+	}
 
-	var (
-		italics = chargen.New(chargen.Italics(regular.Mask))
-		size    = regular.Size
-		stridex = (size.X + text.Padding)
-		stridey = size.Y
-		bounds  = image.Rect(0, 0, stridex*text.Width(), stridey*text.Height())
-		palette color.Palette
-		colors  = map[RGB]*image.Uniform{}
-	)
+	r := &Renderer{
+		text:  text,
+		size:  font.Size,
+		cell:  image.Pt(font.Size.X+text.Padding, font.Size.Y),
+		index: make(map[RGB]uint8),
+		cells: make([]cellKey, len(text.Buffer)),
+		atlas: make(map[cellKey][]uint8),
+	}
+	r.fonts[fontRegular] = font
+	r.fonts[fontItalics] = chargen.New(chargen.Italics(font.Mask))
+	r.fonts[fontBold] = chargen.New(chargen.Bold(font.Mask))
+	for i := range r.glyphs {
+		r.glyphs[i] = make(map[uint16][]bool)
+	}
 
-	// Phase 1 is to scan the palette.
+	// Phase 1 is to resolve the colors and attributes of each cell, building
+	// the palette.
 	if text.Palette == nil {
-		palette = make(color.Palette, len(Palette))
-		copy(palette, Palette)
+		r.palette = make(color.Palette, len(Palette))
+		copy(r.palette, Palette)
 	} else {
-		palette = make(color.Palette, len(text.Palette))
-		copy(palette, text.Palette)
+		r.palette = make(color.Palette, len(text.Palette))
+		copy(r.palette, text.Palette)
 	}
-	for _, char := range text.Buffer {
-		fg := char.BackgroundColor()
-		if i := ColorIndex(fg, palette); i == -1 {
-			palette = append(palette, fg)
-		}
-		bg := char.BackgroundColor()
-		if i := ColorIndex(bg, palette); i == -1 {
-			palette = append(palette, bg)
+	r.base = min(len(r.palette), 0x100)
+	for i, c := range r.palette[:r.base] {
+		if _, dupe := r.index[ToRGB(c)]; !dupe {
+			r.index[ToRGB(c)] = uint8(i)
 		}
 	}
+	for i, char := range text.Buffer {
+		r.cells[i] = r.resolve(char)
+	}
+	if len(r.palette) > 0x100 {
+		r.palette = r.palette[:0x100]
+	}
+	return r, nil
+}
 
-	// Create paletted image.
-	im := image.NewPaletted(bounds, palette)
+// Bounds of the image of the whole buffer.
+func (r *Renderer) Bounds() image.Rectangle {
+	return image.Rect(0, 0, r.cell.X*r.text.Width(), r.cell.Y*r.text.Height())
+}
 
-	// Black canvas
-	colors[palette[0].(RGB)] = image.NewUniform(palette[0])
-	draw.Draw(im, bounds, colors[palette[0].(RGB)], image.ZP, draw.Over)
+// CellSize is the size of a rendered cell in pixels.
+func (r *Renderer) CellSize() image.Point {
+	return r.cell
+}
 
-	// log.Printf("buffer: %#+v", text.Buffer)
+// Palette used by the rendered images, it has at most 256 colors.
+func (r *Renderer) Palette() color.Palette {
+	return r.palette
+}
 
-	if text.progressFunc != nil {
-		text.progressFunc(0)
+// cellBounds is the rectangle of all cells in the buffer.
+func (r *Renderer) cellBounds() image.Rectangle {
+	return image.Rect(0, 0, r.text.Width(), r.text.Height())
+}
+
+// colorIndex returns the palette index of color c, adding it to the palette if
+// there is room or using the closest color otherwise.
+func (r *Renderer) colorIndex(c RGB) uint8 {
+	if i, ok := r.index[c]; ok {
+		return i
 	}
-	for y := 0; y < text.Height(); y++ {
-		for x := 0; x < text.Width(); x++ {
-			var (
-				offset = y*text.Width() + x
-				char   = text.Buffer[offset]
-				attr   = char.Attributes()
-				font   = regular
-				bg     = ToRGB(char.BackgroundColor())
-				fg     = ToRGB(char.ForegroundColor())
-				r      = image.Rect(x*stridex, y*stridey, (x+1)*stridex, (y+1)*stridey)
-				i      *image.Uniform
-				ok     bool
-			)
+	var i uint8
+	if len(r.palette) < 0x100 {
+		i = uint8(len(r.palette))
+		r.palette = append(r.palette, c)
+	} else {
+		i = uint8(r.palette[:0x100].Index(c))
+	}
+	r.index[c] = i
+	return i
+}
 
-			// Parse attributes
-			if attr&Conceal == Conceal {
-				fg = bg
+// cellColor is a color of a cell, index is the index in the palette of the
+// buffer or -1 if the color isn't in the palette.
+type cellColor struct {
+	rgb   RGB
+	index int
+}
 
-			} else { /* not Conceal */
-				if attr&Reverse == Reverse {
-					fg, bg = bg, fg
-				}
-				if attr&Bold == Bold {
-					if j := ColorIndex(fg, palette); j > -1 && j < 8 {
-						fg = palette[j+8].(RGB)
-					}
-				}
-				if attr&Blink == Blink && text.DisableBlink {
-					if j := ColorIndex(bg, palette); j > -1 && j < 8 {
-						bg = palette[j+8].(RGB)
-					}
-				}
-				if attr&Standout == Standout {
-					font = italics
-				}
-			} /* Conceal */
+// cellColor returns the color of a cell, the palette index takes precedence
+// over the RGB color.
+func (r *Renderer) cellColor(c color.Color, i uint8, indexed bool) cellColor {
+	if indexed && int(i) < r.base {
+		return cellColor{ToRGB(r.palette[i]), int(i)}
+	}
+	rgb := ToRGB(c)
+	if j, ok := r.index[rgb]; ok && int(j) < r.base {
+		return cellColor{rgb, int(j)}
+	}
+	return cellColor{rgb, -1}
+}
 
-			//fmt.Printf("vga: image (%d, %d) %q fg=%s bg=%s attr=%s\n",x, y, char.CodePoint(), fg, bg, attr)
+// shift moves a color in the palette range [from, from+8) to the same color in
+// the range [to, to+8), used for bright and faint colors.
+func (r *Renderer) shift(c cellColor, from, to int) (cellColor, bool) {
+	if c.index < from || c.index >= from+8 || c.index-from+to >= r.base {
+		return c, false
+	}
+	i := c.index - from + to
+	return cellColor{ToRGB(r.palette[i]), i}, true
+}
 
-			// Draw background rectangle
-			if i, ok = colors[bg]; !ok {
-				i = image.NewUniform(bg)
-				colors[bg] = i
-			}
-			draw.Draw(im, r, i, image.ZP, draw.Src)
+// resolve the rendering attributes of a character.
+func (r *Renderer) resolve(char Character) cellKey {
+	var (
+		attr   = char.Attributes()
+		fi, fo = char.ForegroundIndex()
+		bi, bo = char.BackgroundIndex()
+		fg     = r.cellColor(char.ForegroundColor(), fi, fo)
+		bg     = r.cellColor(char.BackgroundColor(), bi, bo)
+		key    = cellKey{char: char.Glyph()}
+		ok     bool
+	)
 
-			// Draw character
-			if i, ok = colors[fg]; !ok {
-				i = image.NewUniform(fg)
-				colors[fg] = i
+	if attr&Conceal == Conceal {
+		fg = bg
+	} else {
+		if attr&Reverse == Reverse {
+			fg, bg = bg, fg
+		}
+		if attr&Bold == Bold {
+			fg, _ = r.shift(fg, 0, 8)
+			if r.text.BoldSmear {
+				key.variant = fontBold
 			}
-			if text.DisableBlink || attr&Blink != Blink || blink {
-				font.Draw(im, r.Min, i, uint16(char&charMask))
+		}
+		if attr&Blink == Blink && r.text.DisableBlink {
+			bg, _ = r.shift(bg, 0, 8)
+		}
+		if attr&Faint == Faint {
+			if fg, ok = r.shift(fg, 8, 0); !ok {
+				fg = cellColor{mixRGB(fg.rgb, bg.rgb), -1}
 			}
+		}
+		if attr&Standout == Standout {
+			key.variant = fontItalics
+		}
+	}
+
+	line := fg.rgb
+	if c, ok := char.UnderlineColor(); ok && attr&Conceal == 0 {
+		line = ToRGB(c)
+	}
+
+	if attr&Blink == Blink && !r.text.DisableBlink {
+		key.style |= styleBlink
+	}
+	if attr&CrossedOut == CrossedOut {
+		key.style |= styleCrossedOut
+	}
+	if attr&Underline == Underline {
+		key.style |= styleUnderline
+	}
+	if attr&DoubleUnderline == DoubleUnderline {
+		key.style |= styleDoubleUnderline
+	}
+	if attr&Overline == Overline {
+		key.style |= styleOverline
+	}
+	key.fg, key.bg, key.line = r.colorIndex(fg.rgb), r.colorIndex(bg.rgb), r.colorIndex(line)
+	return key
+}
+
+// mixRGB returns the color halfway between a and b.
+func mixRGB(a, b RGB) RGB {
+	return (a&0xfefefe)>>1 + (b&0xfefefe)>>1 + (a & b & 0x010101)
+}
 
-			// CrossedOut
-			if attr&CrossedOut == CrossedOut {
-				line := image.Rect(r.Min.X, r.Min.Y+size.Y/2, r.Max.X, r.Min.Y+size.Y/2+1)
-				draw.Draw(im, line, image.NewUniform(fg), image.ZP, draw.Src)
+// glyph returns the opaque pixels of a character in a font variant.
+func (r *Renderer) glyph(variant uint8, char uint16) []bool {
+	if bits, ok := r.glyphs[variant][char]; ok {
+		return bits
+	}
+	font := r.fonts[variant]
+	if char >= font.Mask.Characters() {
+		r.glyphs[variant][char] = nil
+		return nil
+	}
+	var (
+		mask, sp = font.CharMask(char)
+		bits     = make([]bool, r.size.X*r.size.Y)
+	)
+	if mask != nil {
+		for y := 0; y < r.size.Y; y++ {
+			for x := 0; x < r.size.X; x++ {
+				_, _, _, a := mask.At(sp.X+x, sp.Y+y).RGBA()
+				bits[y*r.size.X+x] = a >= 0x8000
 			}
-			// Underline
-			if attr&Underline == Underline {
-				line := image.Rect(r.Min.X, r.Max.Y-2, r.Max.X, r.Max.Y-1)
-				draw.Draw(im, line, image.NewUniform(fg), image.ZP, draw.Src)
+		}
+	}
+	r.glyphs[variant][char] = bits
+	return bits
+}
+
+// rasterise a cell for the glyph atlas.
+func (r *Renderer) rasterise(key cellKey) []uint8 {
+	pix := make([]uint8, r.cell.X*r.cell.Y)
+	for i := range pix {
+		pix[i] = key.bg
+	}
+	if key.style&styleHidden == 0 {
+		if bits := r.glyph(key.variant, key.char); bits != nil {
+			for y := 0; y < r.size.Y; y++ {
+				for x := 0; x < r.size.X; x++ {
+					if bits[y*r.size.X+x] {
+						pix[y*r.cell.X+x] = key.fg
+					}
+				}
 			}
 		}
-		if text.progressFunc != nil {
-			text.progressFunc(float64(y) / float64(text.height))
+	}
+	line := func(y int, c uint8) {
+		if y >= 0 && y < r.cell.Y {
+			for x := 0; x < r.cell.X; x++ {
+				pix[y*r.cell.X+x] = c
+			}
 		}
 	}
+	if key.style&styleCrossedOut != 0 {
+		line(r.size.Y/2, key.fg)
+	}
+	if key.style&styleOverline != 0 {
+		line(0, key.fg)
+	}
+	if key.style&styleDoubleUnderline != 0 {
+		line(r.cell.Y-3, key.line)
+		line(r.cell.Y-1, key.line)
+	} else if key.style&styleUnderline != 0 {
+		line(r.cell.Y-2, key.line)
+	}
+	return pix
+}
 
-	if text.progressFunc != nil {
-		text.progressFunc(1)
+// drawCells draws the cells in to im, with the top left cell at p. If blinkOnly
+// is set, only the blinking cells are drawn. Pixels outside of im are clipped.
+func (r *Renderer) drawCells(im *image.Paletted, p image.Point, cells image.Rectangle, blink, blinkOnly bool, progress func(float64)) {
+	if cells = cells.Intersect(r.cellBounds()); cells.Empty() {
+		return
 	}
 
-	return im, nil
+	// Phase 2 is to rasterise the cells that are not in the atlas yet.
+	var (
+		width = r.text.Width()
+		cols  = cells.Dx()
+		rows  = cells.Dy()
+		keys  = make([]cellKey, cols*rows)
+	)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			key := r.cells[(cells.Min.Y+y)*width+cells.Min.X+x]
+			if key.style&styleBlink != 0 && !blink {
+				key.style |= styleHidden
+			}
+			if _, ok := r.atlas[key]; !ok {
+				r.atlas[key] = r.rasterise(key)
+			}
+			keys[y*cols+x] = key
+		}
+	}
+
+	// Phase 3 is to copy the cells from the atlas in to the image, rendering
+	// bands of rows in parallel.
+	var (
+		bands = runtime.GOMAXPROCS(0)
+		done  int
+		lock  sync.Mutex
+		wait  sync.WaitGroup
+	)
+	if progress != nil {
+		progress(0)
+	}
+	if bands > rows {
+		bands = rows
+	}
+	for band := 0; band < bands; band++ {
+		wait.Add(1)
+		go func(top, bottom int) {
+			defer wait.Done()
+			for y := top; y < bottom; y++ {
+				for x := 0; x < cols; x++ {
+					key := keys[y*cols+x]
+					if blinkOnly && key.style&styleBlink == 0 {
+						continue
+					}
+					var (
+						min = p.Add(image.Pt(x*r.cell.X, y*r.cell.Y))
+						dr  = image.Rectangle{Min: min, Max: min.Add(r.cell)}.Intersect(im.Rect)
+						pix = r.atlas[key]
+					)
+					if dr.Empty() {
+						continue
+					}
+					for py := dr.Min.Y; py < dr.Max.Y; py++ {
+						src := (py-min.Y)*r.cell.X + dr.Min.X - min.X
+						copy(im.Pix[im.PixOffset(dr.Min.X, py):], pix[src:src+dr.Dx()])
+					}
+				}
+				if progress != nil {
+					lock.Lock()
+					done++
+					progress(float64(done) / float64(rows))
+					lock.Unlock()
+				}
+			}
+		}(band*rows/bands, (band+1)*rows/bands)
+	}
+	wait.Wait()
 }
@@ -0,0 +1,222 @@
+package vga
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/textmodes/parser/chargen"
+)
+
+// testFont is a 2x2 font where every character is a dot in the top left.
+func testFont() *chargen.Font {
+	data := make([]byte, 256*4/8)
+	for char := 0; char < 256; char++ {
+		bit := char * 4
+		data[bit>>3] |= 0x80 >> uint(bit&7)
+	}
+	return chargen.New(chargen.NewBytesMask(data, chargen.MaskOptions{Size: image.Pt(2, 2)}))
+}
+
+func testTextImage() *Text {
+	text := NewText(3, 2)
+	text.SetForegroundColor(Red)
+	text.SetBackgroundColor(Blue)
+	text.WriteCharacter('A')
+	text.SetAttribute(Blink)
+	text.WriteCharacter('B')
+	text.ResetAttributes()
+	text.SetAttribute(Bold)
+	text.SetForegroundColor(Green)
+	text.WriteCharacter('C')
+	text.ResetAttributes()
+	text.SetAttribute(Reverse)
+	text.WriteCharacter('D')
+	return text
+}
+
+func TestTextImage(t *testing.T) {
+	text := testTextImage()
+	im, err := text.Image(testFont(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if im.Rect != image.Rect(0, 0, 6, 4) {
+		t.Fatalf("expected 6x4 image, got %s", im.Rect)
+	}
+	for _, test := range []struct {
+		X, Y int
+		Want RGB
+	}{
+		{0, 0, Red},         // A
+		{1, 0, Blue},        // A background
+		{2, 0, Red},         // B, blinking
+		{4, 0, BrightGreen}, // C, bold
+		{5, 1, Black},       // C background
+		{0, 2, Black},       // D, reverse
+		{1, 2, White},       // D background
+	} {
+		if c := ToRGB(im.At(test.X, test.Y)); c != test.Want {
+			t.Errorf("pixel (%d, %d): expected %s, got %s", test.X, test.Y, test.Want, c)
+		}
+	}
+
+	if im, err = text.Image(testFont(), false); err != nil {
+		t.Fatal(err)
+	}
+	if c := ToRGB(im.At(2, 0)); c != Blue {
+		t.Errorf("expected blinking character to be hidden, got %s", c)
+	}
+
+	if _, err = text.Image(nil, true); err == nil {
+		t.Error("expected error for nil font")
+	}
+}
+
+func TestTextImageNineDot(t *testing.T) {
+	text := testTextImage()
+	text.NineDot = true
+	for _, test := range []struct {
+		Name string
+		Font *chargen.Font
+		Want image.Rectangle
+	}{
+		{"8 pixels", chargen.New(chargen.NewBytesMask(make([]byte, 256), chargen.MaskOptions{Size: image.Pt(8, 1)})), image.Rect(0, 0, 27, 2)},
+		{"2 pixels", testFont(), image.Rect(0, 0, 6, 4)},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			im, err := text.Image(test.Font, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if im.Rect != test.Want {
+				t.Fatalf("expected %s, got %s", test.Want, im.Rect)
+			}
+		})
+	}
+}
+
+func TestTextBlinkFrames(t *testing.T) {
+	var (
+		text = testTextImage()
+		font = testFont()
+	)
+	off, on, err := text.BlinkFrames(font)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, blink := range []bool{false, true} {
+		want, err := text.Image(font, blink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []*image.Paletted{off, on}[i]
+		if !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("blink %t: frame differs from Image", blink)
+		}
+	}
+}
+
+func BenchmarkTextImage(b *testing.B) {
+	var (
+		text = NewText(80, 2000)
+		font = testFont()
+	)
+	for i := range text.Buffer {
+		text.Buffer[i] = MakeCharacter(uint8(i), Palette[i%16], Palette[(i/16)%8])
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := text.Image(font, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTextImageLines(t *testing.T) {
+	var (
+		font = chargen.New(chargen.NewBytesMask(make([]byte, 256*2*4/8), chargen.MaskOptions{Size: image.Pt(2, 4)}))
+		text = NewText(4, 1)
+	)
+	text.SetAttribute(Faint | Overline)
+	text.WriteCharacter(' ')
+	text.SetForegroundColor(BrightRed)
+	text.WriteCharacter(' ')
+	text.ResetAttributes()
+	text.SetAttribute(DoubleUnderline)
+	text.SetUnderlineColor(Green)
+	text.WriteCharacter(' ')
+	text.ResetAttributes()
+	text.SetAttribute(Underline)
+	text.WriteCharacter(' ')
+
+	im, err := text.Image(font, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		X    int
+		Want [4]RGB
+	}{
+		{0, [4]RGB{BrightBlack, Black, Black, Black}}, // faint white
+		{2, [4]RGB{Red, Black, Black, Black}},         // faint bright red
+		{4, [4]RGB{Black, Green, Black, Green}},       // double underline
+		{6, [4]RGB{Black, Black, White, Black}},       // underline
+	} {
+		for y, want := range test.Want {
+			if c := ToRGB(im.At(test.X, y)); c != want {
+				t.Errorf("pixel (%d, %d): expected %s, got %s", test.X, y, want, c)
+			}
+		}
+	}
+}
+
+func TestTextImageIndexed(t *testing.T) {
+	text := NewText(2, 1)
+	text.SetForegroundIndex(1)
+	text.SetBackgroundIndex(4)
+	text.WriteCharacter('A')
+	text.SetForegroundColor(Red)
+	text.WriteCharacter('B')
+
+	if fg, bg := text.CellColors(text.Buffer[0]); ToRGB(fg) != Red || ToRGB(bg) != Blue {
+		t.Fatalf("expected red on blue, got %v on %v", fg, bg)
+	}
+
+	// Swapping the palette recolors the indexed cells only
+	text.Palette = make(color.Palette, 16)
+	copy(text.Palette, Palette)
+	text.Palette[1], text.Palette[4] = Green, Yellow
+	if fg, bg := text.CellColors(text.Buffer[0]); ToRGB(fg) != Green || ToRGB(bg) != Yellow {
+		t.Fatalf("expected green on yellow, got %v on %v", fg, bg)
+	}
+
+	im, err := text.Image(testFont(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		X    int
+		Want RGB
+	}{
+		{0, Green},  // A
+		{1, Yellow}, // A background
+		{2, Red},    // B, RGB color
+		{3, Yellow}, // B background
+	} {
+		if c := ToRGB(im.At(test.X, 0)); c != test.Want {
+			t.Errorf("pixel (%d, 0): expected %s, got %s", test.X, test.Want, c)
+		}
+	}
+
+	// Bold brightens by index, even if the RGB color is not in the palette
+	text.Buffer[0].SetAttribute(Bold)
+	text.Palette[9] = Cyan
+	if im, err = text.Image(testFont(), true); err != nil {
+		t.Fatal(err)
+	}
+	if c := ToRGB(im.At(0, 0)); c != Cyan {
+		t.Errorf("expected bold index 1 to use index 9, got %s", c)
+	}
+}
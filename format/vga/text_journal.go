@@ -0,0 +1,109 @@
+package vga
+
+// cellChange is a change to a cell of the buffer.
+type cellChange struct {
+	offset        int
+	before, after Character
+}
+
+// journal records the changes made by the editing functions, changes are
+// grouped in to edits that are undone and redone as a whole.
+type journal struct {
+	undo, redo [][]cellChange
+	edit       []cellChange
+	index      map[int]int // offset to index in edit
+	depth      int
+}
+
+// BeginEdit starts a group of edits that is undone and redone as a whole;
+// groups may be nested, the group ends with the outermost EndEdit. Every
+// editing function is an edit of its own if no group is started.
+func (text *Text) BeginEdit() {
+	text.journal.depth++
+}
+
+// EndEdit ends a group of edits started with BeginEdit.
+func (text *Text) EndEdit() {
+	j := &text.journal
+	if j.depth == 0 {
+		return
+	}
+	if j.depth--; j.depth > 0 || len(j.edit) == 0 {
+		return
+	}
+	j.undo = append(j.undo, j.edit)
+	if text.UndoLimit > 0 && len(j.undo) > text.UndoLimit {
+		j.undo = j.undo[len(j.undo)-text.UndoLimit:]
+	}
+	j.redo = nil
+	j.edit, j.index = nil, nil
+}
+
+// CanUndo checks if there are edits to undo.
+func (text *Text) CanUndo() bool {
+	return len(text.journal.undo) > 0
+}
+
+// CanRedo checks if there are undone edits to redo.
+func (text *Text) CanRedo() bool {
+	return len(text.journal.redo) > 0
+}
+
+// Undo the last edit, it returns false if there was nothing to undo.
+func (text *Text) Undo() bool {
+	j := &text.journal
+	if len(j.undo) == 0 || j.depth > 0 {
+		return false
+	}
+	edit := j.undo[len(j.undo)-1]
+	j.undo = j.undo[:len(j.undo)-1]
+	for i := len(edit) - 1; i >= 0; i-- {
+		if change := edit[i]; change.offset < len(text.Buffer) {
+			text.Buffer[change.offset] = change.before
+		}
+	}
+	j.redo = append(j.redo, edit)
+	return true
+}
+
+// Redo the last undone edit, it returns false if there was nothing to redo.
+func (text *Text) Redo() bool {
+	j := &text.journal
+	if len(j.redo) == 0 || j.depth > 0 {
+		return false
+	}
+	edit := j.redo[len(j.redo)-1]
+	j.redo = j.redo[:len(j.redo)-1]
+	for _, change := range edit {
+		if change.offset < len(text.Buffer) {
+			text.Buffer[change.offset] = change.after
+		}
+	}
+	j.undo = append(j.undo, edit)
+	return true
+}
+
+// ClearJournal forgets all edits to undo and redo.
+func (text *Text) ClearJournal() {
+	text.journal = journal{depth: text.journal.depth}
+}
+
+// setCell updates the cell at offset, recording the change in the journal.
+func (text *Text) setCell(offset int, char Character) {
+	before := text.Buffer[offset]
+	if before == char {
+		return
+	}
+	text.Buffer[offset] = char
+
+	j := &text.journal
+	if j.index == nil {
+		j.index = make(map[int]int)
+	}
+	if i, ok := j.index[offset]; ok {
+		j.edit[i].after = char
+		return
+	}
+	j.index[offset] = len(j.edit)
+	j.edit = append(j.edit, cellChange{offset: offset, before: before, after: char})
+}
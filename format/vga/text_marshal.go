@@ -0,0 +1,446 @@
+package vga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// ErrTextFormat is returned when unmarshaling an invalid buffer snapshot.
+var ErrTextFormat = errors.New("vga: invalid text format")
+
+// textMagic starts a binary Text snapshot, followed by the format version.
+const textMagic = "VGAT\x01"
+
+// Flags in a binary Text snapshot.
+const (
+	textAutoExpand = 1 << iota
+	textDisableBlink
+	textNineDot
+	textBoldSmear
+	textScrollRegion
+)
+
+/*
+MarshalBinary encodes the buffer, including the palette, cursors and scroll
+region, but not the edits recorded for Undo. All integers are unsigned
+varints, except for padding which is a signed varint:
+
+	"VGAT" 0x01 flags width height padding top bottom
+
+Followed by the cursor and saved cursor, as x, y and 16 bytes of character in
+little endian. Then the number of palette colors, zero for the default palette,
+followed by 3 bytes of RGB for every color. The cells are encoded like in
+Diff.Bytes, each cell relative to the previous one.
+*/
+func (text *Text) MarshalBinary() ([]byte, error) {
+	var (
+		b     = bytes.NewBufferString(textMagic)
+		v     = make([]byte, binary.MaxVarintLen64)
+		flags byte
+	)
+	putUvarint := func(x uint64) {
+		b.Write(v[:binary.PutUvarint(v, x)])
+	}
+	for flag, set := range map[byte]bool{
+		textAutoExpand:   text.AutoExpand,
+		textDisableBlink: text.DisableBlink,
+		textNineDot:      text.NineDot,
+		textBoldSmear:    text.BoldSmear,
+		textScrollRegion: text.scrollRegionActive,
+	} {
+		if set {
+			flags |= flag
+		}
+	}
+	b.WriteByte(flags)
+	putUvarint(uint64(text.width))
+	putUvarint(uint64(text.height))
+	b.Write(v[:binary.PutVarint(v, int64(text.Padding))])
+	putUvarint(uint64(text.scrollRegion[0]))
+	putUvarint(uint64(text.scrollRegion[1]))
+
+	for _, cursor := range []*textCursor{text.cursor, text.savedCursor} {
+		if cursor == nil {
+			cursor = newTextCursor()
+		}
+		putUvarint(uint64(cursor.X))
+		putUvarint(uint64(cursor.Y))
+		binary.LittleEndian.PutUint64(v, cursor.cell)
+		b.Write(v[:8])
+		binary.LittleEndian.PutUint64(v, cursor.ext)
+		b.Write(v[:8])
+	}
+
+	putUvarint(uint64(len(text.Palette)))
+	for _, c := range text.Palette {
+		rgb := ToRGB(c)
+		b.Write([]byte{byte(rgb >> 16), byte(rgb >> 8), byte(rgb)})
+	}
+
+	var prev Character
+	for _, char := range text.Buffer {
+		writeCell(b, char, prev)
+		prev = char
+	}
+	return b.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a buffer encoded by MarshalBinary, replacing the
+// buffer and clearing the edits recorded for Undo.
+func (text *Text) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(textMagic)) {
+		return ErrTextFormat
+	}
+
+	var (
+		r       = bytes.NewReader(data[len(textMagic):])
+		decoded = Text{UndoLimit: text.UndoLimit, progressFunc: text.progressFunc}
+		err     error
+	)
+	readUvarint := func() uint {
+		if err != nil {
+			return 0
+		}
+		var x uint64
+		if x, err = binary.ReadUvarint(r); err == nil && x > 1<<31 {
+			err = ErrTextFormat
+		}
+		return uint(x)
+	}
+	flags, err := r.ReadByte()
+	if err != nil {
+		return unexpected(err)
+	}
+	decoded.AutoExpand = flags&textAutoExpand != 0
+	decoded.DisableBlink = flags&textDisableBlink != 0
+	decoded.NineDot = flags&textNineDot != 0
+	decoded.BoldSmear = flags&textBoldSmear != 0
+	decoded.scrollRegionActive = flags&textScrollRegion != 0
+	decoded.width = readUvarint()
+	decoded.height = readUvarint()
+	if err == nil {
+		var padding int64
+		padding, err = binary.ReadVarint(r)
+		decoded.Padding = int(padding)
+	}
+	decoded.scrollRegion[0] = readUvarint()
+	decoded.scrollRegion[1] = readUvarint()
+
+	v := make([]byte, 16)
+	for _, cursor := range []**textCursor{&decoded.cursor, &decoded.savedCursor} {
+		*cursor = &textCursor{X: readUvarint(), Y: readUvarint()}
+		if err == nil {
+			_, err = io.ReadFull(r, v)
+		}
+		(*cursor).cell = binary.LittleEndian.Uint64(v[:8])
+		(*cursor).ext = binary.LittleEndian.Uint64(v[8:])
+	}
+
+	if n := readUvarint(); err == nil && n > 0 {
+		if n > 256 {
+			return ErrTextFormat
+		}
+		decoded.Palette = make(color.Palette, n)
+		for i := range decoded.Palette {
+			if _, err = io.ReadFull(r, v[:3]); err != nil {
+				return unexpected(err)
+			}
+			decoded.Palette[i] = NewRGB(v[0], v[1], v[2])
+		}
+	}
+	if err != nil {
+		return unexpected(err)
+	}
+
+	size := decoded.width * decoded.height
+	if size > uint(r.Len()) {
+		// Every cell takes at least one byte
+		return ErrTextFormat
+	}
+	decoded.Buffer = make(TextBuffer, size)
+	var (
+		prev    Character
+		scratch [8]byte
+	)
+	for i := range decoded.Buffer {
+		if decoded.Buffer[i], err = readCell(r, prev, &scratch, ErrTextFormat); err != nil {
+			return unexpected(err)
+		}
+		prev = decoded.Buffer[i]
+	}
+	if r.Len() > 0 {
+		return ErrTextFormat
+	}
+	if err = decoded.validate(); err != nil {
+		return err
+	}
+
+	*text = decoded
+	return nil
+}
+
+// validate checks the decoded cursors and scroll region against the size of
+// the buffer, so the decoded buffer can be written to.
+func (text *Text) validate() error {
+	if text.width < 1 || text.height < 1 {
+		return ErrTextFormat
+	}
+	for _, cursor := range []*textCursor{text.cursor, text.savedCursor} {
+		// After writing the last cell, the cursor is on the row below the
+		// buffer until the next write scrolls or expands the buffer
+		if cursor.X >= text.width || cursor.Y > text.height {
+			return ErrTextFormat
+		}
+	}
+	if text.scrollRegionActive && (text.scrollRegion[0] > text.scrollRegion[1] || text.scrollRegion[1] >= text.height) {
+		return ErrTextFormat
+	}
+	return nil
+}
+
+// unexpected converts io.EOF to io.ErrUnexpectedEOF.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// MarshalText encodes the color as "#rrggbb".
+func (rgb RGB) MarshalText() ([]byte, error) {
+	return []byte(rgb.String()), nil
+}
+
+// UnmarshalText decodes a color encoded as "#rrggbb".
+func (rgb *RGB) UnmarshalText(text []byte) error {
+	var r, g, b uint8
+	if len(text) != 7 {
+		return fmt.Errorf("vga: invalid color %q", text)
+	}
+	if _, err := fmt.Sscanf(string(text), "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return fmt.Errorf("vga: invalid color %q", text)
+	}
+	*rgb = NewRGB(r, g, b)
+	return nil
+}
+
+// jsonCharacter is the JSON representation of a Character.
+type jsonCharacter struct {
+	Glyph           uint16   `json:"glyph"`
+	Foreground      RGB      `json:"fg"`
+	Background      RGB      `json:"bg"`
+	ForegroundIndex *uint8   `json:"fgIndex,omitempty"`
+	BackgroundIndex *uint8   `json:"bgIndex,omitempty"`
+	UnderlineColor  *RGB     `json:"underlineColor,omitempty"`
+	Attributes      []string `json:"attributes,omitempty"`
+}
+
+// MarshalJSON encodes the character as a JSON object with the glyph index,
+// "#rrggbb" colors, palette indexes if set and the names of the attributes.
+func (char Character) MarshalJSON() ([]byte, error) {
+	v := jsonCharacter{
+		Glyph:      char.Glyph(),
+		Foreground: ToRGB(char.ForegroundColor()),
+		Background: ToRGB(char.BackgroundColor()),
+	}
+	if i, ok := char.ForegroundIndex(); ok {
+		v.ForegroundIndex = &i
+	}
+	if i, ok := char.BackgroundIndex(); ok {
+		v.BackgroundIndex = &i
+	}
+	if c, ok := char.UnderlineColor(); ok {
+		rgb := ToRGB(c)
+		v.UnderlineColor = &rgb
+	}
+	attrs := char.Attributes()
+	for _, a := range attributeNames {
+		if attrs&a.Attribute == a.Attribute {
+			v.Attributes = append(v.Attributes, a.Name)
+		}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a character encoded by MarshalJSON.
+func (char *Character) UnmarshalJSON(data []byte) error {
+	var v jsonCharacter
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var decoded Character
+	decoded.SetGlyph(v.Glyph)
+	decoded.SetForegroundColor(v.Foreground)
+	decoded.SetBackgroundColor(v.Background)
+	if v.ForegroundIndex != nil {
+		decoded.SetForegroundIndex(*v.ForegroundIndex)
+	}
+	if v.BackgroundIndex != nil {
+		decoded.SetBackgroundIndex(*v.BackgroundIndex)
+	}
+	if v.UnderlineColor != nil {
+		decoded.SetUnderlineColor(*v.UnderlineColor)
+	}
+	var attrs Attribute
+	for _, name := range v.Attributes {
+		var found bool
+		for _, a := range attributeNames {
+			if strings.EqualFold(name, a.Name) {
+				attrs |= a.Attribute
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("vga: unknown attribute %q", name)
+		}
+	}
+	decoded.SetAttributes(attrs)
+
+	*char = decoded
+	return nil
+}
+
+// jsonText is the JSON representation of a Text.
+type jsonText struct {
+	Width        uint       `json:"width"`
+	Height       uint       `json:"height"`
+	Palette      []RGB      `json:"palette,omitempty"`
+	AutoExpand   bool       `json:"autoExpand,omitempty"`
+	DisableBlink bool       `json:"disableBlink,omitempty"`
+	NineDot      bool       `json:"nineDot,omitempty"`
+	BoldSmear    bool       `json:"boldSmear,omitempty"`
+	Padding      int        `json:"padding,omitempty"`
+	ScrollRegion *[2]uint   `json:"scrollRegion,omitempty"`
+	Cursor       jsonCursor `json:"cursor"`
+	SavedCursor  jsonCursor `json:"savedCursor"`
+	Cells        TextBuffer `json:"cells"`
+}
+
+// jsonCursor is the JSON representation of a textCursor, the character holds
+// the colors and attributes used for writing.
+type jsonCursor struct {
+	X         uint      `json:"x"`
+	Y         uint      `json:"y"`
+	Character Character `json:"character"`
+}
+
+/*
+MarshalJSON encodes the buffer as a JSON object, with the cells in row order:
+
+	{
+	  "width": 80,
+	  "height": 25,
+	  "palette": ["#000000", "#aa0000", ...],
+	  "disableBlink": true,
+	  "scrollRegion": [0, 24],
+	  "cursor": {"x": 0, "y": 0, "character": {...}},
+	  "savedCursor": {"x": 0, "y": 0, "character": {...}},
+	  "cells": [
+	    {
+	      "glyph": 65,
+	      "fg": "#aa0000",
+	      "bg": "#000000",
+	      "fgIndex": 1,
+	      "bgIndex": 0,
+	      "underlineColor": "#00aa00",
+	      "attributes": ["bold", "blink"]
+	    },
+	    ...
+	  ]
+	}
+
+The palette is omitted for the default palette, as are flags that are not
+set. Colors with a palette index should be looked up in the palette, the RGB
+value is the palette color at the time the index was set.
+*/
+func (text *Text) MarshalJSON() ([]byte, error) {
+	v := jsonText{
+		Width:        text.width,
+		Height:       text.height,
+		AutoExpand:   text.AutoExpand,
+		DisableBlink: text.DisableBlink,
+		NineDot:      text.NineDot,
+		BoldSmear:    text.BoldSmear,
+		Padding:      text.Padding,
+		Cells:        text.Buffer,
+	}
+	if v.Cells == nil {
+		v.Cells = TextBuffer{}
+	}
+	for _, c := range text.Palette {
+		v.Palette = append(v.Palette, ToRGB(c))
+	}
+	if text.scrollRegionActive {
+		v.ScrollRegion = &text.scrollRegion
+	}
+	for _, cursor := range []struct {
+		src *textCursor
+		dst *jsonCursor
+	}{
+		{text.cursor, &v.Cursor},
+		{text.savedCursor, &v.SavedCursor},
+	} {
+		if cursor.src == nil {
+			cursor.src = newTextCursor()
+		}
+		*cursor.dst = jsonCursor{X: cursor.src.X, Y: cursor.src.Y, Character: cursor.src.Character}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a buffer encoded by MarshalJSON, replacing the buffer
+// and clearing the edits recorded for Undo.
+func (text *Text) UnmarshalJSON(data []byte) error {
+	var v jsonText
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Width > 1<<31 || v.Height > 1<<31 {
+		return ErrTextFormat
+	}
+	if size := uint64(v.Width) * uint64(v.Height); uint64(len(v.Cells)) != size {
+		return fmt.Errorf("vga: expected %d cells for %dx%d text, got %d", size, v.Width, v.Height, len(v.Cells))
+	}
+	if len(v.Palette) > 256 {
+		return ErrTextFormat
+	}
+
+	decoded := Text{
+		AutoExpand:   v.AutoExpand,
+		DisableBlink: v.DisableBlink,
+		NineDot:      v.NineDot,
+		BoldSmear:    v.BoldSmear,
+		Padding:      v.Padding,
+		UndoLimit:    text.UndoLimit,
+		Buffer:       v.Cells,
+		width:        v.Width,
+		height:       v.Height,
+		cursor:       &textCursor{Character: v.Cursor.Character, X: v.Cursor.X, Y: v.Cursor.Y},
+		savedCursor:  &textCursor{Character: v.SavedCursor.Character, X: v.SavedCursor.X, Y: v.SavedCursor.Y},
+		progressFunc: text.progressFunc,
+	}
+	if v.Palette != nil {
+		decoded.Palette = make(color.Palette, len(v.Palette))
+		for i, c := range v.Palette {
+			decoded.Palette[i] = c
+		}
+	}
+	if v.ScrollRegion != nil {
+		decoded.scrollRegion = *v.ScrollRegion
+		decoded.scrollRegionActive = true
+	}
+	if err := decoded.validate(); err != nil {
+		return err
+	}
+
+	*text = decoded
+	return nil
+}
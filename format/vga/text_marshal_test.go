@@ -0,0 +1,147 @@
+package vga
+
+import (
+	"encoding/json"
+	"image/color"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func testMarshalText() *Text {
+	text := testTextImage()
+	text.Palette = make(color.Palette, 16)
+	copy(text.Palette, Palette)
+	text.DisableBlink = true
+	text.Padding = -1
+	text.SetScrollRegion(0, 1)
+	text.SetUnderlineColor(Green)
+	text.SetAttribute(DoubleUnderline)
+	text.SetForegroundIndex(9)
+	text.Goto(1, 1)
+	text.SaveCursor()
+	text.WriteCodePoint(0x1ff)
+	return text
+}
+
+func testTextEqual(t *testing.T, want, got *Text) {
+	t.Helper()
+	want.journal, got.journal = journal{}, journal{}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected\n%#+v\ngot\n%#+v", want, got)
+	}
+}
+
+func TestTextMarshalBinary(t *testing.T) {
+	text := testMarshalText()
+	data, err := text.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(Text)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	testTextEqual(t, text, decoded)
+
+	for _, test := range []struct {
+		Name string
+		Data []byte
+		Want error
+	}{
+		{"Magic", []byte("VGAT\x02"), ErrTextFormat},
+		{"Short", data[:len(data)-1], io.ErrUnexpectedEOF},
+		{"Trailing", append(data[:len(data):len(data)], 0), ErrTextFormat},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if err := new(Text).UnmarshalBinary(test.Data); err != test.Want {
+				t.Fatalf("expected error %v, got %v", test.Want, err)
+			}
+		})
+	}
+}
+
+func TestTextMarshalJSON(t *testing.T) {
+	text := testMarshalText()
+	data, err := json.Marshal(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(Text)
+	if err = json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	testTextEqual(t, text, decoded)
+
+	var char Character
+	if err = json.Unmarshal([]byte(`{"glyph":65,"fg":"#aa0000","bg":"#0000aa","fgIndex":1,"attributes":["bold","crossed out"]}`), &char); err != nil {
+		t.Fatal(err)
+	}
+	want := MakeCharacter('A', Red, Blue)
+	want.SetForegroundIndex(1)
+	want.SetAttributes(Bold | CrossedOut)
+	if char != want {
+		t.Fatalf("expected %#+v, got %#+v", want, char)
+	}
+	if err = json.Unmarshal([]byte(`{"glyph":65,"fg":"red"}`), &char); err == nil {
+		t.Fatal("expected error for invalid color")
+	}
+
+	for _, data := range []string{
+		`{"width":4294967296,"height":4294967296,"cells":[]}`,
+		`{"width":2,"height":3,"cells":[]}`,
+	} {
+		if err = json.Unmarshal([]byte(data), new(Text)); err == nil {
+			t.Fatalf("expected error for %s", data)
+		}
+	}
+}
+
+func TestTextUnmarshalInvalid(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Modify func(*Text)
+	}{
+		{"Empty", func(text *Text) { *text = *NewText(0, 0) }},
+		{"Cursor X", func(text *Text) { text.cursor.X = text.width }},
+		{"Cursor Y", func(text *Text) { text.cursor.Y = text.height + 1 }},
+		{"Saved cursor", func(text *Text) { text.savedCursor.Y = 1 << 31 }},
+		{"Scroll region order", func(text *Text) { text.scrollRegion = [2]uint{1, 0} }},
+		{"Scroll region bounds", func(text *Text) { text.scrollRegion = [2]uint{0, text.height} }},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			text := testMarshalText()
+			test.Modify(text)
+
+			data, err := text.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = new(Text).UnmarshalBinary(data); err != ErrTextFormat {
+				t.Errorf("binary: expected error %v, got %v", ErrTextFormat, err)
+			}
+			if data, err = json.Marshal(text); err != nil {
+				t.Fatal(err)
+			}
+			if err = json.Unmarshal(data, new(Text)); err != ErrTextFormat {
+				t.Errorf("json: expected error %v, got %v", ErrTextFormat, err)
+			}
+		})
+	}
+
+	// Writing the last cell moves the cursor below the buffer
+	text := NewText(2, 1)
+	text.WriteString("ab")
+	data, err := text.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(Text)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	decoded.WriteCharacter('c')
+	if c := decoded.Buffer[0].CodePoint(); c != 'c' {
+		t.Fatalf("expected buffer to scroll, got %q", c)
+	}
+}
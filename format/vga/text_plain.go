@@ -0,0 +1,69 @@
+package vga
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/textmodes/parser/chargen"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// PlainTextOptions are the options for PlainText.
+type PlainTextOptions struct {
+	// Runes maps characters to runes, such as returned by
+	// chargen.CodePageRunes or sauce.Runes for the font in use. If nil, code
+	// page 437 is used.
+	Runes []rune
+
+	// BlankBlocks replaces block and shade characters by spaces.
+	BlankBlocks bool
+}
+
+// PlainText converts the buffer to Unicode text, one line per row with the
+// trailing blanks and blank lines removed. Glyphs beyond the runes, such as
+// the second half of 512 character fonts, use the rune of their code point.
+func (text *Text) PlainText(opts PlainTextOptions) string {
+	runes := opts.Runes
+	if runes == nil {
+		runes = chargen.CodePageRunes(charmap.CodePage437)
+	}
+
+	var (
+		b     strings.Builder
+		line  = make([]rune, text.width)
+		blank int // pending blank lines
+	)
+	for y := uint(0); y < text.height; y++ {
+		for x, char := range text.Buffer[y*text.width : (y+1)*text.width] {
+			var r rune = utf8.RuneError
+			if glyph := int(char.Glyph()); glyph < len(runes) {
+				r = runes[glyph]
+			} else if int(char.CodePoint()) < len(runes) {
+				r = runes[char.CodePoint()]
+			}
+			if r < ' ' || r == utf8.RuneError || (opts.BlankBlocks && isBlockRune(r)) {
+				r = ' '
+			}
+			line[x] = r
+		}
+
+		s := strings.TrimRightFunc(string(line), unicode.IsSpace)
+		if s == "" {
+			blank++
+			continue
+		}
+		for ; blank > 0; blank-- {
+			b.WriteByte('\n')
+		}
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// isBlockRune checks if r is a block or shade element.
+func isBlockRune(r rune) bool {
+	return (r >= 0x2580 && r <= 0x259f) || // Block Elements
+		(r >= 0x1fb00 && r <= 0x1fb9f) // Symbols for Legacy Computing blocks and shades
+}
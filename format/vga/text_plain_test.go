@@ -0,0 +1,31 @@
+package vga
+
+import (
+	"testing"
+
+	"github.com/textmodes/parser/chargen"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestTextPlainText(t *testing.T) {
+	text := NewText(6, 4)
+	text.WriteString("\xdb\xb0 Hi \x00")
+	text.Goto(0, 2)
+	text.WriteString("\x86\x01 ")
+
+	for _, test := range []struct {
+		Name string
+		Opts PlainTextOptions
+		Want string
+	}{
+		{"CP437", PlainTextOptions{}, "█░ Hi\n\nå☺\n"},
+		{"CP437 no blocks", PlainTextOptions{BlankBlocks: true}, "   Hi\n\nå☺\n"},
+		{"CP866", PlainTextOptions{Runes: chargen.CodePageRunes(charmap.CodePage866)}, "█░ Hi\n\nЖ☺\n"},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := text.PlainText(test.Opts); got != test.Want {
+				t.Fatalf("expected %q, got %q", test.Want, got)
+			}
+		})
+	}
+}
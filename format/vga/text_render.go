@@ -0,0 +1,153 @@
+package vga
+
+import (
+	"image"
+	"image/draw"
+	"io"
+
+	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/image/pngstream"
+)
+
+// stripBytes is the approximate size of the strips used by WritePNG.
+const stripBytes = 1 << 20
+
+// Draw renders the cells in the rectangle of cells in to dst, with the top left
+// cell at point p. Images created with the Palette of the Renderer are drawn
+// directly, other images are drawn through an intermediate image of the cells.
+func (r *Renderer) Draw(dst draw.Image, p image.Point, cells image.Rectangle, blink bool) {
+	if cells = cells.Intersect(r.cellBounds()); cells.Empty() {
+		return
+	}
+	if im, ok := dst.(*image.Paletted); ok && r.samePalette(im) {
+		r.drawCells(im, p, cells, blink, false, nil)
+		return
+	}
+	size := image.Pt(cells.Dx()*r.cell.X, cells.Dy()*r.cell.Y)
+	im := image.NewPaletted(image.Rectangle{Min: p, Max: p.Add(size)}, r.palette)
+	r.drawCells(im, p, cells, blink, false, nil)
+	draw.Draw(dst, im.Rect, im, im.Rect.Min, draw.Src)
+}
+
+// samePalette checks if the image uses the palette of the Renderer.
+func (r *Renderer) samePalette(im *image.Paletted) bool {
+	return len(im.Palette) == len(r.palette) && (len(r.palette) == 0 || &im.Palette[0] == &r.palette[0])
+}
+
+// Strips returns an iterator over horizontal strips of the image of the whole
+// buffer, each strip has rows text rows (the last strip may have less).
+func (r *Renderer) Strips(rows int, blink bool) *Strips {
+	if rows < 1 {
+		rows = 1
+	}
+	return &Strips{r: r, rows: rows, blink: blink}
+}
+
+// Strips iterates over horizontal strips of a rendered buffer.
+/*
+
+Usage:
+
+	strips := renderer.Strips(25, false)
+	for strips.Next() {
+		im := strips.Image()
+		// ...
+	}
+
+*/
+type Strips struct {
+	r     *Renderer
+	rows  int
+	blink bool
+	cells image.Rectangle
+	pix   []uint8
+	im    *image.Paletted
+}
+
+// Next renders the next strip, it returns false if there are no strips left.
+func (s *Strips) Next() bool {
+	var (
+		bounds = s.r.cellBounds()
+		top    = bounds.Min.Y
+	)
+	if s.im != nil {
+		top = s.cells.Max.Y
+	}
+	if top >= bounds.Max.Y {
+		return false
+	}
+	s.cells = image.Rect(bounds.Min.X, top, bounds.Max.X, min(top+s.rows, bounds.Max.Y))
+
+	var (
+		cell = s.r.cell
+		rect = image.Rect(0, s.cells.Min.Y*cell.Y, s.cells.Dx()*cell.X, s.cells.Max.Y*cell.Y)
+	)
+	if s.pix == nil {
+		s.pix = make([]uint8, rect.Dx()*s.rows*cell.Y)
+	}
+	s.im = &image.Paletted{
+		Pix:     s.pix[:rect.Dx()*rect.Dy()],
+		Stride:  rect.Dx(),
+		Rect:    rect,
+		Palette: s.r.palette,
+	}
+	s.r.drawCells(s.im, rect.Min, s.cells, s.blink, false, nil)
+	return true
+}
+
+// Image is the current strip, its bounds are the bounds of the strip in the
+// image of the whole buffer. The image is reused by the next call to Next.
+func (s *Strips) Image() *image.Paletted {
+	return s.im
+}
+
+// Cells is the rectangle of cells in the current strip.
+func (s *Strips) Cells() image.Rectangle {
+	return s.cells
+}
+
+// DrawCells renders the cells in the rectangle of cells in to dst with the
+// specified chargen font, with the top left cell at point p. See
+// Renderer.Draw.
+func (text *Text) DrawCells(dst draw.Image, p image.Point, cells image.Rectangle, font *chargen.Font, blink bool) error {
+	r, err := text.NewRenderer(font)
+	if err != nil {
+		return err
+	}
+	r.Draw(dst, p, cells, blink)
+	return nil
+}
+
+// WritePNG renders the buffer with the specified chargen font as PNG image to
+// w. The image is rendered and encoded in strips, so the full image is never
+// in memory.
+func (text *Text) WritePNG(w io.Writer, font *chargen.Font, blink bool) error {
+	r, err := text.NewRenderer(font)
+	if err != nil {
+		return err
+	}
+	var (
+		bounds = r.Bounds()
+		rows   = 1
+	)
+	if n := bounds.Dx() * r.cell.Y; n > 0 {
+		rows = max(1, stripBytes/n)
+	}
+	pw, err := pngstream.NewWriter(w, bounds.Dx(), bounds.Dy(), r.palette)
+	if err != nil {
+		return err
+	}
+	var (
+		progress = text.progressFunc
+		height   = text.Height()
+	)
+	for strips := r.Strips(rows, blink); strips.Next(); {
+		if err = pw.WriteRows(strips.Image()); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(float64(strips.Cells().Max.Y) / float64(height))
+		}
+	}
+	return pw.Close()
+}
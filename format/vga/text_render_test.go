@@ -0,0 +1,116 @@
+package vga
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestRendererDraw(t *testing.T) {
+	var (
+		text = testTextImage()
+		font = testFont()
+	)
+	want, err := text.Image(font, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := text.NewRenderer(font)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Paletted image with the renderer palette, offset and clipped
+	im := image.NewPaletted(image.Rect(0, 0, 3, 2), r.Palette())
+	r.Draw(im, image.Pt(-1, 0), image.Rect(1, 1, 3, 2), true)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			if got, want := im.ColorIndexAt(x, y), want.ColorIndexAt(x+3, y+2); got != want {
+				t.Errorf("paletted pixel (%d, %d): expected %d, got %d", x, y, want, got)
+			}
+		}
+	}
+
+	// Any other image
+	rgba := image.NewRGBA(image.Rect(0, 0, 6, 4))
+	if err = text.DrawCells(rgba, image.Point{}, image.Rect(0, 0, 3, 2), font, true); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 6; x++ {
+			if got, want := ToRGB(rgba.At(x, y)), ToRGB(want.At(x, y)); got != want {
+				t.Errorf("RGBA pixel (%d, %d): expected %s, got %s", x, y, want, got)
+			}
+		}
+	}
+}
+
+func TestRendererStrips(t *testing.T) {
+	var (
+		text = NewText(5, 7)
+		font = testFont()
+	)
+	for i := range text.Buffer {
+		text.Buffer[i] = MakeCharacter(uint8(i), Palette[i%16], Palette[(i/16)%8])
+	}
+	want, err := text.Image(font, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := text.NewRenderer(font)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		got    = image.NewPaletted(want.Rect, want.Palette)
+		strips = r.Strips(3, true)
+		n      int
+	)
+	for strips.Next() {
+		im := strips.Image()
+		if im.Rect.Min.Y != n*3*2 {
+			t.Fatalf("strip %d: unexpected bounds %s", n, im.Rect)
+		}
+		for y := im.Rect.Min.Y; y < im.Rect.Max.Y; y++ {
+			copy(got.Pix[got.PixOffset(0, y):], im.Pix[im.PixOffset(0, y):im.PixOffset(im.Rect.Max.X, y)])
+		}
+		n++
+	}
+	if n != 3 {
+		t.Errorf("expected 3 strips, got %d", n)
+	}
+	if !bytes.Equal(got.Pix, want.Pix) {
+		t.Error("strips differ from Image")
+	}
+}
+
+func TestTextWritePNG(t *testing.T) {
+	var (
+		text = testTextImage()
+		font = testFont()
+		b    = new(bytes.Buffer)
+	)
+	if err := text.WritePNG(b, font, false); err != nil {
+		t.Fatal(err)
+	}
+	got, err := png.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := text.Image(font, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("expected bounds %s, got %s", want.Bounds(), got.Bounds())
+	}
+	for y := 0; y < want.Rect.Dy(); y++ {
+		for x := 0; x < want.Rect.Dx(); x++ {
+			if c, w := ToRGB(got.At(x, y)), ToRGB(want.At(x, y)); c != w {
+				t.Errorf("pixel (%d, %d): expected %s, got %s", x, y, w, c)
+			}
+		}
+	}
+}
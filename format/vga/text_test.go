@@ -178,3 +178,36 @@ func TestTextWrites(t *testing.T) {
 		}
 	})
 }
+
+func TestTextCellAttribute(t *testing.T) {
+	text := NewText(1, 1)
+	for _, test := range []struct {
+		Name   string
+		FG, BG uint8
+		Attr   Attribute
+		Want   uint8
+	}{
+		{"default", 7, 0, 0, 0x07},
+		{"red on blue", 1, 4, 0, 0x14},
+		{"bright", 9, 12, 0, 0x9c},
+		{"bold", 3, 0, Bold, 0x0e},
+		{"blink", 7, 2, Blink, 0xa7},
+		{"reverse", 1, 6, Reverse, 0x43},
+		{"reverse bold", 1, 6, Reverse | Bold, 0x4b},
+		{"conceal", 7, 4, Conceal, 0x11},
+		{"256 colors", 196, 21, 0, 0x9c},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			char := MakeIndexedCharacter('x', test.FG, test.BG)
+			char.SetAttributes(test.Attr)
+			if got := text.CellAttribute(char); got != test.Want {
+				t.Fatalf("expected attribute %#02x, got %#02x", test.Want, got)
+			}
+		})
+	}
+
+	char := MakeCharacter('x', NewRGB(0xa0, 0x10, 0x10), NewRGB(0, 0, 0))
+	if got := text.CellAttribute(char); got != 0x04 {
+		t.Fatalf("expected nearest color attribute 0x04, got %#02x", got)
+	}
+}
@@ -0,0 +1,173 @@
+package aspect
+
+import (
+	"image"
+	"image/color"
+)
+
+// Filter is the resampling filter.
+type Filter int
+
+// Filters.
+const (
+	// Nearest uses nearest neighbour sampling, paletted images remain
+	// paletted.
+	Nearest Filter = iota
+
+	// Area averages the colors of the source pixels covered by each
+	// destination pixel.
+	Area
+)
+
+func (filter Filter) String() string {
+	switch filter {
+	case Nearest:
+		return "nearest"
+	case Area:
+		return "area"
+	default:
+		return "invalid"
+	}
+}
+
+// Correct stretches the image so the pixels, with an aspect ratio of pixel
+// (width:height), are displayed as square pixels. The image is only ever
+// enlarged: tall pixels stretch the height and wide pixels stretch the width.
+// If the ratio is empty or square, the image is returned as-is.
+func Correct(m image.Image, pixel image.Point, filter Filter) image.Image {
+	if pixel.X <= 0 || pixel.Y <= 0 || pixel.X == pixel.Y {
+		return m
+	}
+	r := m.Bounds()
+	if pixel.X < pixel.Y {
+		r.Min.Y = scale(r.Min.Y, pixel.Y, pixel.X)
+		r.Max.Y = scale(r.Max.Y, pixel.Y, pixel.X)
+	} else {
+		r.Min.X = scale(r.Min.X, pixel.X, pixel.Y)
+		r.Max.X = scale(r.Max.X, pixel.X, pixel.Y)
+	}
+	return resample(m, r, filter)
+}
+
+// scale v by n/d, rounded to the nearest integer.
+func scale(v, n, d int) int {
+	if v < 0 {
+		return -scale(-v, n, d)
+	}
+	return (2*v*n + d) / (2 * d)
+}
+
+func resample(m image.Image, r image.Rectangle, filter Filter) image.Image {
+	if r.Empty() {
+		return image.NewRGBA(r)
+	}
+	switch filter {
+	case Area:
+		return resampleArea(m, r)
+	default:
+		return resampleNearest(m, r)
+	}
+}
+
+func resampleNearest(m image.Image, r image.Rectangle) image.Image {
+	var (
+		src = m.Bounds()
+		xs  = nearest(src.Min.X, src.Dx(), r.Dx())
+		ys  = nearest(src.Min.Y, src.Dy(), r.Dy())
+	)
+	if p, ok := m.(*image.Paletted); ok {
+		dst := image.NewPaletted(r, p.Palette)
+		for y := 0; y < r.Dy(); y++ {
+			for x := 0; x < r.Dx(); x++ {
+				dst.Pix[y*dst.Stride+x] = p.ColorIndexAt(xs[x], ys[y])
+			}
+		}
+		return dst
+	}
+	dst := image.NewRGBA(r)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			dst.Set(r.Min.X+x, r.Min.Y+y, m.At(xs[x], ys[y]))
+		}
+	}
+	return dst
+}
+
+// nearest returns the source coordinate for each of the n destination
+// coordinates, sampling at the center of the destination pixels.
+func nearest(origin, size, n int) []int {
+	v := make([]int, n)
+	for i := range v {
+		v[i] = origin + ((2*i+1)*size)/(2*n)
+	}
+	return v
+}
+
+// weight of a source pixel for a destination pixel.
+type weight struct {
+	i, w int
+}
+
+// weights returns the source pixels and their weights for each of the n
+// destination pixels. A source pixel is n units wide and a destination pixel
+// is size units wide, so the weights of each destination pixel add up to size.
+func weights(origin, size, n int) [][]weight {
+	v := make([][]weight, n)
+	for i := range v {
+		lo, hi := i*size, (i+1)*size
+		for j := lo / n; j*n < hi; j++ {
+			if w := min(hi, (j+1)*n) - max(lo, j*n); w > 0 {
+				v[i] = append(v[i], weight{origin + j, w})
+			}
+		}
+	}
+	return v
+}
+
+func resampleArea(m image.Image, r image.Rectangle) image.Image {
+	var (
+		src   = m.Bounds()
+		xs    = weights(src.Min.X, src.Dx(), r.Dx())
+		ys    = weights(src.Min.Y, src.Dy(), r.Dy())
+		total = uint64(src.Dx()) * uint64(src.Dy())
+		dst   = image.NewRGBA(r)
+	)
+	for y, wys := range ys {
+		for x, wxs := range xs {
+			var sr, sg, sb, sa uint64
+			for _, wy := range wys {
+				for _, wx := range wxs {
+					var (
+						w              = uint64(wx.w) * uint64(wy.w)
+						cr, cg, cb, ca = m.At(wx.i, wy.i).RGBA()
+					)
+					sr += uint64(cr) * w
+					sg += uint64(cg) * w
+					sb += uint64(cb) * w
+					sa += uint64(ca) * w
+				}
+			}
+			dst.SetRGBA(r.Min.X+x, r.Min.Y+y, color.RGBA{
+				R: uint8((sr + total/2) / total >> 8),
+				G: uint8((sg + total/2) / total >> 8),
+				B: uint8((sb + total/2) / total >> 8),
+				A: uint8((sa + total/2) / total >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,82 @@
+package aspect
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func testImage() *image.Paletted {
+	// 4x2 checker board of 2x1 blocks
+	im := image.NewPaletted(image.Rect(0, 0, 4, 2), color.Palette{color.Black, color.White})
+	im.Pix = []uint8{
+		1, 1, 0, 0,
+		0, 0, 1, 1,
+	}
+	return im
+}
+
+func TestCorrect(t *testing.T) {
+	for _, test := range []struct {
+		Name  string
+		Pixel image.Point
+		Want  image.Rectangle
+	}{
+		{"square", image.Pt(1, 1), image.Rect(0, 0, 4, 2)},
+		{"empty", image.Point{}, image.Rect(0, 0, 4, 2)},
+		{"tall", image.Pt(1, 2), image.Rect(0, 0, 4, 4)},
+		{"wide", image.Pt(3, 2), image.Rect(0, 0, 6, 2)},
+		{"vga", image.Pt(20, 27), image.Rect(0, 0, 4, 3)},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if r := Correct(testImage(), test.Pixel, Nearest).Bounds(); r != test.Want {
+				t.Fatalf("expected bounds %s, got %s", test.Want, r)
+			}
+		})
+	}
+}
+
+func TestNearest(t *testing.T) {
+	m := Correct(testImage(), image.Pt(1, 2), Nearest)
+	p, ok := m.(*image.Paletted)
+	if !ok {
+		t.Fatalf("expected *image.Paletted, got %T", m)
+	}
+	want := []uint8{
+		1, 1, 0, 0,
+		1, 1, 0, 0,
+		0, 0, 1, 1,
+		0, 0, 1, 1,
+	}
+	for i, v := range want {
+		if p.Pix[i] != v {
+			t.Fatalf("pixel %d: expected %d, got %d", i, v, p.Pix[i])
+		}
+	}
+}
+
+func TestArea(t *testing.T) {
+	// Stretching 2 rows to 3 rows, the middle row averages both rows
+	m := Correct(testImage(), image.Pt(2, 3), Area)
+	for _, test := range []struct {
+		X, Y int
+		Want uint8
+	}{
+		{0, 0, 0xff},
+		{2, 0, 0x00},
+		{0, 1, 0x80},
+		{2, 1, 0x80},
+		{0, 2, 0x00},
+		{2, 2, 0xff},
+	} {
+		if c := color.GrayModel.Convert(m.At(test.X, test.Y)).(color.Gray); c.Y != test.Want {
+			t.Errorf("pixel (%d, %d): expected %#02x, got %#02x", test.X, test.Y, test.Want, c.Y)
+		}
+	}
+
+	// Downscaling averages the whole block
+	m = resample(testImage(), image.Rect(0, 0, 1, 1), Area)
+	if c := color.GrayModel.Convert(m.At(0, 0)).(color.Gray); c.Y != 0x80 {
+		t.Errorf("expected %#02x, got %#02x", 0x80, c.Y)
+	}
+}
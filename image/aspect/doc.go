@@ -0,0 +1,10 @@
+/*
+Package aspect implements aspect ratio correction for images rendered for
+display devices with non-square pixels.
+
+Text mode screens such as VGA 720x400 and Amiga 640x200 were displayed on 4:3
+monitors, so their pixels were taller than wide. Rendered on a modern display
+with square pixels, the pixels have to be stretched to look like they did on
+the original hardware.
+*/
+package aspect
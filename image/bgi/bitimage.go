@@ -0,0 +1,52 @@
+package bgi
+
+import "image"
+
+// BitImage is a block of pixels, as saved by GetImage.
+type BitImage struct {
+	// Width and Height of the image in pixels.
+	Width, Height int
+
+	// Pix are the color indexes, stored row by row.
+	Pix []uint8
+}
+
+// GetImage saves the pixels in the (inclusive) rectangle (left, top)-(right,
+// bottom). Pixels outside of the canvas are saved as color 0.
+func (canvas *Canvas) GetImage(left, top, right, bottom int) *BitImage {
+	if left > right {
+		left, right = right, left
+	}
+	if top > bottom {
+		top, bottom = bottom, top
+	}
+	im := &BitImage{
+		Width:  right - left + 1,
+		Height: bottom - top + 1,
+	}
+	im.Pix = make([]uint8, im.Width*im.Height)
+	for y := 0; y < im.Height; y++ {
+		for x := 0; x < im.Width; x++ {
+			im.Pix[y*im.Width+x] = canvas.GetPixel(left+x, top+y)
+		}
+	}
+	return im
+}
+
+// PutImage draws a previously saved image with its top left corner at (left,
+// top); the pixels are combined with the canvas using op.
+func (canvas *Canvas) PutImage(left, top int, im *BitImage, op WriteMode) {
+	if im == nil {
+		return
+	}
+	for y := 0; y < im.Height; y++ {
+		for x := 0; x < im.Width; x++ {
+			canvas.setColorIndex(left+x, top+y, im.Pix[y*im.Width+x], op)
+		}
+	}
+}
+
+// Bounds of the image.
+func (im *BitImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, im.Width, im.Height)
+}
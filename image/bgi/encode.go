@@ -0,0 +1,247 @@
+package bgi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Limits of the CHR file format.
+const (
+	fontHeaderSize  = 0x80
+	fontStrokeMin   = -64
+	fontStrokeMax   = 63
+	fontDescription = fontHeaderSize - 8 - 1 - 12
+)
+
+// Stroke is a single pen operation in a glyph. The coordinates are in font
+// units relative to the origin of the glyph, with Y pointing up.
+type Stroke struct {
+	X    int  `json:"x"`
+	Y    int  `json:"y"`
+	Draw bool `json:"draw,omitempty"` // Draw a line from the previous position, else move
+}
+
+// Glyph is a stroked character.
+type Glyph struct {
+	Char    byte     `json:"char"`
+	Width   int      `json:"width"`
+	Strokes []Stroke `json:"strokes"`
+}
+
+// FontMetrics are the vertical distances from the origin of a font.
+type FontMetrics struct {
+	Cap       int `json:"cap"`       // Distance to the top of the capitals
+	Baseline  int `json:"baseline"`  // Distance to the baseline
+	Descender int `json:"descender"` // Distance to the bottom of the descenders
+}
+
+// GlyphSet contains the stroke definitions for a font.
+type GlyphSet struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Metrics     FontMetrics `json:"metrics"`
+	Glyphs      []Glyph     `json:"glyphs"`
+}
+
+// Description of the font, as found in the file header.
+func (f *Font) Description() string {
+	return f.name
+}
+
+// Metrics returns the vertical font metrics.
+func (f *Font) Metrics() FontMetrics {
+	return FontMetrics{
+		Cap:       int(f.header.OrgToCap),
+		Baseline:  int(f.header.OrgToBase),
+		Descender: int(f.header.OrgToDec),
+	}
+}
+
+// Glyphs returns the stroke definitions of all characters in the font.
+func (f *Font) Glyphs() []Glyph {
+	glyphs := make([]Glyph, f.header.Chars)
+	for i := range glyphs {
+		glyphs[i] = Glyph{
+			Char:  f.header.FirstChar + byte(i),
+			Width: int(f.wtable[i]),
+		}
+		if int(f.offsets[i]>>1) >= len(f.vectors) {
+			continue
+		}
+	reading:
+		for _, stroke := range f.vectors[f.offsets[i]>>1:] {
+			switch stroke & strokeOpMask {
+			case strokeOpEnd:
+				break reading
+			case strokeOpMove:
+				glyphs[i].Strokes = append(glyphs[i].Strokes, Stroke{X: stroke.X(), Y: stroke.Y()})
+			case strokeOpDraw:
+				glyphs[i].Strokes = append(glyphs[i].Strokes, Stroke{X: stroke.X(), Y: stroke.Y(), Draw: true})
+			}
+		}
+	}
+	return glyphs
+}
+
+// GlyphSet returns the name, metrics and stroke definitions of the font.
+func (f *Font) GlyphSet() GlyphSet {
+	return GlyphSet{
+		Name:        strings.TrimRight(f.Name(), "\x00 "),
+		Description: f.name,
+		Metrics:     f.Metrics(),
+		Glyphs:      f.Glyphs(),
+	}
+}
+
+// NewFontFromGlyphSet builds a stroked font from glyph definitions. The font
+// covers all characters from the lowest to the highest character in the set,
+// characters without a definition are left empty.
+func NewFontFromGlyphSet(set GlyphSet) (*Font, error) {
+	if len(set.Glyphs) == 0 {
+		return nil, errors.New("bgi: no glyphs")
+	}
+	if len(set.Name) > 4 {
+		return nil, fmt.Errorf("bgi: font name %q is longer than 4 characters", set.Name)
+	}
+	if len(set.Description) > fontDescription {
+		return nil, fmt.Errorf("bgi: font description is longer than %d characters", fontDescription)
+	}
+	if strings.IndexByte(set.Description, SUB) != -1 {
+		return nil, errors.New("bgi: font description contains an end of file marker")
+	}
+	for _, v := range []int{set.Metrics.Cap, set.Metrics.Baseline, set.Metrics.Descender} {
+		if v < -128 || v > 127 {
+			return nil, fmt.Errorf("bgi: font metric %d out of range", v)
+		}
+	}
+
+	first, last := set.Glyphs[0].Char, set.Glyphs[0].Char
+	for _, glyph := range set.Glyphs {
+		if glyph.Char < first {
+			first = glyph.Char
+		}
+		if glyph.Char > last {
+			last = glyph.Char
+		}
+	}
+
+	var (
+		f     = &Font{name: set.Description}
+		chars = int(last-first) + 1
+		seen  = make([]bool, chars)
+	)
+	copy(f.fileHeader.Name[:], set.Name)
+	for i := len(set.Name); i < len(f.fileHeader.Name); i++ {
+		f.fileHeader.Name[i] = ' '
+	}
+	f.fileHeader.HeaderSize = fontHeaderSize
+	f.fileHeader.FontMajor = 1
+	f.fileHeader.BGIMajor = 1
+	f.header = fontHeader{
+		Signature: '+',
+		Chars:     uint16(chars),
+		FirstChar: first,
+		CharDefs:  uint16(16 + 3*chars),
+		OrgToCap:  int8(set.Metrics.Cap),
+		OrgToBase: int8(set.Metrics.Baseline),
+		OrgToDec:  int8(set.Metrics.Descender),
+	}
+	f.offsets = make([]uint16, chars)
+	f.wtable = make([]uint8, chars)
+
+	// Empty characters share a single end of character definition.
+	f.vectors = []stroke{strokeOpEnd}
+	for _, glyph := range set.Glyphs {
+		i := int(glyph.Char - first)
+		if seen[i] {
+			return nil, fmt.Errorf("bgi: duplicate glyph for character %d", glyph.Char)
+		}
+		seen[i] = true
+		if glyph.Width < 0 || glyph.Width > 255 {
+			return nil, fmt.Errorf("bgi: width %d of character %d out of range", glyph.Width, glyph.Char)
+		}
+		f.wtable[i] = uint8(glyph.Width)
+		if len(glyph.Strokes) == 0 {
+			continue
+		}
+		if len(f.vectors)*2 > 0xffff {
+			return nil, errors.New("bgi: too many strokes")
+		}
+		f.offsets[i] = uint16(len(f.vectors) * 2)
+		for _, s := range glyph.Strokes {
+			encoded, err := encodeStroke(s)
+			if err != nil {
+				return nil, fmt.Errorf("bgi: character %d: %v", glyph.Char, err)
+			}
+			f.vectors = append(f.vectors, encoded)
+		}
+		f.vectors = append(f.vectors, strokeOpEnd)
+	}
+	size, err := fontFileSize(chars, len(f.vectors))
+	if err != nil {
+		return nil, err
+	}
+	f.fileHeader.FileSize = size
+
+	f.xoffset = make([]int, chars)
+	f.widths = make([]int, chars)
+	f.parseWidths()
+
+	return f, nil
+}
+
+// fontFileSize returns the size of the font data following the header, for
+// chars characters and vectors strokes.
+func fontFileSize(chars, vectors int) (uint16, error) {
+	size := 16 + 3*chars + 2*vectors
+	if size > 0xffff {
+		return 0, fmt.Errorf("bgi: font size %d exceeds %d bytes", size, 0xffff)
+	}
+	return uint16(size), nil
+}
+
+func encodeStroke(s Stroke) (stroke, error) {
+	if s.X < fontStrokeMin || s.X > fontStrokeMax || s.Y < fontStrokeMin || s.Y > fontStrokeMax {
+		return 0, fmt.Errorf("stroke (%d,%d) out of range", s.X, s.Y)
+	}
+	v := stroke(s.X&0x7f) | stroke(s.Y&0x7f)<<8
+	if s.Draw {
+		return v | strokeOpDraw, nil
+	}
+	return v | strokeOpMove, nil
+}
+
+// WriteTo writes the font in the Borland CHR format.
+func (f *Font) WriteTo(w io.Writer) (int64, error) {
+	var (
+		b          = new(bytes.Buffer)
+		fileHeader = f.fileHeader
+	)
+	if fileHeader.HeaderSize == 0 {
+		fileHeader.HeaderSize = fontHeaderSize
+	}
+	size, err := fontFileSize(len(f.offsets), len(f.vectors))
+	if err != nil {
+		return 0, err
+	}
+	fileHeader.FileSize = size
+
+	b.WriteString("PK\b\bBGI ")
+	b.WriteString(f.name)
+	b.WriteByte(SUB)
+	binary.Write(b, binary.LittleEndian, fileHeader)
+	if b.Len() > int(fileHeader.HeaderSize) {
+		return 0, errors.New("bgi: font description too long")
+	}
+	b.Write(make([]byte, int(fileHeader.HeaderSize)-b.Len()))
+	binary.Write(b, binary.LittleEndian, f.header)
+	binary.Write(b, binary.LittleEndian, f.offsets)
+	binary.Write(b, binary.LittleEndian, f.wtable)
+	binary.Write(b, binary.LittleEndian, f.vectors)
+
+	return b.WriteTo(w)
+}
@@ -0,0 +1,176 @@
+package bgi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testFonts(t *testing.T) map[string][]byte {
+	names, err := filepath.Glob("*.CHR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) == 0 {
+		t.Skip("no CHR fonts found")
+	}
+	fonts := make(map[string][]byte)
+	for _, name := range names {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fonts[name] = b
+	}
+	return fonts
+}
+
+func TestFontWriteTo(t *testing.T) {
+	for name, b := range testFonts(t) {
+		f, err := NewFont(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		o := new(bytes.Buffer)
+		if _, err = f.WriteTo(o); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(o.Bytes(), b) {
+			t.Errorf("%s: encoded font differs from the original", name)
+		}
+	}
+}
+
+func TestFontGlyphSet(t *testing.T) {
+	for name, b := range testFonts(t) {
+		f, err := NewFont(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		o := new(bytes.Buffer)
+		if err = f.WriteJSON(o); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		g, err := ReadJSON(o)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(g.GlyphSet(), f.GlyphSet()) {
+			t.Errorf("%s: glyph set changed after JSON round trip", name)
+		}
+
+		// The rebuilt font must decode to the same glyphs
+		o.Reset()
+		if _, err = g.WriteTo(o); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		h, err := NewFont(bytes.NewReader(o.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(h.Glyphs(), f.Glyphs()) {
+			t.Errorf("%s: glyphs changed after encoding", name)
+		}
+		if h.widths[0] != f.widths[0] || h.height != f.height {
+			t.Errorf("%s: metrics changed after encoding", name)
+		}
+	}
+}
+
+func TestFontSizeLimit(t *testing.T) {
+	set := GlyphSet{
+		Name:   "BIG",
+		Glyphs: []Glyph{{Char: 'A', Width: 8, Strokes: make([]Stroke, 0x8000)}},
+	}
+	if _, err := NewFontFromGlyphSet(set); err == nil {
+		t.Fatal("expected error for font exceeding 64 KiB")
+	}
+
+	f := &Font{offsets: make([]uint16, 1), wtable: make([]uint8, 1), vectors: make([]stroke, 0x8000)}
+	if _, err := f.WriteTo(new(bytes.Buffer)); err == nil {
+		t.Fatal("expected error for font exceeding 64 KiB")
+	}
+}
+
+func TestFontWriteSVG(t *testing.T) {
+	for name, b := range testFonts(t) {
+		f, err := NewFont(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		o := new(bytes.Buffer)
+		if err = f.WriteSVG(o); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !strings.Contains(o.String(), `id="char-41"`) {
+			t.Errorf("%s: expected a path for character A", name)
+		}
+	}
+}
+
+func TestParseGlyphPath(t *testing.T) {
+	var tests = []struct {
+		Path string
+		Want []Stroke
+	}{
+		{"M0 0 L10 -20", []Stroke{{0, 0, false}, {10, 20, true}}},
+		{"M0,0 10,-20 H0 z", []Stroke{{0, 0, false}, {10, 20, true}, {0, 20, true}, {0, 0, true}}},
+		{"m1 -1 l2 0 v-2 m-3 3 h1.6", []Stroke{{1, 1, false}, {3, 1, true}, {3, 3, true}, {0, 0, false}, {2, 0, true}}},
+	}
+	for _, test := range tests {
+		t.Run(test.Path, func(t *testing.T) {
+			got, err := ParseGlyphPath(test.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Fatalf("expected %v, got %v", test.Want, got)
+			}
+		})
+	}
+
+	for _, path := range []string{"L0 0", "M0 0 C1 1 2 2 3 3", "M0 0 L100 0", "M0"} {
+		if _, err := ParseGlyphPath(path); err == nil {
+			t.Errorf("%q: expected error", path)
+		}
+	}
+}
+
+func TestGlyphSVGPath(t *testing.T) {
+	r := testFonts(t)["TRIP.CHR"]
+	if r == nil {
+		t.Skip("TRIP.CHR not found")
+	}
+	f, err := NewFont(bytes.NewReader(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, glyph := range f.Glyphs() {
+		strokes, err := ParseGlyphPath(glyph.SVGPath())
+		if err != nil {
+			t.Fatalf("character %d: %v", glyph.Char, err)
+		}
+		if !reflect.DeepEqual(drawnLines(strokes), drawnLines(glyph.Strokes)) {
+			t.Fatalf("character %d: lines changed after SVG round trip", glyph.Char)
+		}
+	}
+}
+
+// drawnLines returns the line segments drawn by the strokes.
+func drawnLines(strokes []Stroke) [][2]Stroke {
+	var (
+		lines [][2]Stroke
+		pen   Stroke
+	)
+	for _, s := range strokes {
+		if s.Draw {
+			lines = append(lines, [2]Stroke{pen, {X: s.X, Y: s.Y}})
+		}
+		pen = Stroke{X: s.X, Y: s.Y}
+	}
+	return lines
+}
@@ -0,0 +1,253 @@
+package bgi
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SVGPath returns the strokes of the glyph as SVG path data. The origin of the
+// glyph is at (0, 0) and the Y axis points down, as is common in SVG.
+func (g Glyph) SVGPath() string {
+	var (
+		parts []string
+		pen   = Stroke{}
+		moved bool
+	)
+	for _, s := range g.Strokes {
+		if !s.Draw {
+			pen, moved = s, true
+			continue
+		}
+		if moved || len(parts) == 0 {
+			parts = append(parts, fmt.Sprintf("M%d %d", pen.X, -pen.Y))
+			moved = false
+		}
+		parts = append(parts, fmt.Sprintf("L%d %d", s.X, -s.Y))
+		pen = s
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseGlyphPath parses single line SVG path data in to strokes. Only the
+// line commands M, L, H, V and Z are supported (absolute and relative), curves
+// can not be represented by stroked fonts. The Y axis points down, coordinates
+// are rounded to font units.
+func ParseGlyphPath(d string) ([]Stroke, error) {
+	var (
+		strokes     []Stroke
+		x, y        float64
+		startX      float64
+		startY      float64
+		command     byte
+		tokens, err = tokenizePath(d)
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	number := func() (float64, error) {
+		if len(tokens) == 0 {
+			return 0, fmt.Errorf("bgi: path command %c expects more numbers", command)
+		}
+		v, err := strconv.ParseFloat(tokens[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("bgi: path command %c: %v", command, err)
+		}
+		tokens = tokens[1:]
+		return v, nil
+	}
+	add := func(draw bool) {
+		strokes = append(strokes, Stroke{
+			X:    int(roundHalfUp(x)),
+			Y:    -int(roundHalfUp(y)),
+			Draw: draw,
+		})
+	}
+
+	for len(tokens) > 0 {
+		if t := tokens[0]; len(t) == 1 && unicode.IsLetter(rune(t[0])) {
+			command = t[0]
+			tokens = tokens[1:]
+		} else if command == 0 {
+			return nil, fmt.Errorf("bgi: expected path command, got %q", t)
+		}
+		if len(strokes) == 0 && command != 'M' && command != 'm' {
+			return nil, errors.New("bgi: path data must start with a move command")
+		}
+
+		relative := command >= 'a' && command <= 'z'
+		switch command {
+		case 'M', 'm', 'L', 'l':
+			dx, err := number()
+			if err != nil {
+				return nil, err
+			}
+			dy, err := number()
+			if err != nil {
+				return nil, err
+			}
+			if relative {
+				x, y = x+dx, y+dy
+			} else {
+				x, y = dx, dy
+			}
+			if command == 'M' || command == 'm' {
+				startX, startY = x, y
+				add(false)
+				// Subsequent coordinate pairs are implicit line commands
+				command--
+			} else {
+				add(true)
+			}
+		case 'H', 'h':
+			v, err := number()
+			if err != nil {
+				return nil, err
+			}
+			if relative {
+				x += v
+			} else {
+				x = v
+			}
+			add(true)
+		case 'V', 'v':
+			v, err := number()
+			if err != nil {
+				return nil, err
+			}
+			if relative {
+				y += v
+			} else {
+				y = v
+			}
+			add(true)
+		case 'Z', 'z':
+			x, y = startX, startY
+			add(true)
+			command = 0
+		default:
+			return nil, fmt.Errorf("bgi: unsupported path command %c", command)
+		}
+	}
+
+	for _, s := range strokes {
+		if s.X < fontStrokeMin || s.X > fontStrokeMax || s.Y < fontStrokeMin || s.Y > fontStrokeMax {
+			return nil, fmt.Errorf("bgi: path point (%d,%d) out of range", s.X, -s.Y)
+		}
+	}
+	return strokes, nil
+}
+
+// tokenizePath splits SVG path data in commands and numbers.
+func tokenizePath(d string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(d); {
+		c := d[i]
+		switch {
+		case c == ',' || c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z'):
+			tokens = append(tokens, d[i:i+1])
+			i++
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			j, dot := i+1, c == '.'
+			for ; j < len(d); j++ {
+				if d[j] == '.' && !dot {
+					dot = true
+				} else if d[j] < '0' || d[j] > '9' {
+					break
+				}
+			}
+			tokens = append(tokens, d[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("bgi: unexpected %q in path data", c)
+		}
+	}
+	return tokens, nil
+}
+
+func roundHalfUp(v float64) float64 {
+	if v < 0 {
+		return -roundHalfUp(-v)
+	}
+	return float64(int(v + .5))
+}
+
+// WriteJSON writes the glyph set of the font as JSON.
+func (f *Font) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(f.GlyphSet())
+}
+
+// ReadJSON reads a glyph set in JSON format, as written by WriteJSON, and
+// builds a stroked font from it.
+func ReadJSON(r io.Reader) (*Font, error) {
+	var set GlyphSet
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return nil, err
+	}
+	return NewFontFromGlyphSet(set)
+}
+
+// WriteSVG writes a specimen of all glyphs in the font as an SVG document. Each
+// glyph is a separate path with the id "char-XX", where XX is the hexadecimal
+// character code, laid out in rows of 16 glyphs.
+func (f *Font) WriteSVG(w io.Writer) error {
+	var (
+		glyphs  = f.Glyphs()
+		metrics = f.Metrics()
+		cellW   = 1
+		cellH   = max(1, metrics.Cap-metrics.Descender)
+		rows    = (len(glyphs) + 15) / 16
+	)
+	for _, glyph := range glyphs {
+		cellW = max(cellW, glyph.Width)
+	}
+	for _, s := range glyphs {
+		for _, p := range s.Strokes {
+			cellW = max(cellW, p.X+1)
+		}
+	}
+	cellW += 4
+	cellH += 4
+
+	var err error
+	printf := func(format string, v ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, v...)
+		}
+	}
+
+	printf(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	printf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d">`+"\n", cellW*16, cellH*rows)
+	printf("<title>%s</title>\n", svgEscape(strings.TrimRight(f.Name(), "\x00 ")))
+	printf("<desc>%s</desc>\n", svgEscape(f.name))
+	printf(`<g fill="none" stroke="black" stroke-linecap="round" stroke-linejoin="round">` + "\n")
+	for i, glyph := range glyphs {
+		if len(glyph.Strokes) == 0 {
+			continue
+		}
+		var (
+			x = (i%16)*cellW + 2
+			y = (i/16)*cellH + 2 + metrics.Cap
+		)
+		printf(`<path id="char-%02X" data-width="%d" transform="translate(%d %d)" d="%s"/>`+"\n",
+			glyph.Char, glyph.Width, x, y, glyph.SVGPath())
+	}
+	printf("</g>\n</svg>\n")
+	return err
+}
+
+func svgEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
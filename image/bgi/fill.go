@@ -0,0 +1,65 @@
+package bgi
+
+import "image"
+
+// FloodFill fills the area around (x, y) bounded by the border color with the
+// current fill style. If (x, y) is on the border, nothing is filled.
+func (canvas *Canvas) FloodFill(x, y int, border uint8) {
+	border &= 0x0f
+	if _, ok := canvas.visible(x, y); !ok || canvas.GetPixel(x, y) == border {
+		return
+	}
+
+	// The area is determined on a copy of the original pixels, because the
+	// fill pattern may paint pixels in the border color.
+	var (
+		bounds = canvas.Rect
+		pixels = make([]uint8, len(canvas.Pix))
+	)
+	if canvas.clip {
+		bounds = canvas.viewport
+	}
+	copy(pixels, canvas.Pix)
+	bounds = bounds.Sub(canvas.viewport.Min)
+
+	var (
+		size    = bounds.Size()
+		visited = make([]bool, size.X*size.Y)
+		queue   = []image.Point{image.Pt(x, y)}
+	)
+	fillable := func(x, y int) bool {
+		p := image.Pt(x, y)
+		if !p.In(bounds) {
+			return false
+		}
+		if visited[(p.Y-bounds.Min.Y)*size.X+(p.X-bounds.Min.X)] {
+			return false
+		}
+		p = p.Add(canvas.viewport.Min)
+		return pixels[canvas.PixOffset(p.X, p.Y)] != border
+	}
+
+	// Scan line flood fill.
+	for len(queue) > 0 {
+		p := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if !fillable(p.X, p.Y) {
+			continue
+		}
+
+		// Find the span containing p
+		left, right := p.X, p.X
+		for fillable(left-1, p.Y) {
+			left--
+		}
+		for fillable(right+1, p.Y) {
+			right++
+		}
+
+		for fx := left; fx <= right; fx++ {
+			visited[(p.Y-bounds.Min.Y)*size.X+(fx-bounds.Min.X)] = true
+			canvas.setColorFill(fx, p.Y)
+			queue = append(queue, image.Pt(fx, p.Y-1), image.Pt(fx, p.Y+1))
+		}
+	}
+}
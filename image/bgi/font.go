@@ -260,6 +260,15 @@ func (f *Font) parseWidths() {
 	f.height = max(ymax+1, int(origHeight)) + f.yoffset
 }
 
+// charIndex returns the index of char in the font.
+func (f *Font) charIndex(char byte) (int, bool) {
+	index := int(char) - int(f.header.FirstChar)
+	if index < 0 || index >= int(f.header.Chars) {
+		return 0, false
+	}
+	return index, true
+}
+
 func (f *Font) charWidth(char byte) int {
 	char -= f.header.FirstChar
 	if uint16(char) >= f.header.Chars {
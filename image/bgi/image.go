@@ -5,9 +5,10 @@ import (
 	"image/color"
 )
 
+// WriteMode determines how pixels are combined with the canvas.
 type WriteMode byte
 
-// Write modes
+// Write modes, these also act as the put operations for PutImage.
 const (
 	WriteModeSet WriteMode = iota
 	WriteModeXOR
@@ -16,6 +17,60 @@ const (
 	WriteModeNOT
 )
 
+// apply combines the destination pixel with source pixel c.
+func (mode WriteMode) apply(dst, c uint8) uint8 {
+	switch mode {
+	case WriteModeXOR:
+		return (dst ^ c) & 0x0f
+	case WriteModeOR:
+		return (dst | c) & 0x0f
+	case WriteModeAND:
+		return (dst & c) & 0x0f
+	case WriteModeNOT:
+		return ^c & 0x0f
+	default:
+		return c & 0x0f
+	}
+}
+
+// LineStyle selects one of the line patterns.
+type LineStyle uint8
+
+// Line styles.
+const (
+	SolidLine LineStyle = iota
+	DottedLine
+	CenterLine
+	DashedLine
+	UserBitLine
+)
+
+// Line thickness.
+const (
+	NormalWidth = 1
+	ThickWidth  = 3
+)
+
+// FillStyle selects one of the fill patterns.
+type FillStyle uint8
+
+// Fill styles.
+const (
+	EmptyFill FillStyle = iota
+	SolidFill
+	LineFill
+	LightSlashFill
+	SlashFill
+	BackSlashFill
+	LightBackSlashFill
+	HatchFill
+	CrossHatchFill
+	InterleaveFill
+	WideDotFill
+	CloseDotFill
+	UserFill
+)
+
 // Canvas is a Borland Graphics Interface image canvas.
 type Canvas struct {
 	image.Paletted
@@ -23,37 +78,49 @@ type Canvas struct {
 	position  image.Point
 	writeMode WriteMode
 	viewport  image.Rectangle
+	clip      bool
+	aspect    image.Point
+	arc       ArcCoords
 
 	line struct {
 		path         Path
-		patternIndex uint8
+		patternIndex LineStyle
 		patterns     [5]uint16
+		thickness    int
 	}
 	fill struct {
 		path         Path
 		color        uint8
-		patternIndex uint8
+		patternIndex FillStyle
 		patterns     [13][]byte
 	}
+	text textSettings
 
 	fg, bg  uint8
 	hasPath bool
 }
 
+// NewCanvas returns a 640x350 EGA canvas with the default BGI settings.
 func NewCanvas() *Canvas {
 	canvas := &Canvas{
 		Paletted: image.Paletted{
 			Pix:     make([]uint8, 640*350),
-			Stride:  1,
+			Stride:  640,
 			Rect:    image.Rect(0, 0, 640, 350),
 			Palette: copyPalette(Palette),
 		},
 		viewport: image.Rect(0, 0, 640, 350),
+		clip:     true,
+		aspect:   image.Pt(7750, 10000),
 		fg:       7,
 		bg:       0,
 	}
 	copy(canvas.line.patterns[:], LinePatterns[:])
 	copy(canvas.fill.patterns[:], FillPatterns[:])
+	canvas.line.thickness = NormalWidth
+	canvas.fill.patternIndex = SolidFill
+	canvas.fill.color = 15
+	canvas.text = defaultTextSettings
 	canvas.Clear()
 	return canvas
 }
@@ -73,53 +140,154 @@ func (canvas *Canvas) Clear() {
 	for i := range canvas.Pix {
 		canvas.Pix[i] = canvas.bg
 	}
+	canvas.position = image.ZP
+}
+
+// SetWriteMode sets the mode used for drawing lines.
+func (canvas *Canvas) SetWriteMode(mode WriteMode) {
+	canvas.writeMode = mode
 }
 
-// set a pixel color using the current write mode
-func (canvas *Canvas) setColorIndex(x, y int, c uint8) {
-	if !image.Pt(x, y).In(canvas.viewport) {
+// SetLineStyle sets the line style, pattern (only used for UserBitLine) and
+// thickness (NormalWidth or ThickWidth).
+func (canvas *Canvas) SetLineStyle(style LineStyle, pattern uint16, thickness int) {
+	if style > UserBitLine {
 		return
 	}
+	canvas.line.patternIndex = style
+	if style == UserBitLine {
+		canvas.line.patterns[UserBitLine] = pattern
+	}
+	if thickness == ThickWidth {
+		canvas.line.thickness = ThickWidth
+	} else {
+		canvas.line.thickness = NormalWidth
+	}
+}
 
-	c &= 0x0f
-	offset := canvas.PixOffset(x, y)
-	switch canvas.writeMode {
-	case WriteModeSet:
-		canvas.Pix[offset] = c
-	case WriteModeXOR:
-		canvas.Pix[offset] ^= c
-	case WriteModeOR:
-		canvas.Pix[offset] |= c
-	case WriteModeAND:
-		canvas.Pix[offset] &= c
-	case WriteModeNOT:
-		canvas.Pix[offset] &= (^c & 0x0f)
+// SetFillStyle sets the fill pattern and fill color.
+func (canvas *Canvas) SetFillStyle(style FillStyle, index uint8) {
+	if style > UserFill {
+		return
 	}
+	canvas.fill.patternIndex = style
+	canvas.fill.color = index & 0x0f
+}
+
+// SetFillPattern sets a user defined fill pattern and fill color.
+func (canvas *Canvas) SetFillPattern(pattern [8]byte, index uint8) {
+	canvas.fill.patterns[UserFill] = append([]byte(nil), pattern[:]...)
+	canvas.fill.patternIndex = UserFill
+	canvas.fill.color = index & 0x0f
 }
 
-// set a pixel color respecting the current line pattern
-func (canvas *Canvas) setColorLine(x, y int) {
-	if !image.Pt(x, y).In(canvas.viewport) {
+// SetAspectRatio sets the aspect ratio used by Circle, Arc and PieSlice. The
+// default aspect ratio for the 640x350 EGA canvas is 7750:10000.
+func (canvas *Canvas) SetAspectRatio(x, y int) {
+	if x < 1 || y < 1 {
 		return
 	}
+	canvas.aspect = image.Pt(x, y)
+}
 
-	if canvas.line.patterns[canvas.line.patternIndex]>>uint(x%8)&1 == 1 {
-		canvas.setColorIndex(x, y, canvas.fg)
-	} else {
-		canvas.setColorIndex(x, y, canvas.bg)
+// AspectRatio returns the aspect ratio.
+func (canvas *Canvas) AspectRatio() (x, y int) {
+	return canvas.aspect.X, canvas.aspect.Y
+}
+
+// SetViewPort sets the viewport to the (inclusive) rectangle (x1, y1)-(x2, y2).
+// All drawing coordinates are relative to the top left corner of the viewport;
+// if clip is set, drawing outside of the viewport is suppressed.
+func (canvas *Canvas) SetViewPort(x1, y1, x2, y2 int, clip bool) {
+	r := image.Rect(x1, y1, x2+1, y2+1).Intersect(canvas.Rect)
+	if r.Empty() {
+		return
+	}
+	canvas.viewport = r
+	canvas.clip = clip
+	canvas.position = image.ZP
+}
+
+// ViewPort returns the current viewport and clipping flag.
+func (canvas *Canvas) ViewPort() (image.Rectangle, bool) {
+	return canvas.viewport, canvas.clip
+}
+
+// ClearViewPort clears the viewport with the current background color.
+func (canvas *Canvas) ClearViewPort() {
+	for y := canvas.viewport.Min.Y; y < canvas.viewport.Max.Y; y++ {
+		for x := canvas.viewport.Min.X; x < canvas.viewport.Max.X; x++ {
+			canvas.Pix[canvas.PixOffset(x, y)] = canvas.bg
+		}
+	}
+	canvas.position = image.ZP
+}
+
+// Position returns the current position, relative to the viewport.
+func (canvas *Canvas) Position() image.Point {
+	return canvas.position
+}
+
+// PutPixel sets the pixel at (x, y) to color index c.
+func (canvas *Canvas) PutPixel(x, y int, c uint8) {
+	canvas.setColorIndex(x, y, c, WriteModeSet)
+}
+
+// GetPixel returns the color index of the pixel at (x, y).
+func (canvas *Canvas) GetPixel(x, y int) uint8 {
+	pt := image.Pt(x, y).Add(canvas.viewport.Min)
+	if !pt.In(canvas.Rect) {
+		return 0
+	}
+	return canvas.Pix[canvas.PixOffset(pt.X, pt.Y)]
+}
+
+// visible checks if the viewport relative point can be drawn to and returns
+// the absolute point.
+func (canvas *Canvas) visible(x, y int) (image.Point, bool) {
+	pt := image.Pt(x, y).Add(canvas.viewport.Min)
+	if !pt.In(canvas.Rect) || (canvas.clip && !pt.In(canvas.viewport)) {
+		return pt, false
+	}
+	return pt, true
+}
+
+// set a pixel color using write mode
+func (canvas *Canvas) setColorIndex(x, y int, c uint8, mode WriteMode) {
+	pt, ok := canvas.visible(x, y)
+	if !ok {
+		return
+	}
+	offset := canvas.PixOffset(pt.X, pt.Y)
+	canvas.Pix[offset] = mode.apply(canvas.Pix[offset], c)
+}
+
+// linePlotter returns a plot function that respects the current line pattern,
+// the pattern is advanced for each plotted pixel.
+func (canvas *Canvas) linePlotter() func(x, y int) {
+	var (
+		pattern = canvas.line.patterns[canvas.line.patternIndex]
+		bit     uint
+	)
+	return func(x, y int) {
+		if pattern>>(15-bit%16)&1 == 1 {
+			canvas.setColorIndex(x, y, canvas.fg, canvas.writeMode)
+		}
+		bit++
 	}
 }
 
 // set a pixel color respecting the current fill pattern
 func (canvas *Canvas) setColorFill(x, y int) {
-	if !image.Pt(x, y).In(canvas.viewport) {
+	pt, ok := canvas.visible(x, y)
+	if !ok {
 		return
 	}
 
-	if canvas.fill.patterns[canvas.fill.patternIndex][y%8]>>uint(x%8)&1 == 1 {
-		canvas.setColorIndex(x, y, canvas.fill.color)
+	if canvas.fill.patterns[canvas.fill.patternIndex][pt.Y%8]>>uint(7-pt.X%8)&1 == 1 {
+		canvas.setColorIndex(x, y, canvas.fill.color, WriteModeSet)
 	} else {
-		canvas.setColorIndex(x, y, canvas.bg)
+		canvas.setColorIndex(x, y, canvas.bg, WriteModeSet)
 	}
 }
 
@@ -136,6 +304,7 @@ func (canvas *Canvas) MoveTo(x, y int) {
 	canvas.line.path = NewPath(pt)
 	canvas.fill.path = NewPath(pt)
 	canvas.hasPath = true
+	canvas.position = pt
 }
 
 func (canvas *Canvas) LineTo(x, y int) {
@@ -146,9 +315,37 @@ func (canvas *Canvas) LineTo(x, y int) {
 	pt := image.Pt(x, y)
 	canvas.line.path.Add(pt)
 	canvas.fill.path.Add(pt)
+	canvas.position = pt
 }
 
+// Line draws a line from (x1, y1) to (x2, y2) using the current line style,
+// thickness and write mode. The current position is not updated.
+func (canvas *Canvas) Line(x1, y1, x2, y2 int) {
+	canvas.stroke(image.Pt(x1, y1), image.Pt(x2, y2))
+}
+
+// stroke a line with the current line style and thickness
 func (canvas *Canvas) stroke(a, b image.Point) {
+	if canvas.line.thickness != ThickWidth {
+		bresenham(a, b, canvas.linePlotter())
+		return
+	}
+
+	// Thick lines are drawn as three parallel lines, offset along the minor
+	// axis.
+	var offset image.Point
+	if abs(b.X-a.X) > abs(b.Y-a.Y) {
+		offset.Y = 1
+	} else {
+		offset.X = 1
+	}
+	bresenham(a.Sub(offset), b.Sub(offset), canvas.linePlotter())
+	bresenham(a, b, canvas.linePlotter())
+	bresenham(a.Add(offset), b.Add(offset), canvas.linePlotter())
+}
+
+// bresenham calls plot for each pixel on the line from a to b.
+func bresenham(a, b image.Point, plot func(x, y int)) {
 	var (
 		dx, dy, e, slope int
 		x1               = a.X
@@ -171,15 +368,15 @@ func (canvas *Canvas) stroke(a, b image.Point) {
 	switch {
 	case x1 == x2 && y1 == y2:
 		// just one pixel
-		canvas.setColorLine(x1, y1)
+		plot(x1, y1)
 
 	case y1 == y2:
 		// horizontal line
 		for ; dx != 0; dx-- {
-			canvas.setColorLine(x1, y1)
+			plot(x1, y1)
 			x1++
 		}
-		canvas.setColorLine(x1, y1)
+		plot(x1, y1)
 
 	case x1 == x2:
 		// vertical line
@@ -187,27 +384,27 @@ func (canvas *Canvas) stroke(a, b image.Point) {
 			y1, y2 = y2, y1
 		}
 		for ; dy != 0; dy-- {
-			canvas.setColorLine(x1, y1)
+			plot(x1, y1)
 			y1++
 		}
-		canvas.setColorLine(x1, y1)
+		plot(x1, y1)
 
 	case dx == dy:
 		// diagonal line
 		if y1 < y2 {
 			for ; dx != 0; dx-- {
-				canvas.setColorLine(x1, y1)
+				plot(x1, y1)
 				x1++
 				y1++
 			}
 		} else {
 			for ; dx != 0; dx-- {
-				canvas.setColorLine(x1, y1)
+				plot(x1, y1)
 				x1++
 				y1--
 			}
 		}
-		canvas.setColorLine(x1, y1)
+		plot(x1, y1)
 
 	case dx > dy:
 		// wide line
@@ -215,7 +412,7 @@ func (canvas *Canvas) stroke(a, b image.Point) {
 			// BresenhamDxXRYD(img, x1, y1, x2, y2, col)
 			dy, e, slope = 2*dy, dx, 2*dx
 			for ; dx != 0; dx-- {
-				canvas.setColorLine(x1, y1)
+				plot(x1, y1)
 				x1++
 				e -= dy
 				if e < 0 {
@@ -227,7 +424,7 @@ func (canvas *Canvas) stroke(a, b image.Point) {
 			// BresenhamDxXRYU(img, x1, y1, x2, y2, col)
 			dy, e, slope = 2*dy, dx, 2*dx
 			for ; dx != 0; dx-- {
-				canvas.setColorLine(x1, y1)
+				plot(x1, y1)
 				x1++
 				e -= dy
 				if e < 0 {
@@ -236,7 +433,7 @@ func (canvas *Canvas) stroke(a, b image.Point) {
 				}
 			}
 		}
-		canvas.setColorLine(x1, y1)
+		plot(x1, y1)
 
 	default:
 		// tall line
@@ -244,7 +441,7 @@ func (canvas *Canvas) stroke(a, b image.Point) {
 			// BresenhamDyXRYD(img, x1, y1, x2, y2, col)
 			dx, e, slope = 2*dx, dy, 2*dy
 			for ; dy != 0; dy-- {
-				canvas.setColorLine(x1, y1)
+				plot(x1, y1)
 				y1++
 				e -= dx
 				if e < 0 {
@@ -256,7 +453,7 @@ func (canvas *Canvas) stroke(a, b image.Point) {
 			// BresenhamDyXRYU(img, x1, y1, x2, y2, col)
 			dx, e, slope = 2*dx, dy, 2*dy
 			for ; dy != 0; dy-- {
-				canvas.setColorLine(x1, y1)
+				plot(x1, y1)
 				y1--
 				e -= dx
 				if e < 0 {
@@ -265,12 +462,11 @@ func (canvas *Canvas) stroke(a, b image.Point) {
 				}
 			}
 		}
-		canvas.setColorLine(x1, y1)
+		plot(x1, y1)
 	}
 }
 
 func (canvas *Canvas) strokeRaster() {
-	// TODO: line thickness is not implemented
 	for i, b := range canvas.line.path[1:] {
 		canvas.stroke(canvas.line.path[i], b)
 	}
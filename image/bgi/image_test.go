@@ -0,0 +1,181 @@
+package bgi
+
+import (
+	"image"
+	"os"
+	"testing"
+)
+
+func countColor(canvas *Canvas, r image.Rectangle, c uint8) (n int) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if canvas.GetPixel(x, y) == c {
+				n++
+			}
+		}
+	}
+	return
+}
+
+func TestCanvasPixel(t *testing.T) {
+	canvas := NewCanvas()
+	canvas.PutPixel(10, 20, 12)
+	if c := canvas.GetPixel(10, 20); c != 12 {
+		t.Fatalf("expected color 12, got %d", c)
+	}
+
+	canvas.SetViewPort(100, 100, 199, 199, true)
+	canvas.PutPixel(5, 5, 14)
+	if c := canvas.ColorIndexAt(105, 105); c != 14 {
+		t.Fatalf("expected viewport relative pixel, got %d", c)
+	}
+	canvas.PutPixel(150, 5, 14)
+	if c := canvas.ColorIndexAt(250, 105); c != 0 {
+		t.Fatalf("expected pixel outside viewport to be clipped, got %d", c)
+	}
+}
+
+func TestCanvasCircle(t *testing.T) {
+	canvas := NewCanvas()
+	canvas.SetAspectRatio(1, 1)
+	canvas.Color(15)
+	canvas.Circle(100, 100, 40)
+	for _, p := range []image.Point{{140, 100}, {60, 100}, {100, 60}, {100, 140}} {
+		if c := canvas.GetPixel(p.X, p.Y); c != 15 {
+			t.Errorf("expected pixel at %s, got %d", p, c)
+		}
+	}
+	if c := canvas.GetPixel(100, 100); c != 0 {
+		t.Errorf("expected center to be empty, got %d", c)
+	}
+}
+
+func TestCanvasCirclePixels(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Thickness int
+		Want      []string
+	}{
+		{"normal", NormalWidth, []string{
+			"...........",
+			"...........",
+			"....###....",
+			"...#...#...",
+			"..#.....#..",
+			"..#.....#..",
+			"..#.....#..",
+			"...#...#...",
+			"....###....",
+			"...........",
+			"...........",
+		}},
+		{"thick", ThickWidth, []string{
+			"...........",
+			"....###....",
+			"...#####...",
+			"...#####...",
+			".###...###.",
+			".###...###.",
+			".###...###.",
+			"...#####...",
+			"...#####...",
+			"....###....",
+			"...........",
+		}},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			canvas := NewCanvas()
+			canvas.SetAspectRatio(1, 1)
+			canvas.SetLineStyle(SolidLine, 0, test.Thickness)
+			canvas.Color(15)
+			canvas.Circle(5, 5, 3)
+			for y, row := range test.Want {
+				for x, c := range row {
+					if got := canvas.GetPixel(x, y) == 15; got != (c == '#') {
+						t.Fatalf("pixel (%d, %d): expected %q in row %q", x, y, c, row)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCanvasBar(t *testing.T) {
+	canvas := NewCanvas()
+	canvas.SetFillStyle(SolidFill, 4)
+	canvas.Bar(10, 10, 19, 14)
+	if n := countColor(canvas, image.Rect(0, 0, 30, 30), 4); n != 50 {
+		t.Fatalf("expected 50 pixels, got %d", n)
+	}
+}
+
+func TestCanvasFloodFill(t *testing.T) {
+	canvas := NewCanvas()
+	canvas.Color(15)
+	canvas.Rectangle(10, 10, 29, 29)
+	canvas.SetFillStyle(SolidFill, 2)
+	canvas.FloodFill(20, 20, 15)
+	if n := countColor(canvas, image.Rect(0, 0, 40, 40), 2); n != 18*18 {
+		t.Fatalf("expected %d pixels, got %d", 18*18, n)
+	}
+	if c := canvas.GetPixel(5, 5); c != 0 {
+		t.Fatalf("expected fill to stay inside the border, got %d", c)
+	}
+}
+
+func TestCanvasPutImage(t *testing.T) {
+	canvas := NewCanvas()
+	canvas.SetFillStyle(SolidFill, 9)
+	canvas.Bar(0, 0, 7, 7)
+	im := canvas.GetImage(0, 0, 7, 7)
+	if im.Width != 8 || im.Height != 8 {
+		t.Fatalf("expected 8x8 image, got %dx%d", im.Width, im.Height)
+	}
+
+	canvas.PutImage(100, 100, im, WriteModeXOR)
+	if n := countColor(canvas, image.Rect(100, 100, 108, 108), 9); n != 64 {
+		t.Fatalf("expected 64 pixels, got %d", n)
+	}
+	canvas.PutImage(100, 100, im, WriteModeXOR)
+	if n := countColor(canvas, image.Rect(100, 100, 108, 108), 0); n != 64 {
+		t.Fatalf("expected XOR to restore the background, got %d pixels", 64-n)
+	}
+}
+
+func TestCanvasOutText(t *testing.T) {
+	canvas := NewCanvas()
+	canvas.Color(15)
+	if err := canvas.OutTextXY(0, 0, "BGI"); err != nil {
+		t.Fatal(err)
+	}
+	if w := canvas.TextWidth("BGI"); w != 24 {
+		t.Fatalf("expected width 24, got %d", w)
+	}
+	if n := countColor(canvas, image.Rect(0, 0, 24, 8), 15); n == 0 {
+		t.Fatal("expected bitmap text to be drawn")
+	}
+
+	r, err := os.Open("SANS.CHR")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer r.Close()
+	sans, err := NewFont(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canvas.Clear()
+	canvas.SetTextStyle(sans, HorizontalDirection, 4)
+	canvas.MoveTo(10, 10)
+	if err = canvas.OutText("BGI"); err != nil {
+		t.Fatal(err)
+	}
+	w, h := canvas.TextWidth("BGI"), canvas.TextHeight("BGI")
+	if p := canvas.Position(); p.X != 10+w {
+		t.Fatalf("expected position to advance to %d, got %d", 10+w, p.X)
+	}
+	if n := countColor(canvas, image.Rect(10, 10, 10+w, 10+h+1), 15); n == 0 {
+		t.Fatal("expected stroked text to be drawn")
+	}
+}
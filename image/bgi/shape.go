@@ -0,0 +1,356 @@
+package bgi
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// ArcCoords are the coordinates of the last drawn arc.
+type ArcCoords struct {
+	// Center of the arc.
+	Center image.Point
+
+	// Start and End points of the arc.
+	Start, End image.Point
+}
+
+// ArcCoords returns the coordinates of the last call to Arc, Ellipse,
+// PieSlice or Sector.
+func (canvas *Canvas) ArcCoords() ArcCoords {
+	return canvas.arc
+}
+
+// aspectRadius returns the vertical radius for a circle with radius r.
+func (canvas *Canvas) aspectRadius(r int) int {
+	return (r*canvas.aspect.X + canvas.aspect.Y/2) / canvas.aspect.Y
+}
+
+// Circle draws a circle with radius r, corrected for the aspect ratio.
+func (canvas *Canvas) Circle(x, y, r int) {
+	canvas.Ellipse(x, y, 0, 360, r, canvas.aspectRadius(r))
+}
+
+// Arc draws a circular arc from angle start to end (in degrees, counter
+// clockwise where 0 is at 3 o'clock).
+func (canvas *Canvas) Arc(x, y, start, end, r int) {
+	canvas.Ellipse(x, y, start, end, r, canvas.aspectRadius(r))
+}
+
+// Ellipse draws an elliptical arc from angle start to end with horizontal
+// radius rx and vertical radius ry, using the current line thickness.
+func (canvas *Canvas) Ellipse(x, y, start, end, rx, ry int) {
+	var (
+		center = image.Pt(x, y)
+		span   = newAngleSpan(start, end)
+		thick  = canvas.line.thickness == ThickWidth
+	)
+	canvas.arc = arcCoords(center, start, end, rx, ry)
+	for _, p := range ellipsePoints(rx, ry) {
+		if !span.contains(p, rx, ry) {
+			continue
+		}
+		canvas.setColorIndex(x+p.X, y+p.Y, canvas.fg, WriteModeSet)
+		if thick {
+			// Like thick lines, thick arcs are three pixels wide along the
+			// minor axis of the tangent.
+			offset := image.Pt(0, 1)
+			if abs(p.X*ry*ry) > abs(p.Y*rx*rx) {
+				offset = image.Pt(1, 0)
+			}
+			canvas.setColorIndex(x+p.X-offset.X, y+p.Y-offset.Y, canvas.fg, WriteModeSet)
+			canvas.setColorIndex(x+p.X+offset.X, y+p.Y+offset.Y, canvas.fg, WriteModeSet)
+		}
+	}
+}
+
+// FillEllipse draws a filled ellipse with the current fill style, the outline
+// is drawn in the current color.
+func (canvas *Canvas) FillEllipse(x, y, rx, ry int) {
+	canvas.Sector(x, y, 0, 360, rx, ry)
+}
+
+// PieSlice draws a filled circular pie slice, corrected for the aspect ratio.
+func (canvas *Canvas) PieSlice(x, y, start, end, r int) {
+	canvas.Sector(x, y, start, end, r, canvas.aspectRadius(r))
+}
+
+// Sector draws a filled elliptical pie slice, the slice is filled using the
+// current fill style and outlined with the current color and line style.
+func (canvas *Canvas) Sector(x, y, start, end, rx, ry int) {
+	var (
+		span = newAngleSpan(start, end)
+		rows = ellipseSpans(rx, ry)
+	)
+	for dy, dx := range rows {
+		for px := -dx; px <= dx; px++ {
+			p := image.Pt(px, dy-ry)
+			if p == image.ZP || span.contains(p, rx, ry) {
+				canvas.setColorFill(x+p.X, y+p.Y)
+			}
+		}
+	}
+
+	canvas.Ellipse(x, y, start, end, rx, ry)
+	if !span.full {
+		arc := canvas.arc
+		canvas.stroke(arc.Center, arc.Start)
+		canvas.stroke(arc.Center, arc.End)
+	}
+}
+
+// Rectangle draws the outline of a rectangle using the current line style,
+// thickness and write mode.
+func (canvas *Canvas) Rectangle(left, top, right, bottom int) {
+	canvas.DrawPoly(
+		image.Pt(left, top),
+		image.Pt(right, top),
+		image.Pt(right, bottom),
+		image.Pt(left, bottom),
+		image.Pt(left, top),
+	)
+}
+
+// Bar draws a filled rectangle using the current fill style, without outline.
+func (canvas *Canvas) Bar(left, top, right, bottom int) {
+	if left > right {
+		left, right = right, left
+	}
+	if top > bottom {
+		top, bottom = bottom, top
+	}
+	for y := top; y <= bottom; y++ {
+		for x := left; x <= right; x++ {
+			canvas.setColorFill(x, y)
+		}
+	}
+}
+
+// Bar3D draws a filled three-dimensional bar, the front face is filled with
+// the current fill style and all edges are drawn with the current line
+// style. The depth of the bar is drawn at a 3:4 ratio, if top is false the top
+// face is omitted so bars can be stacked.
+func (canvas *Canvas) Bar3D(left, top, right, bottom, depth int, topFlag bool) {
+	if left > right {
+		left, right = right, left
+	}
+	if top > bottom {
+		top, bottom = bottom, top
+	}
+	canvas.Bar(left, top, right, bottom)
+	canvas.Rectangle(left, top, right, bottom)
+	if depth == 0 {
+		return
+	}
+
+	dy := depth * 3 / 4
+	if topFlag {
+		canvas.stroke(image.Pt(left, top), image.Pt(left+depth, top-dy))
+		canvas.stroke(image.Pt(left+depth, top-dy), image.Pt(right+depth, top-dy))
+	}
+	canvas.stroke(image.Pt(right+depth, top-dy), image.Pt(right+depth, bottom-dy))
+	canvas.stroke(image.Pt(right+depth, bottom-dy), image.Pt(right, bottom))
+	if topFlag {
+		canvas.stroke(image.Pt(right, top), image.Pt(right+depth, top-dy))
+	}
+}
+
+// DrawPoly draws lines between the points using the current line style,
+// thickness and write mode. To draw a closed polygon, the last point must be
+// equal to the first.
+func (canvas *Canvas) DrawPoly(points ...image.Point) {
+	switch len(points) {
+	case 0:
+		return
+	case 1:
+		canvas.stroke(points[0], points[0])
+		return
+	}
+	for i, b := range points[1:] {
+		canvas.stroke(points[i], b)
+	}
+}
+
+// FillPoly draws a polygon filled with the current fill style and outlined
+// with the current color and line style. The polygon is closed automatically.
+func (canvas *Canvas) FillPoly(points ...image.Point) {
+	if len(points) == 0 {
+		return
+	}
+	if points[0] != points[len(points)-1] {
+		points = append(points[:len(points):len(points)], points[0])
+	}
+
+	top, bottom := points[0].Y, points[0].Y
+	for _, p := range points {
+		top = min(top, p.Y)
+		bottom = max(bottom, p.Y)
+	}
+
+	var xs []int
+	for y := top; y <= bottom; y++ {
+		xs = xs[:0]
+		for i, q := range points[1:] {
+			p := points[i]
+			if p.Y == q.Y {
+				continue
+			}
+			if p.Y > q.Y {
+				p, q = q, p
+			}
+			if y < p.Y || y >= q.Y {
+				continue
+			}
+			xs = append(xs, p.X+int(math.Floor(float64((y-p.Y)*(q.X-p.X))/float64(q.Y-p.Y)+.5)))
+		}
+		sort.Ints(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			for x := xs[i]; x <= xs[i+1]; x++ {
+				canvas.setColorFill(x, y)
+			}
+		}
+	}
+
+	canvas.DrawPoly(points...)
+}
+
+// angleSpan is a range of angles in degrees.
+type angleSpan struct {
+	start, end float64
+	full       bool
+}
+
+func newAngleSpan(start, end int) angleSpan {
+	if end-start >= 360 || start-end >= 360 || (start == 0 && end == 360) {
+		return angleSpan{full: true}
+	}
+	return angleSpan{
+		start: float64(((start % 360) + 360) % 360),
+		end:   float64(((end % 360) + 360) % 360),
+	}
+}
+
+// contains checks if the point p on an ellipse with radii rx and ry is within
+// the span.
+func (span angleSpan) contains(p image.Point, rx, ry int) bool {
+	if span.full {
+		return true
+	}
+	var (
+		fx = float64(p.X) * float64(max(ry, 1))
+		fy = -float64(p.Y) * float64(max(rx, 1))
+		a  = math.Atan2(fy, fx) * 180 / math.Pi
+	)
+	if a < 0 {
+		a += 360
+	}
+	if span.start <= span.end {
+		return a >= span.start-.5 && a <= span.end+.5
+	}
+	return a >= span.start-.5 || a <= span.end+.5
+}
+
+// arcCoords calculates the start and end coordinates of an arc.
+func arcCoords(center image.Point, start, end, rx, ry int) ArcCoords {
+	point := func(angle int) image.Point {
+		rad := float64(angle) * math.Pi / 180
+		return image.Pt(
+			center.X+int(math.Floor(float64(rx)*math.Cos(rad)+.5)),
+			center.Y-int(math.Floor(float64(ry)*math.Sin(rad)+.5)),
+		)
+	}
+	return ArcCoords{
+		Center: center,
+		Start:  point(start),
+		End:    point(end),
+	}
+}
+
+// ellipseQuadrant returns the points in the first quadrant of an ellipse with
+// radii rx and ry, starting at (0, ry) and ending at (rx, 0) using the midpoint
+// ellipse algorithm.
+func ellipseQuadrant(rx, ry int) []image.Point {
+	if rx <= 0 || ry <= 0 {
+		var points []image.Point
+		for x := 0; x <= max(rx, 0); x++ {
+			points = append(points, image.Pt(x, 0))
+		}
+		for y := 1; y <= max(ry, 0); y++ {
+			points = append(points, image.Pt(0, y))
+		}
+		return points
+	}
+
+	var (
+		points   []image.Point
+		rx2      = int64(rx) * int64(rx)
+		ry2      = int64(ry) * int64(ry)
+		x, y     = int64(0), int64(ry)
+		px, py   = int64(0), 2 * rx2 * y
+		p        = float64(ry2) - float64(rx2*int64(ry)) + .25*float64(rx2)
+		fx2, fy2 = float64(rx2), float64(ry2)
+	)
+
+	// Region 1, slope > -1
+	for px < py {
+		points = append(points, image.Pt(int(x), int(y)))
+		x++
+		px += 2 * ry2
+		if p < 0 {
+			p += float64(ry2 + px)
+		} else {
+			y--
+			py -= 2 * rx2
+			p += float64(ry2 + px - py)
+		}
+	}
+
+	// Region 2, slope <= -1
+	p = fy2*(float64(x)+.5)*(float64(x)+.5) + fx2*float64((y-1)*(y-1)) - fx2*fy2
+	for y >= 0 {
+		points = append(points, image.Pt(int(x), int(y)))
+		y--
+		py -= 2 * rx2
+		if p > 0 {
+			p += float64(rx2 - py)
+		} else {
+			x++
+			px += 2 * ry2
+			p += float64(rx2 - py + px)
+		}
+	}
+
+	return points
+}
+
+// ellipsePoints returns all unique points on an ellipse with radii rx and ry,
+// relative to the center.
+func ellipsePoints(rx, ry int) []image.Point {
+	var (
+		quadrant = ellipseQuadrant(rx, ry)
+		seen     = make(map[image.Point]bool, len(quadrant)*4)
+		points   = make([]image.Point, 0, len(quadrant)*4)
+	)
+	for _, sign := range []image.Point{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}} {
+		for _, p := range quadrant {
+			p = image.Pt(p.X*sign.X, p.Y*sign.Y)
+			if !seen[p] {
+				seen[p] = true
+				points = append(points, p)
+			}
+		}
+	}
+	return points
+}
+
+// ellipseSpans returns the horizontal half width for each row of an ellipse
+// with radii rx and ry, from the top row (-ry) to the bottom row (+ry).
+func ellipseSpans(rx, ry int) []int {
+	ry = max(ry, 0)
+	rows := make([]int, 2*ry+1)
+	for _, p := range ellipseQuadrant(rx, ry) {
+		rows[ry-p.Y] = max(rows[ry-p.Y], p.X)
+		rows[ry+p.Y] = max(rows[ry+p.Y], p.X)
+	}
+	return rows
+}
@@ -0,0 +1,231 @@
+package bgi
+
+import (
+	"image"
+	"sync"
+
+	"github.com/textmodes/parser/data"
+)
+
+// Direction of text.
+type Direction uint8
+
+// Text directions.
+const (
+	// HorizontalDirection draws text from left to right.
+	HorizontalDirection Direction = iota
+
+	// VerticalDirection draws text from bottom to top, rotated 90 degrees
+	// counter clockwise.
+	VerticalDirection
+)
+
+// Justify is the text justification.
+type Justify uint8
+
+// Text justifications.
+const (
+	JustifyLeft   Justify = 0
+	JustifyCenter Justify = 1
+	JustifyRight  Justify = 2
+	JustifyBottom Justify = 0
+	JustifyTop    Justify = 2
+)
+
+type textSettings struct {
+	font       *Font
+	direction  Direction
+	size       int
+	horizontal Justify
+	vertical   Justify
+}
+
+var defaultTextSettings = textSettings{
+	size:       1,
+	horizontal: JustifyLeft,
+	vertical:   JustifyTop,
+}
+
+// The default font is the 8x8 bitmap font from the IBM PC ROM.
+var defaultFont struct {
+	sync.Once
+	data []byte
+	err  error
+}
+
+func defaultFontData() ([]byte, error) {
+	defaultFont.Do(func() {
+		defaultFont.data, defaultFont.err = data.Bytes("font/chargen/ibm_vga50_437.bin")
+	})
+	return defaultFont.data, defaultFont.err
+}
+
+// SetTextStyle sets the font, direction and character size used by OutText
+// and OutTextXY. If font is nil, the default 8x8 bitmap font is used. The size
+// ranges from 1 to 10; for stroked fonts, size 4 is the natural size of the
+// font and for the bitmap font each pixel is scaled to size by size pixels.
+func (canvas *Canvas) SetTextStyle(font *Font, direction Direction, size int) {
+	canvas.text.font = font
+	canvas.text.direction = direction
+	canvas.text.size = max(1, min(10, size))
+}
+
+// SetTextJustify sets the text justification relative to the coordinates
+// passed to OutTextXY. The justification is relative to the screen axes, also
+// for vertical text.
+func (canvas *Canvas) SetTextJustify(horizontal, vertical Justify) {
+	if horizontal <= JustifyRight {
+		canvas.text.horizontal = horizontal
+	}
+	if vertical <= JustifyTop {
+		canvas.text.vertical = vertical
+	}
+}
+
+// TextWidth returns the width of s in pixels, using the current text style.
+func (canvas *Canvas) TextWidth(s string) int {
+	if f := canvas.text.font; f != nil {
+		var (
+			up   = scale.up[canvas.text.size]
+			down = scale.down[canvas.text.size]
+			w    int
+		)
+		for i := 0; i < len(s); i++ {
+			if index, ok := f.charIndex(s[i]); ok {
+				w += int(f.wtable[index])
+			}
+		}
+		return w * up / down
+	}
+	return len(s) * 8 * canvas.text.size
+}
+
+// TextHeight returns the height of s in pixels, using the current text style.
+func (canvas *Canvas) TextHeight(s string) int {
+	if f := canvas.text.font; f != nil {
+		var (
+			up   = scale.up[canvas.text.size]
+			down = scale.down[canvas.text.size]
+		)
+		return (int(f.header.OrgToCap) - int(f.header.OrgToDec)) * up / down
+	}
+	return 8 * canvas.text.size
+}
+
+// OutText draws s at the current position. For horizontal, left justified
+// text the current position is advanced by the width of the text.
+func (canvas *Canvas) OutText(s string) error {
+	if err := canvas.OutTextXY(canvas.position.X, canvas.position.Y, s); err != nil {
+		return err
+	}
+	if canvas.text.direction == HorizontalDirection && canvas.text.horizontal == JustifyLeft {
+		canvas.position.X += canvas.TextWidth(s)
+	}
+	return nil
+}
+
+// OutTextXY draws s at (x, y) using the current color, text style and
+// justification. An error is returned if the default bitmap font can not be
+// loaded.
+func (canvas *Canvas) OutTextXY(x, y int, s string) error {
+	var (
+		w, h   = canvas.TextWidth(s), canvas.TextHeight(s)
+		bw, bh = w, h // block size on screen
+	)
+	if canvas.text.direction == VerticalDirection {
+		bw, bh = h, w
+	}
+
+	// Top left of the text block.
+	switch canvas.text.horizontal {
+	case JustifyCenter:
+		x -= bw / 2
+	case JustifyRight:
+		x -= bw
+	}
+	switch canvas.text.vertical {
+	case JustifyBottom:
+		y -= bh
+	case JustifyCenter:
+		y -= bh / 2
+	}
+
+	// Maps text coordinates (u along the text, v down the glyph) to the screen.
+	transform := func(u, v int) image.Point {
+		if canvas.text.direction == VerticalDirection {
+			return image.Pt(x+v, y+w-1-u)
+		}
+		return image.Pt(x+u, y+v)
+	}
+
+	if canvas.text.font != nil {
+		canvas.outStrokeText(s, transform)
+		return nil
+	}
+	return canvas.outBitmapText(s, transform)
+}
+
+func (canvas *Canvas) outStrokeText(s string, transform func(u, v int) image.Point) {
+	var (
+		f    = canvas.text.font
+		up   = scale.up[canvas.text.size]
+		down = scale.down[canvas.text.size]
+		top  = int(f.header.OrgToCap)
+		plot = func(x, y int) { canvas.setColorIndex(x, y, canvas.fg, WriteModeSet) }
+		u    int
+	)
+	for i := 0; i < len(s); i++ {
+		index, ok := f.charIndex(s[i])
+		if !ok {
+			continue
+		}
+		var pen image.Point
+	strokes:
+		for _, stroke := range f.vectors[f.offsets[index]>>1:] {
+			var (
+				pu = u + stroke.X()*up/down
+				pv = (top - stroke.Y()) * up / down
+				pt = transform(pu, pv)
+			)
+			switch stroke & strokeOpMask {
+			case strokeOpEnd:
+				break strokes
+			case strokeOpMove:
+				pen = pt
+			case strokeOpDraw:
+				bresenham(pen, pt, plot)
+				pen = pt
+			}
+		}
+		u += int(f.wtable[index]) * up / down
+	}
+}
+
+func (canvas *Canvas) outBitmapText(s string, transform func(u, v int) image.Point) error {
+	rom, err := defaultFontData()
+	if err != nil {
+		return err
+	}
+	size := canvas.text.size
+	for i := 0; i < len(s); i++ {
+		offset := int(s[i]) * 8
+		if offset+8 > len(rom) {
+			continue
+		}
+		for gy := 0; gy < 8; gy++ {
+			bits := rom[offset+gy]
+			for gx := 0; gx < 8; gx++ {
+				if bits&(0x80>>uint(gx)) == 0 {
+					continue
+				}
+				for v := gy * size; v < (gy+1)*size; v++ {
+					for u := (i*8 + gx) * size; u < (i*8+gx+1)*size; u++ {
+						pt := transform(u, v)
+						canvas.setColorIndex(pt.X, pt.Y, canvas.fg, WriteModeSet)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,418 @@
+package crt
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+)
+
+// Mask is the type of phosphor mask.
+type Mask int
+
+// Masks.
+const (
+	// NoMask disables the phosphor mask.
+	NoMask Mask = iota
+
+	// ApertureGrille has vertical red, green and blue phosphor stripes, like
+	// Trinitron tubes.
+	ApertureGrille
+
+	// ShadowMask has red, green and blue phosphor triads, shifted on
+	// alternating rows.
+	ShadowMask
+)
+
+func (mask Mask) String() string {
+	switch mask {
+	case NoMask:
+		return "none"
+	case ApertureGrille:
+		return "aperture grille"
+	case ShadowMask:
+		return "shadow mask"
+	default:
+		return "invalid"
+	}
+}
+
+// Options for the display emulation.
+type Options struct {
+	// Scale is the number of output pixels per source pixel, if left empty it
+	// defaults to 3.
+	Scale int
+
+	// Scanlines is the darkening of the gaps between the scan lines, from 0
+	// (no gaps) to 1 (black gaps).
+	Scanlines float64
+
+	// Interlace moves the scan lines half a line down on odd fields, which
+	// flickers when the fields are shown alternately.
+	Interlace bool
+
+	// Bloom is the strength of the phosphor glow around bright pixels, from
+	// 0 (no glow) to 1.
+	Bloom float64
+
+	// BloomRadius is the radius of the phosphor glow in source pixels, if
+	// left empty it defaults to 1.
+	BloomRadius int
+
+	// Curvature is the amount of barrel distortion, 0 is a flat screen.
+	Curvature float64
+
+	// Mask is the type of phosphor mask.
+	Mask Mask
+
+	// MaskStrength is the darkening of the colors a phosphor doesn't emit,
+	// from 0 to 1. The brightness is compensated for the darkening.
+	MaskStrength float64
+
+	// Bleed is the radius in source pixels over which colors bleed
+	// horizontally, like on a composite video signal. The brightness is kept
+	// sharp.
+	Bleed int
+}
+
+// Presets.
+var (
+	// VGA is a VGA computer monitor with faint scan lines.
+	VGA = Options{
+		Scale:        3,
+		Scanlines:    0.25,
+		Bloom:        0.15,
+		BloomRadius:  1,
+		Curvature:    0.02,
+		Mask:         ShadowMask,
+		MaskStrength: 0.2,
+	}
+
+	// Amiga1084S is a Commodore 1084S monitor connected over RGB, with the
+	// visible scan lines of a 15 kHz display.
+	Amiga1084S = Options{
+		Scale:        3,
+		Scanlines:    0.5,
+		Bloom:        0.25,
+		BloomRadius:  1,
+		Curvature:    0.04,
+		Mask:         ShadowMask,
+		MaskStrength: 0.3,
+	}
+
+	// Teletext is an interlaced TV set receiving teletext over RF.
+	Teletext = Options{
+		Scale:        3,
+		Scanlines:    0.4,
+		Interlace:    true,
+		Bloom:        0.3,
+		BloomRadius:  2,
+		Curvature:    0.06,
+		Mask:         ApertureGrille,
+		MaskStrength: 0.25,
+		Bleed:        1,
+	}
+)
+
+// Presets by name.
+var Presets = map[string]Options{
+	"vga":      VGA,
+	"amiga":    Amiga1084S,
+	"teletext": Teletext,
+}
+
+// rgb is a pixel with 8-bit channels, with headroom for processing.
+type rgb struct {
+	r, g, b int32
+}
+
+// fixed converts v to 8.8 fixed point, clamped to [0, 1].
+func fixed(v float64) int32 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 1:
+		return 0x100
+	default:
+		return int32(v * 0x100)
+	}
+}
+
+// Apply the display emulation to an image, for the first field.
+func Apply(m image.Image, opts Options) *image.RGBA {
+	return ApplyField(m, opts, 0)
+}
+
+// ApplyField applies the display emulation to an image, field selects the
+// interlaced field if Interlace is enabled.
+func ApplyField(m image.Image, opts Options, field int) *image.RGBA {
+	if opts.Scale < 1 {
+		opts.Scale = 3
+	}
+	if opts.BloomRadius < 1 {
+		opts.BloomRadius = 1
+	}
+
+	var (
+		src   = m.Bounds()
+		scale = opts.Scale
+		sw    = src.Dx()
+		w, h  = sw * scale, src.Dy() * scale
+		dst   = image.NewRGBA(image.Rect(0, 0, w, h))
+	)
+	if w == 0 || h == 0 {
+		return dst
+	}
+
+	var (
+		pix   = bleed(m, opts.Bleed)
+		out   = make([]rgb, w*h)
+		scan  = 0x100 - fixed(opts.Scanlines)
+		dark  = 0x100 - fixed(opts.MaskStrength)
+		gain  = int32(3 * 0x10000 / (0x100 + 2*dark)) // 1/average mask level
+		curve = int64(opts.Curvature * 0x10000)
+		shift int
+	)
+	if opts.Interlace && field&1 == 1 {
+		shift = scale / 2
+	}
+	for oy := 0; oy < h; oy++ {
+		for ox := 0; ox < w; ox++ {
+			sx, sy, ok := distort(ox, oy, w, h, curve)
+			if !ok {
+				continue
+			}
+			c := pix[(sy/scale)*sw+sx/scale]
+
+			// Scan line gap at the bottom of each line
+			if scale > 1 && (sy+shift)%scale == scale-1 {
+				c.r, c.g, c.b = c.r*scan>>8, c.g*scan>>8, c.b*scan>>8
+			}
+
+			// Phosphor mask
+			if opts.Mask != NoMask {
+				phase := ox % 3
+				if opts.Mask == ShadowMask && (oy>>1)&1 == 1 {
+					phase = (ox + 1) % 3
+				}
+				if phase != 0 {
+					c.r = c.r * dark >> 8
+				}
+				if phase != 1 {
+					c.g = c.g * dark >> 8
+				}
+				if phase != 2 {
+					c.b = c.b * dark >> 8
+				}
+				c.r, c.g, c.b = c.r*gain>>8, c.g*gain>>8, c.b*gain>>8
+			}
+
+			out[oy*w+ox] = c
+		}
+	}
+
+	if bloom := fixed(opts.Bloom); bloom > 0 {
+		glow := blur(out, w, h, opts.BloomRadius*scale)
+		for i, c := range glow {
+			out[i].r += c.r * bloom >> 8
+			out[i].g += c.g * bloom >> 8
+			out[i].b += c.b * bloom >> 8
+		}
+	}
+
+	for i, c := range out {
+		dst.Pix[i*4+0] = clamp(c.r)
+		dst.Pix[i*4+1] = clamp(c.g)
+		dst.Pix[i*4+2] = clamp(c.b)
+		dst.Pix[i*4+3] = 0xff
+	}
+	return dst
+}
+
+// ApplyGIF applies the display emulation to every frame of an animation.
+// Frames are composed on to the full canvas before processing and odd frames
+// use the odd interlaced field. The output frames are dithered to the Plan 9
+// palette.
+func ApplyGIF(g *gif.GIF, opts Options) *gif.GIF {
+	r := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if r.Empty() {
+		for _, frame := range g.Image {
+			r = r.Union(frame.Bounds())
+		}
+	}
+
+	var (
+		canvas = image.NewRGBA(r)
+		out    = &gif.GIF{
+			LoopCount: g.LoopCount,
+		}
+	)
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			previous = image.NewRGBA(r)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		im := ApplyField(canvas, opts, i)
+		p := image.NewPaletted(im.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(p, p.Bounds(), im, image.ZP)
+		out.Image = append(out.Image, p)
+		if i < len(g.Delay) {
+			out.Delay = append(out.Delay, g.Delay[i])
+		} else {
+			out.Delay = append(out.Delay, 0)
+		}
+
+		if i < len(g.Disposal) {
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, frame.Bounds(), image.Transparent, image.ZP, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = previous
+			}
+		}
+	}
+	if len(out.Image) > 0 {
+		b := out.Image[0].Bounds()
+		out.Config = image.Config{
+			ColorModel: color.Palette(palette.Plan9),
+			Width:      b.Dx(),
+			Height:     b.Dy(),
+		}
+	}
+	return out
+}
+
+// distort maps output pixel (x, y) to the pixel shown there on a curved
+// screen, ok is false if the pixel is outside of the screen. The coordinates
+// are normalised to [-1, 1] in 16.16 fixed point.
+func distort(x, y, w, h int, curve int64) (sx, sy int, ok bool) {
+	if curve == 0 {
+		return x, y, true
+	}
+	var (
+		u  = (int64(2*x+1-w) << 16) / int64(w)
+		v  = (int64(2*y+1-h) << 16) / int64(h)
+		r2 = (u*u + v*v) >> 16
+		f  = 1<<16 + (curve*r2)>>16
+	)
+	u, v = u*f>>16, v*f>>16
+	sx = int(((u + 1<<16) * int64(w)) >> 17)
+	sy = int(((v + 1<<16) * int64(h)) >> 17)
+	return sx, sy, sx >= 0 && sy >= 0 && sx < w && sy < h
+}
+
+// bleed reads the image and blurs the colors horizontally over radius pixels,
+// while keeping the luma of each pixel.
+func bleed(m image.Image, radius int) []rgb {
+	var (
+		r   = m.Bounds()
+		w   = r.Dx()
+		pix = make([]rgb, w*r.Dy())
+	)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < w; x++ {
+			cr, cg, cb, _ := m.At(r.Min.X+x, r.Min.Y+y).RGBA()
+			pix[y*w+x] = rgb{int32(cr >> 8), int32(cg >> 8), int32(cb >> 8)}
+		}
+	}
+	if radius < 1 {
+		return pix
+	}
+
+	out := make([]rgb, len(pix))
+	for y := 0; y < r.Dy(); y++ {
+		row := pix[y*w : (y+1)*w]
+		for x, c := range row {
+			var (
+				sum rgb
+				n   int32
+			)
+			for i := max(0, x-radius); i <= min(w-1, x+radius); i++ {
+				sum.r += row[i].r
+				sum.g += row[i].g
+				sum.b += row[i].b
+				n++
+			}
+			sum.r, sum.g, sum.b = sum.r/n, sum.g/n, sum.b/n
+			d := luma(c) - luma(sum)
+			out[y*w+x] = rgb{
+				int32(clamp(sum.r + d)),
+				int32(clamp(sum.g + d)),
+				int32(clamp(sum.b + d)),
+			}
+		}
+	}
+	return out
+}
+
+// luma is the ITU-R BT.601 luma of a pixel.
+func luma(c rgb) int32 {
+	return (77*c.r + 150*c.g + 29*c.b) >> 8
+}
+
+// blur is a separable box blur.
+func blur(pix []rgb, w, h, radius int) []rgb {
+	var (
+		tmp = make([]rgb, len(pix))
+		out = make([]rgb, len(pix))
+	)
+	boxBlur(tmp, pix, w, h, 1, w, radius)
+	boxBlur(out, tmp, h, w, w, 1, radius)
+	return out
+}
+
+// boxBlur blurs n lines of size pixels, where step is the distance between
+// pixels in a line and stride the distance between lines.
+func boxBlur(dst, src []rgb, size, n, step, stride, radius int) {
+	d := int32(2*radius + 1)
+	for line := 0; line < n; line++ {
+		var (
+			offset = line * stride
+			sum    rgb
+		)
+		at := func(i int) rgb {
+			if i < 0 || i >= size {
+				return rgb{}
+			}
+			return src[offset+i*step]
+		}
+		for i := -radius; i <= radius; i++ {
+			c := at(i)
+			sum.r, sum.g, sum.b = sum.r+c.r, sum.g+c.g, sum.b+c.b
+		}
+		for i := 0; i < size; i++ {
+			dst[offset+i*step] = rgb{sum.r / d, sum.g / d, sum.b / d}
+			a, b := at(i+radius+1), at(i-radius)
+			sum.r, sum.g, sum.b = sum.r+a.r-b.r, sum.g+a.g-b.g, sum.b+a.b-b.b
+		}
+	}
+}
+
+func clamp(v int32) uint8 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 0xff:
+		return 0xff
+	default:
+		return uint8(v)
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+package crt
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func testImage(c color.Color) *image.Paletted {
+	im := image.NewPaletted(image.Rect(0, 0, 8, 4), color.Palette{color.Black, c})
+	for i := range im.Pix {
+		im.Pix[i] = 1
+	}
+	return im
+}
+
+func TestApply(t *testing.T) {
+	white := testImage(color.White)
+
+	im := Apply(white, Options{})
+	if r := im.Bounds(); r != image.Rect(0, 0, 24, 12) {
+		t.Fatalf("expected default scale 3, got bounds %s", r)
+	}
+	if c := im.RGBAAt(4, 4); c != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Fatalf("expected no effects, got %v", c)
+	}
+
+	// Scan line gaps at the bottom of every line
+	im = Apply(white, Options{Scale: 4, Scanlines: 0.5})
+	for y := 0; y < 8; y++ {
+		want := uint8(0xff)
+		if y%4 == 3 {
+			want = 0x7f
+		}
+		if c := im.RGBAAt(0, y); c.R != want {
+			t.Errorf("row %d: expected %#02x, got %#02x", y, want, c.R)
+		}
+	}
+
+	// Interlacing moves the gaps on odd fields
+	im = ApplyField(white, Options{Scale: 4, Scanlines: 0.5, Interlace: true}, 1)
+	if c := im.RGBAAt(0, 1); c.R != 0x7f {
+		t.Errorf("expected gap on row 1 of the odd field, got %#02x", c.R)
+	}
+
+	// Aperture grille stripes
+	im = Apply(white, Options{Scale: 3, Mask: ApertureGrille, MaskStrength: 1})
+	for x, want := range []color.RGBA{{0xff, 0, 0, 0xff}, {0, 0xff, 0, 0xff}, {0, 0, 0xff, 0xff}} {
+		if c := im.RGBAAt(x, 0); c != want {
+			t.Errorf("column %d: expected %v, got %v", x, want, c)
+		}
+	}
+
+	// Barrel distortion leaves the corners black
+	im = Apply(white, Options{Scale: 3, Curvature: 0.2})
+	if c := im.RGBAAt(0, 0); c.R != 0 {
+		t.Errorf("expected black corner, got %v", c)
+	}
+	if c := im.RGBAAt(12, 6); c.R != 0xff {
+		t.Errorf("expected white center, got %v", c)
+	}
+
+	// Bloom brightens the neighbours of bright pixels
+	dot := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	dot.Set(4, 2, color.RGBA{0x80, 0x80, 0x80, 0xff})
+	im = Apply(dot, Options{Scale: 1, Bloom: 1})
+	if c := im.RGBAAt(5, 2); c.R == 0 {
+		t.Error("expected bloom next to the bright pixel")
+	}
+	if c := im.RGBAAt(0, 0); c.R != 0 {
+		t.Errorf("expected no bloom far from the bright pixel, got %v", c)
+	}
+}
+
+func TestBleed(t *testing.T) {
+	im := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	im.Set(0, 0, color.RGBA{0xff, 0, 0, 0xff})
+	im.Set(1, 0, color.RGBA{0, 0, 0xff, 0xff})
+	pix := bleed(im, 1)
+	if pix[1].r == 0 {
+		t.Errorf("expected red to bleed into the blue pixel, got %+v", pix[1])
+	}
+	if a, b := luma(pix[1]), luma(rgb{0, 0, 0xff}); a < b-8 || a > b+8 {
+		t.Errorf("expected luma close to %d, got %d", b, a)
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	var (
+		im = testImage(color.RGBA{0x00, 0xaa, 0xaa, 0xff})
+		a  = Apply(im, Teletext)
+		b  = Apply(im, Teletext)
+	)
+	if !bytes.Equal(a.Pix, b.Pix) {
+		t.Fatal("expected identical output")
+	}
+}
+
+func TestApplyGIF(t *testing.T) {
+	g := &gif.GIF{
+		Image: []*image.Paletted{testImage(color.White), testImage(color.White)},
+		Delay: []int{40, 40},
+	}
+	out := ApplyGIF(g, Teletext)
+	if len(out.Image) != 2 || len(out.Delay) != 2 || out.Delay[1] != 40 {
+		t.Fatalf("expected 2 frames with delays, got %d frames, delays %v", len(out.Image), out.Delay)
+	}
+	if out.Config.Width != 24 || out.Config.Height != 12 {
+		t.Fatalf("expected 24x12 config, got %dx%d", out.Config.Width, out.Config.Height)
+	}
+	if bytes.Equal(out.Image[0].Pix, out.Image[1].Pix) {
+		t.Fatal("expected interlaced fields to differ")
+	}
+	if err := gif.EncodeAll(new(bytes.Buffer), out); err != nil {
+		t.Fatal(err)
+	}
+}
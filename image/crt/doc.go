@@ -0,0 +1,10 @@
+/*
+Package crt implements cathode ray tube (CRT) display emulation as a post
+processing step for rendered text mode and bitmap images.
+
+The effects are scanlines, phosphor bloom, barrel distortion, a phosphor mask
+(aperture grille or shadow mask), interlace flicker and composite colour
+bleed. All processing uses integer arithmetic, so the output is the same on
+every platform.
+*/
+package crt
@@ -0,0 +1,30 @@
+package ilbm
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/format/sauce"
+)
+
+func init() {
+	parser.Register(parser.Format{
+		Name:       "ilbm",
+		Extensions: []string{".iff", ".ilbm", ".lbm"},
+		Magic:      []string{"FORM????ILBM", "FORM????PBM ", "FORM????ACBM"},
+		SAUCE:      []parser.SAUCEType{{DataType: sauce.Bitmap, FileType: sauce.LBM}},
+		Decode: func(r io.Reader) (parser.Parser, error) {
+			b, err := ioutil.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			im, err := Decode(bytes.NewReader(b))
+			if err != nil {
+				return nil, err
+			}
+			return parser.WrapImage(im), nil
+		},
+	})
+}
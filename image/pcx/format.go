@@ -0,0 +1,50 @@
+package pcx
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/format/sauce"
+)
+
+func init() {
+	parser.Register(parser.Format{
+		Name:       "pcx",
+		Extensions: []string{".pcx"},
+		Match:      isPCX,
+		SAUCE:      []parser.SAUCEType{{DataType: sauce.Bitmap, FileType: sauce.PCX}},
+		Decode: func(r io.Reader) (parser.Parser, error) {
+			im, err := Decode(r)
+			if err != nil {
+				return nil, err
+			}
+			return parser.WrapImage(im), nil
+		},
+	})
+}
+
+// isPCX checks the manufacturer, version, encoding, bits per pixel and window
+// of a PCX header.
+func isPCX(header []byte) bool {
+	if len(header) < 128 || header[0] != 0x0a || header[2] > 1 {
+		return false
+	}
+	switch header[1] {
+	case 0, 2, 3, 4, 5:
+	default:
+		return false
+	}
+	switch header[3] {
+	case 1, 2, 4, 8:
+	default:
+		return false
+	}
+	var (
+		xmin = binary.LittleEndian.Uint16(header[4:])
+		ymin = binary.LittleEndian.Uint16(header[6:])
+		xmax = binary.LittleEndian.Uint16(header[8:])
+		ymax = binary.LittleEndian.Uint16(header[10:])
+	)
+	return xmin <= xmax && ymin <= ymax
+}
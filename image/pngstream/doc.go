@@ -0,0 +1,5 @@
+/*
+Package pngstream implements a PNG encoder for paletted images that are
+written in horizontal strips, so the full image never has to be in memory.
+*/
+package pngstream
@@ -0,0 +1,194 @@
+package pngstream
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Errors.
+var (
+	ErrPalette = errors.New("pngstream: palette must have 1 to 256 colors")
+	ErrClosed  = errors.New("pngstream: writer is closed")
+)
+
+const (
+	pngHeader = "\x89PNG\r\n\x1a\n"
+
+	// chunkSize is the maximum size of the IDAT chunks.
+	chunkSize = 1 << 16
+)
+
+// Writer writes a paletted PNG image, row by row.
+type Writer struct {
+	w      *bufio.Writer
+	bounds image.Rectangle
+	rows   int
+	idat   *chunkWriter
+	zw     *zlib.Writer
+	line   []byte
+	err    error
+	closed bool
+}
+
+// NewWriter writes the PNG header for a paletted image of width by height
+// pixels to w; the pixels are written with WriteRows.
+func NewWriter(w io.Writer, width, height int, palette color.Palette) (*Writer, error) {
+	if len(palette) == 0 || len(palette) > 256 {
+		return nil, ErrPalette
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("pngstream: invalid image size %dx%d", width, height)
+	}
+
+	pw := &Writer{
+		w:      bufio.NewWriter(w),
+		bounds: image.Rect(0, 0, width, height),
+		line:   make([]byte, 1+width),
+	}
+	if _, err := pw.w.WriteString(pngHeader); err != nil {
+		return nil, err
+	}
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(height))
+	ihdr[8] = 8 // Bit depth
+	ihdr[9] = 3 // Color type: paletted
+	if err := writeChunk(pw.w, "IHDR", ihdr); err != nil {
+		return nil, err
+	}
+
+	var (
+		plte   = make([]byte, 3*len(palette))
+		trns   = make([]byte, len(palette))
+		opaque = true
+		last   = -1
+	)
+	for i, c := range palette {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		plte[3*i+0], plte[3*i+1], plte[3*i+2] = n.R, n.G, n.B
+		if trns[i] = n.A; n.A != 0xff {
+			opaque = false
+			last = i
+		}
+	}
+	if err := writeChunk(pw.w, "PLTE", plte); err != nil {
+		return nil, err
+	}
+	if !opaque {
+		if err := writeChunk(pw.w, "tRNS", trns[:last+1]); err != nil {
+			return nil, err
+		}
+	}
+
+	pw.idat = &chunkWriter{w: pw.w, buf: make([]byte, 0, chunkSize)}
+	pw.zw = zlib.NewWriter(pw.idat)
+	return pw, nil
+}
+
+// WriteRows writes the next rows of the image, from the paletted image m.
+// The rows written are the rows of the bounds of m, which must be as wide as
+// the image.
+func (pw *Writer) WriteRows(m *image.Paletted) error {
+	if pw.closed {
+		return ErrClosed
+	}
+	if pw.err != nil {
+		return pw.err
+	}
+	r := m.Bounds()
+	if r.Dx() != pw.bounds.Dx() {
+		return fmt.Errorf("pngstream: expected rows of %d pixels, got %d", pw.bounds.Dx(), r.Dx())
+	}
+	if pw.rows+r.Dy() > pw.bounds.Dy() {
+		return fmt.Errorf("pngstream: too many rows, image has %d rows", pw.bounds.Dy())
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		// Filter type none
+		pw.line[0] = 0
+		copy(pw.line[1:], m.Pix[m.PixOffset(r.Min.X, y):])
+		if _, pw.err = pw.zw.Write(pw.line); pw.err != nil {
+			return pw.err
+		}
+		pw.rows++
+	}
+	return nil
+}
+
+// Close finishes the image data and writes the PNG trailer. It is an error
+// to close the writer before all rows are written.
+func (pw *Writer) Close() error {
+	if pw.closed {
+		return ErrClosed
+	}
+	pw.closed = true
+	if pw.err != nil {
+		return pw.err
+	}
+	if pw.rows != pw.bounds.Dy() {
+		return fmt.Errorf("pngstream: only %d of %d rows written", pw.rows, pw.bounds.Dy())
+	}
+	if err := pw.zw.Close(); err != nil {
+		return err
+	}
+	if err := pw.idat.flush(); err != nil {
+		return err
+	}
+	if err := writeChunk(pw.w, "IEND", nil); err != nil {
+		return err
+	}
+	return pw.w.Flush()
+}
+
+// chunkWriter buffers compressed image data in to IDAT chunks.
+type chunkWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (cw *chunkWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		i := copy(cw.buf[len(cw.buf):cap(cw.buf)], p)
+		cw.buf = cw.buf[:len(cw.buf)+i]
+		p = p[i:]
+		n += i
+		if len(cw.buf) == cap(cw.buf) {
+			if err = cw.flush(); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (cw *chunkWriter) flush() error {
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	err := writeChunk(cw.w, "IDAT", cw.buf)
+	cw.buf = cw.buf[:0]
+	return err
+}
+
+func writeChunk(w io.Writer, kind string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, crc.Sum32())
+}
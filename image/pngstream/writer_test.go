@@ -0,0 +1,79 @@
+package pngstream
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var (
+		palette = color.Palette{
+			color.NRGBA{0x00, 0x00, 0x00, 0xff},
+			color.NRGBA{0xff, 0x00, 0x00, 0xff},
+			color.NRGBA{0x00, 0xff, 0x00, 0x80},
+		}
+		want = image.NewPaletted(image.Rect(0, 0, 300, 500), palette)
+		b    = new(bytes.Buffer)
+	)
+	for i := range want.Pix {
+		want.Pix[i] = uint8((i*7 + i/300) % len(palette))
+	}
+
+	w, err := NewWriter(b, 300, 500, palette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 500; y += 64 {
+		strip := want.SubImage(image.Rect(0, y, 300, y+64)).(*image.Paletted)
+		if err = w.WriteRows(strip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := png.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := m.(*image.Paletted)
+	if !ok {
+		t.Fatalf("expected paletted image, got %T", m)
+	}
+	if got.Rect != want.Rect || !bytes.Equal(got.Pix, want.Pix) {
+		t.Error("decoded image differs")
+	}
+	for i, c := range palette {
+		if got.Palette[i] != c {
+			t.Errorf("palette %d: expected %v, got %v", i, c, got.Palette[i])
+		}
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	if _, err := NewWriter(new(bytes.Buffer), 1, 1, nil); err != ErrPalette {
+		t.Errorf("expected %v, got %v", ErrPalette, err)
+	}
+
+	palette := color.Palette{color.Black}
+	w, err := NewWriter(new(bytes.Buffer), 2, 2, palette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.WriteRows(image.NewPaletted(image.Rect(0, 0, 3, 1), palette)); err == nil {
+		t.Error("expected error for row width")
+	}
+	if err = w.WriteRows(image.NewPaletted(image.Rect(0, 0, 2, 1), palette)); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err == nil {
+		t.Error("expected error for missing rows")
+	}
+	if err = w.Close(); err != ErrClosed {
+		t.Errorf("expected %v, got %v", ErrClosed, err)
+	}
+}
@@ -0,0 +1,11 @@
+/*
+Package pyramid writes tile pyramids of large images for zoomable viewers, as
+Deep Zoom Image (DZI) or XYZ tiles.
+
+The full resolution image is rendered from its Source in strips, from the top
+down. Each level of the pyramid buffers only the rows needed for the current
+row of tiles, and feeds the downsampled rows to the next level. This means
+pieces of any height can be exported, without rasterising the whole image
+first.
+*/
+package pyramid
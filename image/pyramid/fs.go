@@ -0,0 +1,25 @@
+package pyramid
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FS creates the files of a tile pyramid. The names are slash separated paths.
+type FS interface {
+	Create(name string) (io.WriteCloser, error)
+}
+
+// Dir is a FS that creates the files in a directory, creating the
+// subdirectories as needed.
+type Dir string
+
+// Create a file in the directory.
+func (dir Dir) Create(name string) (io.WriteCloser, error) {
+	name = filepath.Join(string(dir), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(name)
+}
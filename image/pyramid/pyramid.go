@@ -0,0 +1,322 @@
+package pyramid
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"path"
+)
+
+// Errors.
+var (
+	ErrTileSize = errors.New("pyramid: invalid tile size or overlap")
+	ErrEmpty    = errors.New("pyramid: empty image")
+)
+
+// Format is the layout of the tile pyramid.
+type Format int
+
+// Formats.
+const (
+	// DZI is the Deep Zoom Image format, as used by OpenSeadragon. The
+	// pyramid is described by name.dzi and the tiles are stored as
+	// name_files/level/column_row.png, where level 0 is a single pixel.
+	// Tiles at the right and bottom edges are smaller than the tile size.
+	DZI Format = iota
+
+	// XYZ is the slippy map tile format, as used by Leaflet and OpenLayers.
+	// The tiles are stored as name/z/x/y.png, where zoom level 0 fits the
+	// image in a single tile. All tiles have the tile size, tiles at the
+	// edges are padded with transparent pixels.
+	XYZ
+)
+
+func (format Format) String() string {
+	switch format {
+	case DZI:
+		return "dzi"
+	case XYZ:
+		return "xyz"
+	default:
+		return "invalid"
+	}
+}
+
+// Options for writing a tile pyramid.
+type Options struct {
+	// Format of the pyramid.
+	Format Format
+
+	// TileSize is the width and height of the tiles, if left empty it
+	// defaults to 254 for DZI and 256 for XYZ.
+	TileSize int
+
+	// Overlap is the number of pixels the tiles overlap on each side. It is
+	// only used for DZI, where it defaults to 1 if TileSize is empty.
+	Overlap int
+}
+
+// Write the tile pyramid of the image rendered by src to fs, the file names
+// start with name. If opts is nil, DZI tiles are written.
+func Write(fs FS, name string, src Source, opts *Options) error {
+	if opts == nil {
+		opts = new(Options)
+	}
+	var (
+		format  = opts.Format
+		tile    = opts.TileSize
+		overlap = opts.Overlap
+	)
+	if tile == 0 {
+		switch format {
+		case DZI:
+			tile, overlap = 254, 1
+		case XYZ:
+			tile = 256
+		}
+	}
+	if format == XYZ {
+		overlap = 0
+	}
+	if format != DZI && format != XYZ {
+		return fmt.Errorf("pyramid: unknown format %d", format)
+	}
+	if tile < 1 || overlap < 0 || overlap >= tile {
+		return ErrTileSize
+	}
+
+	bounds := src.Bounds()
+	if bounds.Empty() {
+		return ErrEmpty
+	}
+
+	// The top level has the full resolution, each level below has half the
+	// size rounded up; DZI goes down to a single pixel, XYZ down to a single
+	// tile.
+	var (
+		top  int
+		size = bounds.Size()
+	)
+	for 1<<uint(top) < max(size.X, size.Y) {
+		top++
+	}
+	bottom := 0
+	if format == XYZ {
+		for bottom < top && 1<<uint(bottom+1) <= tile {
+			bottom++
+		}
+	}
+
+	w := &writer{
+		fs:      fs,
+		name:    name,
+		format:  format,
+		tile:    tile,
+		overlap: overlap,
+		bottom:  bottom,
+	}
+	var levels []*level
+	for i := top; i >= bottom; i-- {
+		levels = append(levels, &level{w: w, index: i, size: size})
+		size = image.Pt((size.X+1)/2, (size.Y+1)/2)
+	}
+	for i := 0; i < len(levels)-1; i++ {
+		levels[i].down = levels[i+1]
+	}
+
+	if format == DZI {
+		if err := w.writeDescriptor(bounds.Size()); err != nil {
+			return err
+		}
+	}
+
+	// Render the full resolution image in strips of tile rows.
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += tile {
+		r := image.Rect(bounds.Min.X, y, bounds.Max.X, min(y+tile, bounds.Max.Y))
+		m, err := src.Region(r)
+		if err != nil {
+			return err
+		}
+		lut := paletteRGBA(m)
+		for ry := r.Min.Y; ry < r.Max.Y; ry++ {
+			row := make([]uint8, 4*r.Dx())
+			readRow(row, m, r.Min.X, ry, lut)
+			if err = levels[0].push(row); err != nil {
+				return err
+			}
+		}
+	}
+	return levels[0].close()
+}
+
+type writer struct {
+	fs      FS
+	name    string
+	format  Format
+	tile    int
+	overlap int
+	bottom  int
+}
+
+func (w *writer) writeDescriptor(size image.Point) error {
+	f, err := w.fs.Create(w.name + ".dzi")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, `<?xml version="1.0" encoding="UTF-8"?>
+<Image xmlns="http://schemas.microsoft.com/deepzoom/2008" Format="png" Overlap="%d" TileSize="%d">
+  <Size Width="%d" Height="%d"/>
+</Image>
+`, w.overlap, w.tile, size.X, size.Y)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// tileName is the file name of a tile.
+func (w *writer) tileName(level, col, row int) string {
+	if w.format == XYZ {
+		return path.Join(w.name, fmt.Sprint(level-w.bottom), fmt.Sprint(col), fmt.Sprintf("%d.png", row))
+	}
+	return path.Join(w.name+"_files", fmt.Sprint(level), fmt.Sprintf("%d_%d.png", col, row))
+}
+
+func (w *writer) writeTile(name string, m image.Image) error {
+	f, err := w.fs.Create(name)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, m)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// level of the pyramid, it buffers the rows for the current row of tiles.
+type level struct {
+	w       *writer
+	index   int
+	size    image.Point
+	top     int       // y of the first buffered row
+	rows    [][]uint8 // buffered premultiplied RGBA rows
+	pending []uint8   // even row waiting for its pair to downsample
+	tileRow int       // next row of tiles
+	down    *level    // next (smaller) level
+}
+
+// push the next row of pixels.
+func (l *level) push(row []uint8) error {
+	l.rows = append(l.rows, row)
+	if err := l.writeTiles(); err != nil {
+		return err
+	}
+	if l.down == nil {
+		return nil
+	}
+	if l.pending == nil {
+		l.pending = row
+		return nil
+	}
+	row, l.pending = downsample(l.pending, row), nil
+	return l.down.push(row)
+}
+
+// close flushes the last odd row to the levels below.
+func (l *level) close() error {
+	if l.down == nil {
+		return nil
+	}
+	if l.pending != nil {
+		row := downsample(l.pending, nil)
+		l.pending = nil
+		if err := l.down.push(row); err != nil {
+			return err
+		}
+	}
+	return l.down.close()
+}
+
+// writeTiles writes the rows of tiles that are complete.
+func (l *level) writeTiles() error {
+	tile, overlap := l.w.tile, l.w.overlap
+	for l.tileRow*tile < l.size.Y {
+		y0 := max(0, l.tileRow*tile-overlap)
+		y1 := min(l.size.Y, (l.tileRow+1)*tile+overlap)
+		if l.top+len(l.rows) < y1 {
+			return nil
+		}
+		for col := 0; col*tile < l.size.X; col++ {
+			x0 := max(0, col*tile-overlap)
+			x1 := min(l.size.X, (col+1)*tile+overlap)
+			r := image.Rect(0, 0, x1-x0, y1-y0)
+			if l.w.format == XYZ {
+				r = image.Rect(0, 0, tile, tile)
+			}
+			im := image.NewRGBA(r)
+			for y := y0; y < y1; y++ {
+				copy(im.Pix[(y-y0)*im.Stride:], l.rows[y-l.top][4*x0:4*x1])
+			}
+			if err := l.w.writeTile(l.w.tileName(l.index, col, l.tileRow), im); err != nil {
+				return err
+			}
+		}
+		l.tileRow++
+
+		// Drop the rows above the next row of tiles.
+		if drop := l.tileRow*tile - overlap - l.top; drop > 0 {
+			drop = min(drop, len(l.rows))
+			n := copy(l.rows, l.rows[drop:])
+			for i := n; i < len(l.rows); i++ {
+				l.rows[i] = nil
+			}
+			l.rows = l.rows[:n]
+			l.top += drop
+		}
+	}
+	return nil
+}
+
+// downsample two rows to a row of half the width, rounded up. The bottom row
+// b is nil for the last row of an image with an odd height.
+func downsample(a, b []uint8) []uint8 {
+	var (
+		width = len(a) / 4
+		out   = make([]uint8, 4*((width+1)/2))
+	)
+	for x := 0; x < width; x += 2 {
+		for c := 0; c < 4; c++ {
+			sum, n := int(a[4*x+c]), 1
+			if x+1 < width {
+				sum += int(a[4*(x+1)+c])
+				n++
+			}
+			if b != nil {
+				sum += int(b[4*x+c])
+				n++
+				if x+1 < width {
+					sum += int(b[4*(x+1)+c])
+					n++
+				}
+			}
+			out[2*x+c] = uint8((sum + n/2) / n)
+		}
+	}
+	return out
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
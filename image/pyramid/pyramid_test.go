@@ -0,0 +1,181 @@
+package pyramid
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/format/vga"
+)
+
+// testFS keeps the files in memory.
+type testFS map[string]*bytes.Buffer
+
+func (fs testFS) Create(name string) (io.WriteCloser, error) {
+	b := new(bytes.Buffer)
+	fs[name] = b
+	return nopCloser{b}, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func (fs testFS) tile(t *testing.T, name string) image.Image {
+	t.Helper()
+	b, ok := fs[name]
+	if !ok {
+		t.Fatalf("missing tile %s", name)
+	}
+	m, err := png.Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatalf("tile %s: %v", name, err)
+	}
+	return m
+}
+
+func testImage(w, h int) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			m.Set(x, y, color.RGBA{uint8(x * 20), uint8(y * 20), 0x80, 0xff})
+		}
+	}
+	return m
+}
+
+func TestWriteDZI(t *testing.T) {
+	var (
+		fs  = make(testFS)
+		src = testImage(10, 7)
+	)
+	if err := Write(fs, "test", Image(src), &Options{TileSize: 4, Overlap: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	dzi := fs["test.dzi"].String()
+	if !strings.Contains(dzi, `Overlap="1" TileSize="4"`) || !strings.Contains(dzi, `<Size Width="10" Height="7"/>`) {
+		t.Errorf("unexpected descriptor:\n%s", dzi)
+	}
+
+	// Levels 0 (1x1) up to 4 (10x7), with 3x2 tiles at level 4
+	if n := len(fs); n != 1+1+1+1+2+6 {
+		t.Errorf("expected 12 files, got %d", n)
+	}
+	for _, test := range []struct {
+		Name string
+		Size image.Point
+	}{
+		{"test_files/0/0_0.png", image.Pt(1, 1)},
+		{"test_files/3/0_0.png", image.Pt(5, 4)},
+		{"test_files/3/1_0.png", image.Pt(2, 4)},
+		{"test_files/4/0_0.png", image.Pt(5, 5)},
+		{"test_files/4/1_0.png", image.Pt(6, 5)},
+		{"test_files/4/2_1.png", image.Pt(3, 4)},
+	} {
+		if size := fs.tile(t, test.Name).Bounds().Size(); size != test.Size {
+			t.Errorf("%s: expected size %s, got %s", test.Name, test.Size, size)
+		}
+	}
+
+	// Tile 1_1 at full resolution starts at (3, 3)
+	m := fs.tile(t, "test_files/4/1_1.png")
+	for y := 0; y < m.Bounds().Dy(); y++ {
+		for x := 0; x < m.Bounds().Dx(); x++ {
+			if got, want := color.RGBAModel.Convert(m.At(x, y)), src.At(3+x, 3+y); got != want {
+				t.Fatalf("pixel (%d, %d): expected %v, got %v", x, y, want, got)
+			}
+		}
+	}
+
+	// Level 3 averages 2x2 pixels
+	m = fs.tile(t, "test_files/3/0_0.png")
+	if got, want := color.RGBAModel.Convert(m.At(1, 1)), (color.RGBA{50, 50, 0x80, 0xff}); got != want {
+		t.Errorf("downsampled pixel: expected %v, got %v", want, got)
+	}
+}
+
+func TestWriteXYZ(t *testing.T) {
+	fs := make(testFS)
+	if err := Write(fs, "test", Image(testImage(10, 7)), &Options{Format: XYZ, TileSize: 4}); err != nil {
+		t.Fatal(err)
+	}
+	// Zoom 0 (3x2), 1 (5x4) and 2 (10x7)
+	if n := len(fs); n != 1+2+6 {
+		t.Errorf("expected 9 files, got %d", n)
+	}
+	for name := range fs {
+		if size := fs.tile(t, name).Bounds().Size(); size != image.Pt(4, 4) {
+			t.Errorf("%s: expected 4x4 tile, got %s", name, size)
+		}
+	}
+	if _, ok := fs["test/2/2/1.png"]; !ok {
+		t.Error("missing tile test/2/2/1.png")
+	}
+	m := fs.tile(t, "test/0/0/0.png")
+	if _, _, _, a := m.At(3, 0).RGBA(); a != 0 {
+		t.Error("expected transparent padding")
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	data := make([]byte, 256*4*4/8)
+	for char := 0; char < 256; char++ {
+		for i := 0; i < char%16; i++ {
+			bit := char*16 + i
+			data[bit>>3] |= 0x80 >> uint(bit&7)
+		}
+	}
+	var (
+		font = chargen.New(chargen.NewBytesMask(data, chargen.MaskOptions{Size: image.Pt(4, 4)}))
+		text = vga.NewText(7, 23)
+	)
+	for i := range text.Buffer {
+		text.Buffer[i] = vga.MakeCharacter(uint8(i), vga.Palette[i%16], vga.Palette[(i/16)%8])
+	}
+	im, err := text.Image(font, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Text(text, font, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		want = make(testFS)
+		got  = make(testFS)
+		opts = &Options{TileSize: 10, Overlap: 2}
+	)
+	if err = Write(want, "test", Image(im), opts); err != nil {
+		t.Fatal(err)
+	}
+	if err = Write(got, "test", src, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d files, got %d", len(want), len(got))
+	}
+	for name, b := range want {
+		if !bytes.Equal(got[name].Bytes(), b.Bytes()) {
+			t.Errorf("%s differs from the rendered image", name)
+		}
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	src := Image(testImage(2, 2))
+	if err := Write(make(testFS), "test", src, &Options{TileSize: 2, Overlap: 2}); err != ErrTileSize {
+		t.Errorf("expected %v, got %v", ErrTileSize, err)
+	}
+	if err := Write(make(testFS), "test", Image(image.NewRGBA(image.Rectangle{})), nil); err != ErrEmpty {
+		t.Errorf("expected %v, got %v", ErrEmpty, err)
+	}
+}
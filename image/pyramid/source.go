@@ -0,0 +1,99 @@
+package pyramid
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/format/vga"
+)
+
+// Source is an image of which regions can be rendered on demand.
+type Source interface {
+	// Bounds of the full resolution image.
+	Bounds() image.Rectangle
+
+	// Region renders (at least) the region r of the image.
+	Region(r image.Rectangle) (image.Image, error)
+}
+
+// Image is a Source for an image that is in memory, such as the result of a
+// parser.Image.
+func Image(m image.Image) Source {
+	return imageSource{m}
+}
+
+type imageSource struct {
+	image.Image
+}
+
+func (src imageSource) Region(r image.Rectangle) (image.Image, error) {
+	return src.Image, nil
+}
+
+// Text is a Source for a text buffer rendered with the specified chargen font;
+// only the cells in the requested regions are rendered.
+func Text(text *vga.Text, font *chargen.Font, blink bool) (Source, error) {
+	r, err := text.NewRenderer(font)
+	if err != nil {
+		return nil, err
+	}
+	return textSource{r: r, blink: blink}, nil
+}
+
+type textSource struct {
+	r     *vga.Renderer
+	blink bool
+}
+
+func (src textSource) Bounds() image.Rectangle {
+	return src.r.Bounds()
+}
+
+func (src textSource) Region(r image.Rectangle) (image.Image, error) {
+	var (
+		cell  = src.r.CellSize()
+		cells = image.Rect(
+			r.Min.X/cell.X, r.Min.Y/cell.Y,
+			(r.Max.X+cell.X-1)/cell.X, (r.Max.Y+cell.Y-1)/cell.Y,
+		)
+		im = image.NewPaletted(r, src.r.Palette())
+	)
+	src.r.Draw(im, image.Pt(cells.Min.X*cell.X, cells.Min.Y*cell.Y), cells, src.blink)
+	return im, nil
+}
+
+// readRow copies a row of pixels from m as premultiplied RGBA.
+func readRow(dst []uint8, m image.Image, x, y int, lut [][4]uint8) {
+	switch m := m.(type) {
+	case *image.RGBA:
+		copy(dst, m.Pix[m.PixOffset(x, y):])
+	case *image.Paletted:
+		for i, c := range m.Pix[m.PixOffset(x, y) : m.PixOffset(x, y)+len(dst)/4] {
+			copy(dst[i*4:], lut[c][:])
+		}
+	default:
+		for i := 0; i < len(dst)/4; i++ {
+			c := color.RGBAModel.Convert(m.At(x+i, y)).(color.RGBA)
+			dst[i*4+0], dst[i*4+1], dst[i*4+2], dst[i*4+3] = c.R, c.G, c.B, c.A
+		}
+	}
+}
+
+// paletteRGBA returns the premultiplied RGBA values of the palette of m, if m
+// is a paletted image.
+func paletteRGBA(m image.Image) [][4]uint8 {
+	p, ok := m.(*image.Paletted)
+	if !ok {
+		return nil
+	}
+	lut := make([][4]uint8, 256)
+	for i, c := range p.Palette {
+		if i == len(lut) {
+			break
+		}
+		v := color.RGBAModel.Convert(c).(color.RGBA)
+		lut[i] = [4]uint8{v.R, v.G, v.B, v.A}
+	}
+	return lut
+}
@@ -0,0 +1,195 @@
+package parser
+
+import (
+	"errors"
+	"image"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/textmodes/parser/format/sauce"
+)
+
+// ErrUnknownFormat is returned by Detect if no registered format matches.
+var ErrUnknownFormat = errors.New("parser: unknown format")
+
+// AnyFileType matches every SAUCE file type of a data type.
+const AnyFileType = -1
+
+// SAUCEType is a SAUCE data type and file type, see package sauce.
+type SAUCEType struct {
+	DataType uint8
+	FileType int
+}
+
+// Format is a file format with its decoder.
+type Format struct {
+	// Name of the format, in lower case.
+	Name string
+
+	// Extensions of the file name, including the dot.
+	Extensions []string
+
+	// Magic strings at the start of the file, "?" matches any byte.
+	Magic []string
+
+	// Match checks the start of the file, for formats that have no magic
+	// string but can be recognised by their content.
+	Match func(header []byte) bool
+
+	// SAUCE data types and file types of the format.
+	SAUCE []SAUCEType
+
+	// Decode the file.
+	Decode func(io.Reader) (Parser, error)
+}
+
+// Confidence of a detected format, the sum of the matching clues.
+type Confidence int
+
+// Confidence clues, from weak to strong. Content matches are guesses, so a
+// matching SAUCE type outranks them even without a matching extension.
+const (
+	ConfidenceExtension Confidence = 1 << iota
+	ConfidenceContent
+	ConfidenceSAUCE
+	ConfidenceMagic
+)
+
+// headerSize is the number of bytes at the start of the file used for
+// matching magic strings and content.
+const headerSize = 512
+
+var (
+	formatsMu sync.RWMutex
+	formats   []Format
+)
+
+// Register a format, typically called from the init function of the package
+// implementing the decoder. Registering a name twice replaces the format.
+func Register(format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for i, other := range formats {
+		if other.Name == format.Name {
+			formats[i] = format
+			return
+		}
+	}
+	formats = append(formats, format)
+}
+
+// Formats returns the registered formats, sorted by name.
+func Formats() []Format {
+	formatsMu.RLock()
+	list := make([]Format, len(formats))
+	copy(list, formats)
+	formatsMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Lookup a registered format by name.
+func Lookup(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for _, format := range formats {
+		if strings.EqualFold(format.Name, name) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// Detect the format of r, using the magic strings and content of the start of
+// the file, the SAUCE record and the extension of filename (which may be
+// empty). The format with the highest confidence wins; r is rewound to the
+// start.
+func Detect(r io.ReadSeeker, filename string) (Format, Confidence, error) {
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Format{}, 0, err
+	}
+	header = header[:n]
+
+	record, err := sauce.Parse(r)
+	if err != nil && err != sauce.ErrNoRecord && err != sauce.ErrShortRead {
+		return Format{}, 0, err
+	}
+	if _, err = r.Seek(0, io.SeekStart); err != nil {
+		return Format{}, 0, err
+	}
+
+	var (
+		ext  = strings.ToLower(filepath.Ext(filename))
+		best Format
+		high Confidence
+	)
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for _, format := range formats {
+		if c := format.confidence(header, record, ext); c > high {
+			best, high = format, c
+		}
+	}
+	if high == 0 {
+		return Format{}, 0, ErrUnknownFormat
+	}
+	return best, high, nil
+}
+
+func (format Format) confidence(header []byte, record *sauce.Record, ext string) (c Confidence) {
+	for _, magic := range format.Magic {
+		if matchMagic(magic, header) {
+			c |= ConfidenceMagic
+			break
+		}
+	}
+	if format.Match != nil && format.Match(header) {
+		c |= ConfidenceContent
+	}
+	if record != nil {
+		for _, t := range format.SAUCE {
+			if t.DataType == record.DataType && (t.FileType == AnyFileType || t.FileType == int(record.FileType)) {
+				c |= ConfidenceSAUCE
+				break
+			}
+		}
+	}
+	for _, other := range format.Extensions {
+		if ext != "" && strings.EqualFold(ext, other) {
+			c |= ConfidenceExtension
+			break
+		}
+	}
+	return
+}
+
+func matchMagic(magic string, b []byte) bool {
+	if len(magic) > len(b) {
+		return false
+	}
+	for i, c := range []byte(magic) {
+		if c != '?' && c != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// WrapImage returns an Image parser for a decoded image, for registering image
+// decoders.
+func WrapImage(im image.Image) Image {
+	return imageParser{im}
+}
+
+type imageParser struct {
+	im image.Image
+}
+
+func (p imageParser) Image() (image.Image, error) {
+	return p.im, nil
+}
@@ -0,0 +1,103 @@
+package parser_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/format/sauce"
+
+	_ "github.com/textmodes/parser/image/ilbm"
+	_ "github.com/textmodes/parser/image/pcx"
+	_ "github.com/textmodes/parser/text/ansi"
+	_ "github.com/textmodes/parser/text/binarytext"
+	_ "github.com/textmodes/parser/text/teletext"
+	_ "github.com/textmodes/parser/text/tundradraw"
+	_ "github.com/textmodes/parser/text/xbin"
+)
+
+func withSAUCE(b []byte, record *sauce.Record) []byte {
+	buf := bytes.NewBuffer(b)
+	buf.WriteByte(0x1a)
+	record.WriteTo(buf)
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	pcx := make([]byte, 128)
+	copy(pcx, "\x0a\x05\x01\x08\x00\x00\x00\x00\x0f\x00\x0f\x00")
+
+	for _, test := range []struct {
+		Name     string
+		Filename string
+		Data     []byte
+		Want     string
+		Score    parser.Confidence
+	}{
+		{"XBin", "test.xb", []byte("XBIN\x1a\x50\x00\x19\x00\x10\x00"), "xbin", parser.ConfidenceMagic | parser.ConfidenceExtension},
+		{"XBin without extension", "", []byte("XBIN\x1a\x50\x00\x19\x00\x10\x00"), "xbin", parser.ConfidenceMagic},
+		{"TundraDraw", "test.txt", []byte("\x18TUNDRA24"), "tundradraw", parser.ConfidenceMagic},
+		{"ILBM", "", []byte("FORM\x00\x00\x00\x04ILBM"), "ilbm", parser.ConfidenceMagic},
+		{"PCX", "", pcx, "pcx", parser.ConfidenceContent},
+		{"EP1", "", []byte("\xfe\x01\x09\x00\x00\x00"), "ep1", parser.ConfidenceMagic},
+		{"TTI", "", []byte("DE,Test page\r\nPN,10000\r\n"), "tti", parser.ConfidenceContent},
+		{"ANSi", "test.ANS", []byte("\x1b[0m"), "ansi", parser.ConfidenceExtension},
+		{
+			"ANSi SAUCE over content",
+			"test.ans",
+			withSAUCE([]byte("OK, here we go\r\n"), &sauce.Record{DataType: sauce.Character, FileType: sauce.ANSi}),
+			"ansi",
+			parser.ConfidenceSAUCE | parser.ConfidenceExtension,
+		},
+		{
+			"BinaryText SAUCE",
+			"test.dat",
+			withSAUCE(make([]byte, 160), &sauce.Record{DataType: sauce.BinaryText, FileType: 40}),
+			"binarytext",
+			parser.ConfidenceSAUCE,
+		},
+		{
+			"TundraDraw SAUCE over extension",
+			"test.ans",
+			withSAUCE([]byte("\x18TUNDRA24"), &sauce.Record{DataType: sauce.Character, FileType: sauce.TundraDraw}),
+			"tundradraw",
+			parser.ConfidenceMagic | parser.ConfidenceSAUCE,
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			r := bytes.NewReader(test.Data)
+			format, score, err := parser.Detect(r, test.Filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format.Name != test.Want || score != test.Score {
+				t.Fatalf("expected %s (%d), got %s (%d)", test.Want, test.Score, format.Name, score)
+			}
+			if r.Len() != len(test.Data) {
+				t.Fatal("expected reader to be rewound")
+			}
+		})
+	}
+
+	if _, _, err := parser.Detect(bytes.NewReader([]byte("hello")), "hello.txt"); err != parser.ErrUnknownFormat {
+		t.Fatalf("expected error %v, got %v", parser.ErrUnknownFormat, err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	format, ok := parser.Lookup("XBin")
+	if !ok {
+		t.Fatal("expected xbin to be registered")
+	}
+	if format.Decode == nil {
+		t.Fatal("expected xbin decoder")
+	}
+	if _, ok = parser.Lookup("gif"); ok {
+		t.Fatal("expected gif not to be registered")
+	}
+	for i, format := range parser.Formats()[1:] {
+		if prev := parser.Formats()[i]; prev.Name >= format.Name {
+			t.Fatalf("expected formats sorted by name, got %s before %s", prev.Name, format.Name)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"image/color"
+
+	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+)
+
+// TextBuffer is a parsed text mode file backed by a VGA text buffer, such as
+// an ANSi, BinaryText, XBin or TundraDraw.
+type TextBuffer interface {
+	Parser
+
+	// VGAText returns the text buffer.
+	VGAText() *vga.Text
+
+	// TextFont returns the font used to render the buffer and its SAUCE font
+	// name, the name is empty for fonts embedded in the file.
+	TextFont() (font *chargen.Font, name string)
+
+	// TextPalette returns the palette of the buffer.
+	TextPalette() color.Palette
+
+	// SAUCERecord returns the SAUCE record (may be nil).
+	SAUCERecord() *sauce.Record
+}
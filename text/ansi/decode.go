@@ -5,9 +5,11 @@ import (
 	"bytes"
 	"image/color"
 	"io"
+	"io/ioutil"
 
 	"github.com/textmodes/parser"
 	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/format/sauce"
 	"github.com/textmodes/parser/format/vga"
 )
 
@@ -18,6 +20,9 @@ type Decoder struct {
 	// Font for Image() and Scroller()
 	Font *chargen.Font
 
+	// Record is the SAUCE record found while decoding (may be nil).
+	Record *sauce.Record
+
 	// progressFunc will be called when generating a Scoller.
 	progressFunc func(float64)
 }
@@ -29,6 +34,63 @@ func NewDecoder() *Decoder {
 	}
 }
 
+// Decode an ANSi from reader r into an auto expanding buffer. If r is an
+// io.ReadSeeker, the buffer width is taken from the SAUCE record. The font is
+// the SAUCE font, or IBM VGA if the record has none.
+func Decode(r io.Reader) (*Decoder, error) {
+	decoder := NewDecoder()
+	decoder.AutoExpand = true
+	if s, ok := r.(io.ReadSeeker); ok {
+		record, err := sauce.Parse(s)
+		if err != nil && err != sauce.ErrNoRecord && err != sauce.ErrShortRead {
+			return nil, err
+		}
+		if _, err = s.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if record != nil && record.DataType == sauce.Character && record.FileType <= sauce.ANSiMation && record.TypeInfo[0] > 0 {
+			height := uint(record.TypeInfo[1])
+			if height == 0 {
+				height = 25
+			}
+			decoder.Resize(uint(record.TypeInfo[0]), height)
+		}
+	}
+	if err := decoder.Decode(r); err != nil {
+		return nil, err
+	}
+
+	var info string
+	if decoder.Record != nil && decoder.Record.DataType == sauce.Character {
+		info = decoder.Record.Info
+	}
+	font, err := sauce.Font(info)
+	if err != nil {
+		if font, err = sauce.Font(""); err != nil {
+			return nil, err
+		}
+	}
+	decoder.Font = font
+	return decoder, nil
+}
+
+// VGAText returns the text buffer.
+func (decoder *Decoder) VGAText() *vga.Text { return decoder.Text }
+
+// TextFont returns the font and its SAUCE font name.
+func (decoder *Decoder) TextFont() (*chargen.Font, string) {
+	if decoder.Record != nil && decoder.Record.DataType == sauce.Character && decoder.Record.Info != "" {
+		return decoder.Font, decoder.Record.Info
+	}
+	return decoder.Font, "IBM VGA"
+}
+
+// TextPalette returns the palette of the text buffer.
+func (decoder *Decoder) TextPalette() color.Palette { return decoder.ActivePalette() }
+
+// SAUCERecord returns the SAUCE record (may be nil).
+func (decoder *Decoder) SAUCERecord() *sauce.Record { return decoder.Record }
+
 // Decode an ANSi
 func (decoder *Decoder) Decode(r io.Reader) error {
 	var (
@@ -82,7 +144,7 @@ func (decoder *Decoder) Decode(r io.Reader) error {
 			}
 			if bytes.Equal(peek, []byte("SAUCE00")) {
 				// SAUCE record next, done parsing
-				return nil
+				return decoder.decodeRecord(br)
 			}
 			tracef("SUB peek: %q (%d)", peek, len(peek))
 			decoder.WriteCharacter(b)
@@ -97,6 +159,24 @@ func (decoder *Decoder) Decode(r io.Reader) error {
 	}
 }
 
+func (decoder *Decoder) decodeRecord(r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if decoder.Record, err = sauce.ParseBytes(b); err != nil {
+		// Garbage after SUB is ignored
+		debugf("SAUCE record: %v", err)
+		decoder.Record = nil
+		return nil
+	}
+	decoder.NineDot = decoder.Record.NineDot()
+	if flags := decoder.Record.Flags; flags != nil && flags.NonBlink {
+		decoder.DisableBlink = true
+	}
+	return nil
+}
+
 func (decoder *Decoder) backspace() {
 	decoder.Move(-1, 0)
 	decoder.WriteCharacter(' ')
@@ -221,10 +301,12 @@ func (decoder *Decoder) processCSISequence(r *bufio.Reader) (err error) {
 		tracef("read: %q", b)
 	}
 
-	// Read numeric sequence
+	// Read numeric sequence, sub parameters are separated by colons
 	var (
-		args = make([]int, 0, 32)
-		n    int
+		args  = make([]int, 0, 32)
+		colon = make([]bool, 0, 32)
+		sep   byte
+		n     int
 	)
 	for b >= ' ' && b < '@' {
 		n = 0
@@ -237,7 +319,9 @@ func (decoder *Decoder) processCSISequence(r *bufio.Reader) (err error) {
 		}
 		if len(args) < cap(args) {
 			args = append(args, n)
+			colon = append(colon, sep == ':')
 		}
+		sep = b
 
 		debugf("process CSR %v %c", args, b)
 		switch {
@@ -309,7 +393,7 @@ func (decoder *Decoder) processCSISequence(r *bufio.Reader) (err error) {
 	case 'K':
 		decoder.eraseLine(defaultInt(args, 0))
 	case 'm':
-		decoder.processSGRMode(args)
+		decoder.processSGRMode(sgrParams(args, colon))
 	case 't':
 		decoder.processCustomMode(args)
 	case 'r': // Set Scrolling Region [top;bottom] (default = full size of window)
@@ -341,9 +425,31 @@ func (decoder *Decoder) processOSCSequence(r *bufio.Reader) (err error) {
 	return
 }
 
-func (decoder *Decoder) processSGRMode(args []int) {
-	for i, l := 0, len(args); i < l; i++ {
-		switch args[i] {
+// sgrParam is a SGR parameter, followed by its colon separated sub
+// parameters (ISO-8613-6).
+type sgrParam []int
+
+// sgrParams groups the parameters with their sub parameters.
+func sgrParams(args []int, colon []bool) []sgrParam {
+	params := make([]sgrParam, 0, len(args))
+	for i, v := range args {
+		if colon[i] && len(params) > 0 {
+			params[len(params)-1] = append(params[len(params)-1], v)
+		} else {
+			params = append(params, sgrParam{v})
+		}
+	}
+	return params
+}
+
+func (decoder *Decoder) processSGRMode(params []sgrParam) {
+	if len(params) == 0 {
+		// ESC[m is ESC[0m
+		params = []sgrParam{{0}}
+	}
+	for i, l := 0, len(params); i < l; i++ {
+		param := params[i]
+		switch param[0] {
 		case 0: // reset
 			decoder.ResetAttributes()
 		case 1: // bold
@@ -353,7 +459,18 @@ func (decoder *Decoder) processSGRMode(args []int) {
 		case 3: // standout
 			decoder.SetAttribute(vga.Standout)
 		case 4: // underline
-			decoder.SetAttribute(vga.Underline)
+			decoder.ClearAttribute(vga.Underline | vga.DoubleUnderline)
+			if len(param) < 2 {
+				decoder.SetAttribute(vga.Underline)
+				break
+			}
+			switch param[1] {
+			case 0: // no underline
+			case 2: // double underline
+				decoder.SetAttribute(vga.DoubleUnderline)
+			default: // single, curly, dotted or dashed underline
+				decoder.SetAttribute(vga.Underline)
+			}
 		case 5, 6: // blink
 			decoder.SetAttribute(vga.Blink)
 		case 7: // reverse
@@ -362,12 +479,15 @@ func (decoder *Decoder) processSGRMode(args []int) {
 			decoder.SetAttribute(vga.Conceal)
 		case 9: // crossed out
 			decoder.SetAttribute(vga.CrossedOut)
-		case 22: // normal
-			decoder.ClearAttributes()
+		case 21: // double underline
+			decoder.ClearAttribute(vga.Underline)
+			decoder.SetAttribute(vga.DoubleUnderline)
+		case 22: // normal intensity
+			decoder.ClearAttribute(vga.Bold | vga.Faint)
 		case 23: // not standout
 			decoder.ClearAttribute(vga.Standout)
 		case 24: // not underline
-			decoder.ClearAttribute(vga.Underline)
+			decoder.ClearAttribute(vga.Underline | vga.DoubleUnderline)
 		case 25: // not blink
 			decoder.ClearAttribute(vga.Blink)
 		case 27: // not reverse
@@ -377,36 +497,52 @@ func (decoder *Decoder) processSGRMode(args []int) {
 		case 29: // not crossed out
 			decoder.ClearAttribute(vga.CrossedOut)
 		case 30, 31, 32, 33, 34, 35, 36, 37:
-			decoder.SetForegroundColor(vga.Palette[args[i]-30])
+			decoder.SetForegroundIndex(uint8(param[0] - 30))
 		case 38: // color mode
-			skip, c, ok := processSGRModeColor(args[i:])
+			skip, c, ok := processSGRModeParamColor(params[i:])
 			if ok {
-				decoder.SetForegroundColor(c)
+				decoder.setForeground(c)
 			}
 			tracef("skip %d (%d -> %d)", skip, i, i+skip)
 			i += skip
 		case 39: // default foreground
 			if i == 2 {
 				// 256-color mode
-				decoder.SetForegroundColor(vga.Palette[args[1]])
+				decoder.SetForegroundIndex(uint8(params[1][0]))
 			} else {
-				decoder.SetForegroundColor(vga.White)
+				decoder.SetForegroundIndex(7)
 			}
 		case 40, 41, 42, 43, 44, 45, 46, 47:
-			decoder.SetBackgroundColor(vga.Palette[args[i]-40])
+			decoder.SetBackgroundIndex(uint8(param[0] - 40))
 		case 48: // color mode
-			skip, c, ok := processSGRModeColor(args[i:])
+			skip, c, ok := processSGRModeParamColor(params[i:])
 			if ok {
-				decoder.SetBackgroundColor(c)
+				decoder.setBackground(c)
 			}
 			i += skip
 		case 49: // default background
 			if i == 2 {
 				// 256-color mode
-				decoder.SetBackgroundColor(vga.Palette[args[1]])
+				decoder.SetBackgroundIndex(uint8(params[1][0]))
 			} else {
-				decoder.SetBackgroundColor(vga.Black)
+				decoder.SetBackgroundIndex(0)
+			}
+		case 53: // overline
+			decoder.SetAttribute(vga.Overline)
+		case 55: // not overline
+			decoder.ClearAttribute(vga.Overline)
+		case 58: // underline color
+			skip, c, ok := processSGRModeParamColor(params[i:])
+			if ok {
+				decoder.SetUnderlineColor(c)
 			}
+			i += skip
+		case 59: // default underline color
+			decoder.ResetUnderlineColor()
+		case 90, 91, 92, 93, 94, 95, 96, 97: // aixterm bright foreground
+			decoder.SetForegroundIndex(uint8(param[0] - 90 + 8))
+		case 100, 101, 102, 103, 104, 105, 106, 107: // aixterm bright background
+			decoder.SetBackgroundIndex(uint8(param[0] - 100 + 8))
 		}
 	}
 }
@@ -422,13 +558,76 @@ func (decoder *Decoder) processCustomMode(args []int) {
 	}
 }
 
+// paletteIndex is a color by index in the palette.
+type paletteIndex uint8
+
+func (i paletteIndex) RGBA() (r, g, b, a uint32) {
+	return vga.Palette[i].RGBA()
+}
+
+// setForeground sets the foreground color, by palette index if possible.
+func (decoder *Decoder) setForeground(c color.Color) {
+	if i, ok := c.(paletteIndex); ok {
+		decoder.SetForegroundIndex(uint8(i))
+	} else {
+		decoder.SetForegroundColor(c)
+	}
+}
+
+// setBackground sets the background color, by palette index if possible.
+func (decoder *Decoder) setBackground(c color.Color) {
+	if i, ok := c.(paletteIndex); ok {
+		decoder.SetBackgroundIndex(uint8(i))
+	} else {
+		decoder.SetBackgroundColor(c)
+	}
+}
+
+// processSGRModeParamColor processes an extended color parameter, the color
+// is either in the sub parameters (38:2::r:g:b) or in the parameters that
+// follow (38;2;r;g;b).
+func processSGRModeParamColor(params []sgrParam) (skip int, c color.Color, ok bool) {
+	if len(params[0]) > 1 {
+		c, ok = processSGRModeSubColor(params[0][1:])
+		return 0, c, ok
+	}
+	args := make([]int, len(params))
+	for i, param := range params {
+		args[i] = param[0]
+	}
+	return processSGRModeColor(args)
+}
+
+// processSGRModeSubColor processes the sub parameters of an extended color
+// parameter: 5:index, or 2:r:g:b with an optional color space ID before the
+// color components.
+func processSGRModeSubColor(args []int) (c color.Color, ok bool) {
+	switch args[0] {
+	case 2: // 24-bit color mode
+		args = args[1:]
+		if len(args) > 3 {
+			args = args[1:] // color space ID
+		}
+		if len(args) < 3 {
+			return nil, false
+		}
+		return &color.RGBA{uint8(args[0]), uint8(args[1]), uint8(args[2]), 0xff}, true
+	case 5: // 256 color mode
+		if len(args) < 2 || args[1] >= len(vga.Palette) {
+			return nil, false
+		}
+		return paletteIndex(args[1]), true
+	}
+	return nil, false
+}
+
 func processSGRModeColor(args []int) (skip int, c color.Color, ok bool) {
 	tracef("process SGR mode color %v", args)
 	if len(args) < 2 {
 		return
 	}
 	switch args[0] {
-	case 38, 48:
+	case 38, 48, 58:
 		args = args[1:]
 		switch args[0] {
 		case 2: // 24-bit color mode
@@ -450,7 +649,7 @@ func processSGRModeColor(args []int) (skip int, c color.Color, ok bool) {
 				return
 			}
 			tracef("VGA color %d", args[0])
-			return 2, vga.Palette[args[0]], true
+			return 2, paletteIndex(args[0]), true
 		}
 	}
 	// unknown mode
@@ -482,4 +681,5 @@ var (
 	_ parser.Image          = (*Decoder)(nil)
 	_ parser.Animation      = (*Decoder)(nil)
 	_ parser.AnimationDelay = (*Decoder)(nil)
+	_ parser.TextBuffer     = (*Decoder)(nil)
 )
@@ -34,19 +34,16 @@ func (decoder *Decoder) AnimateDelay(delay time.Duration) (*gif.GIF, error) {
 		err error
 		d   = int(delay / (time.Second / 100))
 	)
-	if src[0], err = decoder.Text.Image(decoder.Font, false); err != nil {
-		return nil, err
-	}
 	if decoder.Text.DisableBlink {
 		// Oh guess what, we're not even blinking...
+		if src[0], err = decoder.Text.Image(decoder.Font, false); err != nil {
+			return nil, err
+		}
 		return &gif.GIF{
 			Image: src[:1],
 		}, nil
 	}
-	// TODO(maze): we can optimize a lot here, by only drawing the glyphs that
-	//             didn't draw (because they're blinking) in the first pass over
-	//             the second image
-	if src[1], err = decoder.Text.Image(decoder.Font, true); err != nil {
+	if src[0], src[1], err = decoder.Text.BlinkFrames(decoder.Font); err != nil {
 		return nil, err
 	}
 	return &gif.GIF{
@@ -68,10 +65,7 @@ func (decoder *Decoder) ScrollerDelay(delay time.Duration) (*gif.GIF, error) {
 		src [2]*image.Paletted
 		err error
 	)
-	if src[0], err = decoder.Text.Image(decoder.Font, false); err != nil {
-		return nil, err
-	}
-	if src[1], err = decoder.Text.Image(decoder.Font, true); err != nil {
+	if src[0], src[1], err = decoder.Text.BlinkFrames(decoder.Font); err != nil {
 		return nil, err
 	}
 
@@ -1,6 +1,7 @@
 package ansi
 
 import (
+	"bytes"
 	"image/gif"
 	"image/png"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
 )
 
 func TestDecode(t *testing.T) {
@@ -138,3 +140,136 @@ func TestDelay(t *testing.T) {
 		t.Fatalf("expected %s in 100ths of a second to be 40, got %d", delay, d)
 	}
 }
+
+func TestDecodeRecord(t *testing.T) {
+	record := &sauce.Record{
+		DataType: sauce.Character,
+		FileType: sauce.ANSi,
+		TypeInfo: [4]uint16{80, 1},
+		Flags:    &sauce.ANSiFlags{LetterSpacing: sauce.LetterSpacing9Pixel},
+		Info:     "IBM VGA",
+	}
+	d := NewDecoder()
+	if err := d.Decode(bytes.NewReader(append([]byte("test\x1a"), record.Bytes()...))); err != nil {
+		t.Fatal(err)
+	}
+	if d.Record == nil || d.Record.Info != "IBM VGA" {
+		t.Fatalf("expected SAUCE record, got %+v", d.Record)
+	}
+	if !d.NineDot {
+		t.Fatal("expected 9 dot rendering")
+	}
+
+	var err error
+	if d.Font, err = d.Record.Font(); err != nil {
+		t.Fatal(err)
+	}
+	i, err := d.Image()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := i.Bounds().Dx(); w != 80*9 {
+		t.Fatalf("expected image width %d, got %d", 80*9, w)
+	}
+}
+
+func TestDecodeFunc(t *testing.T) {
+	record := &sauce.Record{
+		DataType: sauce.Character,
+		FileType: sauce.ANSi,
+		TypeInfo: [4]uint16{132, 1},
+		Info:     "IBM VGA50",
+	}
+	d, err := Decode(bytes.NewReader(append([]byte("test\x1a"), record.Bytes()...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := d.Width(); w != 132 {
+		t.Fatalf("expected width 132 from SAUCE, got %d", w)
+	}
+	if d.Font == nil || d.Font.Mask.CharacterSize().Y != 8 {
+		t.Fatalf("expected 8x8 VGA50 font, got %v", d.Font)
+	}
+}
+
+func TestDecodeSGR(t *testing.T) {
+	for _, test := range []struct {
+		Input     string
+		Attr      vga.Attribute
+		FG, BG    vga.RGB
+		Underline vga.RGB // zero for the foreground color
+	}{
+		{"\x1b[1;2mx", vga.Bold | vga.Faint, vga.White, vga.Black, 0},
+		{"\x1b[1;2;22mx", 0, vga.White, vga.Black, 0},
+		{"\x1b[1;4;22mx", vga.Underline, vga.White, vga.Black, 0},
+		{"\x1b[4;21mx", vga.DoubleUnderline, vga.White, vga.Black, 0},
+		{"\x1b[4:2mx", vga.DoubleUnderline, vga.White, vga.Black, 0},
+		{"\x1b[4:3mx", vga.Underline, vga.White, vga.Black, 0},
+		{"\x1b[21;4:0mx", 0, vga.White, vga.Black, 0},
+		{"\x1b[53mx", vga.Overline, vga.White, vga.Black, 0},
+		{"\x1b[53;55mx", 0, vga.White, vga.Black, 0},
+		{"\x1b[4;58;5;9mx", vga.Underline, vga.White, vga.Black, vga.BrightRed},
+		{"\x1b[4;58:2::1:2:3mx", vga.Underline, vga.White, vga.Black, vga.NewRGB(1, 2, 3)},
+		{"\x1b[4;58:2:1:2:3;59mx", vga.Underline, vga.White, vga.Black, 0},
+		{"\x1b[91;104mx", 0, vga.BrightRed, vga.BrightBlue, 0},
+		{"\x1b[38:2::10:20:30;48:5:2mx", 0, vga.NewRGB(10, 20, 30), vga.Green, 0},
+		{"\x1b[38;2;10;20;30;48;5;2mx", 0, vga.NewRGB(10, 20, 30), vga.Green, 0},
+		{"\x1b[38:5:3;1mx", vga.Bold, vga.Yellow, vga.Black, 0},
+		{"\x1b[1;31m\x1b[mx", 0, vga.White, vga.Black, 0},
+	} {
+		d := NewDecoder()
+		if err := d.Decode(bytes.NewReader([]byte(test.Input))); err != nil {
+			t.Fatal(err)
+		}
+		char := d.Buffer[0]
+		if char.CodePoint() != 'x' {
+			t.Errorf("%q: expected x, got %q", test.Input, char.CodePoint())
+			continue
+		}
+		if attr := char.Attributes(); attr != test.Attr {
+			t.Errorf("%q: expected attributes %s, got %s", test.Input, test.Attr, attr)
+		}
+		if fg := vga.ToRGB(char.ForegroundColor()); fg != test.FG {
+			t.Errorf("%q: expected foreground %s, got %s", test.Input, test.FG, fg)
+		}
+		if bg := vga.ToRGB(char.BackgroundColor()); bg != test.BG {
+			t.Errorf("%q: expected background %s, got %s", test.Input, test.BG, bg)
+		}
+		c, ok := char.UnderlineColor()
+		if ok != (test.Underline != 0) || (ok && vga.ToRGB(c) != test.Underline) {
+			t.Errorf("%q: expected underline color %s, got %v (%t)", test.Input, test.Underline, c, ok)
+		}
+	}
+}
+
+func TestDecodeSGRIndex(t *testing.T) {
+	for _, test := range []struct {
+		Input  string
+		FG, BG int // -1 for RGB colors
+	}{
+		{"x", 7, 0},
+		{"\x1b[31;104mx", 1, 12},
+		{"\x1b[38;5;200;48:5:17mx", 200, 17},
+		{"\x1b[38;2;1;2;3;39mx", 7, 0},
+		{"\x1b[38;2;1;2;3;48:2::4:5:6mx", -1, -1},
+	} {
+		d := NewDecoder()
+		if err := d.Decode(bytes.NewReader([]byte(test.Input))); err != nil {
+			t.Fatal(err)
+		}
+		char := d.Buffer[0]
+		for _, c := range []struct {
+			Name string
+			Want int
+			Get  func() (uint8, bool)
+		}{
+			{"foreground", test.FG, char.ForegroundIndex},
+			{"background", test.BG, char.BackgroundIndex},
+		} {
+			i, ok := c.Get()
+			if (c.Want == -1 && ok) || (c.Want != -1 && (!ok || int(i) != c.Want)) {
+				t.Errorf("%q: expected %s index %d, got %d (%t)", test.Input, c.Name, c.Want, i, ok)
+			}
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package ansi
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+)
+
+// sgrAttributes are the SGR parameters for the attributes, in output order.
+var sgrAttributes = []struct {
+	vga.Attribute
+	Param string
+}{
+	{vga.Bold, "1"},
+	{vga.Faint, "2"},
+	{vga.Standout, "3"},
+	{vga.Underline, "4"},
+	{vga.Blink, "5"},
+	{vga.Reverse, "7"},
+	{vga.Conceal, "8"},
+	{vga.CrossedOut, "9"},
+	{vga.DoubleUnderline, "21"},
+	{vga.Overline, "53"},
+}
+
+// sgrState is the graphic rendition of a cell, as SGR parameters.
+type sgrState struct {
+	attr       vga.Attribute
+	fg, bg, ul string
+}
+
+// sgrReset is the state after SGR 0.
+var sgrReset = sgrState{fg: "37", bg: "40"}
+
+/*
+Encode writes text as ANSi, followed by a SAUCE record based on record if it
+is not nil. The SAUCE record carries the size of the buffer, iCE colors and
+letter spacing; the title, author, group, font name and other flags are
+copied from record.
+
+Palette colors 0-15 are written as classic colors, using bold for bright
+foreground colors and blink for bright background colors. Other colors are
+written as 256 color palette indexes or 24-bit colors. Glyphs that can not be
+written in an ANSi, see Unencodable, are replaced by spaces.
+*/
+func Encode(w io.Writer, text *vga.Text, record *sauce.Record) error {
+	var (
+		b       = new(bytes.Buffer)
+		palette = text.Palette
+		width   = text.Width()
+		state   = sgrReset
+	)
+	if palette == nil {
+		palette = vga.Palette
+	}
+
+	b.WriteString("\x1b[0m")
+	for y := 0; y < text.Height(); y++ {
+		line := text.Buffer[y*width : (y+1)*width]
+
+		// Trailing blanks are left to the cleared buffer
+		end := len(line)
+		for end > 0 && isBlank(cellState(line[end-1], palette), line[end-1]) {
+			end--
+		}
+
+		for _, char := range line[:end] {
+			next := cellState(char, palette)
+			writeSGR(b, state, next)
+			state = next
+
+			if code := char.CodePoint(); encodable(code) {
+				b.WriteByte(code)
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+
+		// Full lines wrap, so the next line starts without a line break
+		if end < width && y < text.Height()-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	writeSGR(b, state, sgrReset)
+
+	size := b.Len()
+	if record != nil {
+		b.WriteByte(SUB)
+		b.Write(Record(text, record, size).Bytes())
+	}
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// Record returns a SAUCE record for an ANSi of size bytes, based on record.
+func Record(text *vga.Text, record *sauce.Record, size int) *sauce.Record {
+	out := *record
+	out.DataType = sauce.Character
+	out.FileType = sauce.ANSi
+	out.FileSize = uint32(size)
+	out.TypeInfo = [4]uint16{uint16(text.Width()), uint16(text.Height())}
+	out.Flags = new(sauce.ANSiFlags)
+	if record.Flags != nil {
+		*out.Flags = *record.Flags
+	}
+	out.Flags.NonBlink = text.DisableBlink
+	if text.NineDot {
+		out.Flags.LetterSpacing = sauce.LetterSpacing9Pixel
+	} else if out.Flags.LetterSpacing == sauce.LetterSpacing9Pixel {
+		out.Flags.LetterSpacing = sauce.LetterSpacing8Pixel
+	}
+	out.RawFlags = out.Flags.Byte()
+	if out.Date.IsZero() {
+		out.Date = time.Now()
+	}
+	return &out
+}
+
+// Unencodable counts the cells with glyphs that can not be written in an
+// ANSi because the Decoder interprets them as control characters. Glyphs
+// beyond the first 256 are not counted, they are written modulo 256.
+func Unencodable(text *vga.Text) (n int) {
+	for _, char := range text.Buffer {
+		if !encodable(char.CodePoint()) {
+			n++
+		}
+	}
+	return
+}
+
+// encodable checks if a glyph is not interpreted as a control character by
+// the Decoder.
+func encodable(glyph byte) bool {
+	switch glyph {
+	case BS, TAB, LF, VT, FF, CR, SUB, ESC:
+		return false
+	default:
+		return true
+	}
+}
+
+// cellState returns the graphic rendition for a character.
+func cellState(char vga.Character, palette color.Palette) sgrState {
+	var (
+		state = sgrState{attr: char.Attributes()}
+		fi    = colorIndex(char.ForegroundColor(), palette)
+		bi    = colorIndex(char.BackgroundColor(), palette)
+	)
+	if i, ok := char.ForegroundIndex(); ok {
+		fi = int(i)
+	}
+	if i, ok := char.BackgroundIndex(); ok {
+		bi = int(i)
+	}
+
+	// Bold and blink brighten the colors after reversing them, so reversed
+	// cells use palette indexes for bright colors
+	reverse := state.attr&vga.Reverse != 0
+	switch {
+	case fi >= 8 && fi < 16 && !reverse:
+		state.attr |= vga.Bold
+		state.fg = strconv.Itoa(30 + fi - 8)
+	case fi >= 0 && fi < 8:
+		state.fg = strconv.Itoa(30 + fi)
+	default:
+		state.fg = colorParam(38, char.ForegroundColor(), fi)
+	}
+	switch {
+	case bi >= 8 && bi < 16 && !reverse:
+		state.attr |= vga.Blink
+		state.bg = strconv.Itoa(40 + bi - 8)
+	case bi >= 0 && bi < 8:
+		state.bg = strconv.Itoa(40 + bi)
+	default:
+		state.bg = colorParam(48, char.BackgroundColor(), bi)
+	}
+	if c, ok := char.UnderlineColor(); ok {
+		state.ul = colorParam(58, c, -1)
+	}
+	return state
+}
+
+// colorIndex returns the index of c in the first 16 colors of the palette, or
+// -1 if it is not in there.
+func colorIndex(c color.Color, palette color.Palette) int {
+	if len(palette) > 16 {
+		palette = palette[:16]
+	}
+	return vga.ColorIndex(c, palette)
+}
+
+// colorParam returns the extended color parameter for 256 color index i, or
+// for the 24-bit color c if i is not set.
+func colorParam(param int, c color.Color, i int) string {
+	if i >= 0 && i < 256 {
+		return fmt.Sprintf("%d;5;%d", param, i)
+	}
+	rgb := vga.ToRGB(c)
+	return fmt.Sprintf("%d;2;%d;%d;%d", param, uint8(rgb>>16), uint8(rgb>>8), uint8(rgb))
+}
+
+// isBlank checks if a character shows nothing but the background of a cleared
+// buffer.
+func isBlank(state sgrState, char vga.Character) bool {
+	switch char.Glyph() {
+	case 0x00, ' ', 0xff:
+	default:
+		return false
+	}
+	visible := vga.Underline | vga.DoubleUnderline | vga.Overline | vga.CrossedOut | vga.Reverse | vga.Blink
+	return state.attr&visible == 0 && state.bg == sgrReset.bg
+}
+
+// writeSGR writes the SGR sequence to change from the current to the next
+// state, resetting first if attributes need to be cleared.
+func writeSGR(w *bytes.Buffer, current, next sgrState) {
+	var params []string
+	if current.attr&^next.attr != 0 || (current.ul != "" && next.ul == "") {
+		params = append(params, "0")
+		current = sgrReset
+	}
+	for _, a := range sgrAttributes {
+		if next.attr&a.Attribute != 0 && current.attr&a.Attribute == 0 {
+			params = append(params, a.Param)
+		}
+	}
+	if next.fg != current.fg {
+		params = append(params, next.fg)
+	}
+	if next.bg != current.bg {
+		params = append(params, next.bg)
+	}
+	if next.ul != current.ul {
+		params = append(params, next.ul)
+	}
+	if len(params) > 0 {
+		fmt.Fprintf(w, "\x1b[%sm", strings.Join(params, ";"))
+	}
+}
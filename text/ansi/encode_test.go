@@ -0,0 +1,162 @@
+package ansi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+)
+
+func TestEncode(t *testing.T) {
+	for _, test := range []struct {
+		Name  string
+		Cells []vga.Character
+		Width int
+		Want  string
+	}{
+		{
+			"plain",
+			[]vga.Character{
+				vga.MakeIndexedCharacter('h', 7, 0), vga.MakeIndexedCharacter('i', 7, 0), vga.BlankCharacter,
+				vga.BlankCharacter, vga.BlankCharacter, vga.BlankCharacter,
+			},
+			3,
+			"\x1b[0mhi\r\n",
+		},
+		{
+			"colors",
+			[]vga.Character{
+				vga.MakeIndexedCharacter('a', 1, 0), vga.MakeIndexedCharacter('b', 9, 0),
+				vga.MakeIndexedCharacter('c', 9, 12), vga.MakeIndexedCharacter('d', 7, 0),
+			},
+			4,
+			"\x1b[0m\x1b[31ma\x1b[1mb\x1b[5;44mc\x1b[0md",
+		},
+		{
+			"extended",
+			[]vga.Character{
+				vga.MakeIndexedCharacter('x', 196, 0),
+				vga.MakeCharacter('y', vga.NewRGB(1, 2, 3), vga.Palette[0]),
+			},
+			2,
+			"\x1b[0m\x1b[38;5;196mx\x1b[38;2;1;2;3my\x1b[37m",
+		},
+		{
+			"control",
+			[]vga.Character{vga.MakeIndexedCharacter(ESC, 7, 0), vga.MakeIndexedCharacter('!', 7, 0)},
+			2,
+			"\x1b[0m !",
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			text := vga.NewText(uint(test.Width), uint(len(test.Cells)/test.Width))
+			copy(text.Buffer, test.Cells)
+			var b bytes.Buffer
+			if err := Encode(&b, text, nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != test.Want {
+				t.Fatalf("expected %q, got %q", test.Want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	text := vga.NewText(10, 3)
+	text.DisableBlink = true
+	text.NineDot = true
+	for i := range text.Buffer {
+		char := vga.MakeIndexedCharacter('A'+uint8(i), uint8(i)%16, uint8(i/3)%16)
+		switch i % 5 {
+		case 1:
+			char.SetAttribute(vga.Underline)
+		case 2:
+			char.SetAttribute(vga.Reverse | vga.CrossedOut)
+		}
+		text.Buffer[i] = char
+	}
+	text.Buffer[11] = vga.MakeCharacter(0xdb, vga.NewRGB(0x12, 0x34, 0x56), vga.NewRGB(0x65, 0x43, 0x21))
+	text.Buffer[29] = vga.BlankCharacter
+
+	var b bytes.Buffer
+	if err := Encode(&b, text, &sauce.Record{Title: "round trip", Info: "IBM VGA"}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Record == nil || d.Record.Title != "round trip" || d.Record.TypeInfo[0] != 10 {
+		t.Fatalf("expected SAUCE record, got %+v", d.Record)
+	}
+	if !d.DisableBlink || !d.NineDot {
+		t.Fatalf("expected iCE colors and 9 dot rendering, got %t and %t", d.DisableBlink, d.NineDot)
+	}
+	if d.Width() != text.Width() || d.Height() != text.Height() {
+		t.Fatalf("expected %dx%d, got %dx%d", text.Width(), text.Height(), d.Width(), d.Height())
+	}
+	mask := ^(vga.Bold | vga.Blink)
+	for i, want := range text.Buffer {
+		got := d.Buffer[i]
+		wantFG, wantBG := renderColors(text, want)
+		gotFG, gotBG := renderColors(d.Text, got)
+		if got.Glyph() != want.Glyph() ||
+			got.Attributes()&mask != want.Attributes()&mask ||
+			gotFG != wantFG || gotBG != wantBG {
+			t.Fatalf("cell %d: expected %q %s %v/%v, got %q %s %v/%v", i,
+				want.Glyph(), want.Attributes(), wantFG, wantBG,
+				got.Glyph(), got.Attributes(), gotFG, gotBG)
+		}
+	}
+}
+
+// renderColors returns the colors of a cell as rendered, with bold and blink
+// brightening the colors.
+func renderColors(text *vga.Text, char vga.Character) (fg, bg vga.RGB) {
+	attr := char.Attributes()
+	fi, fok := char.ForegroundIndex()
+	bi, bok := char.BackgroundIndex()
+	if attr&vga.Reverse != 0 {
+		fi, fok, bi, bok = bi, bok, fi, fok
+	}
+	if fok && fi < 8 && attr&vga.Bold != 0 {
+		fi += 8
+	}
+	if bok && bi < 8 && attr&vga.Blink != 0 && text.DisableBlink {
+		bi += 8
+	}
+	fc, bc := text.CellColors(char)
+	if attr&vga.Reverse != 0 {
+		fc, bc = bc, fc
+	}
+	palette := text.ActivePalette()
+	if fok {
+		fc = palette[fi]
+	}
+	if bok {
+		bc = palette[bi]
+	}
+	return vga.ToRGB(fc), vga.ToRGB(bc)
+}
+
+func TestUnencodable(t *testing.T) {
+	text := vga.NewText(4, 1)
+	text.Buffer[0].SetCodePoint(TAB)
+	text.Buffer[1].SetCodePoint(SUB)
+	text.Buffer[2].SetCodePoint(0x01)
+	if n := Unencodable(text); n != 2 {
+		t.Fatalf("expected 2 unencodable cells, got %d", n)
+	}
+
+	var b bytes.Buffer
+	if err := Encode(&b, text, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(b.String(), "\t\x1a") {
+		t.Fatalf("expected control characters to be replaced, got %q", b.String())
+	}
+}
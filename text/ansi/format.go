@@ -0,0 +1,21 @@
+package ansi
+
+import (
+	"io"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/format/sauce"
+)
+
+func init() {
+	parser.Register(parser.Format{
+		Name:       "ansi",
+		Extensions: []string{".ans", ".asc", ".diz", ".nfo"},
+		SAUCE: []parser.SAUCEType{
+			{DataType: sauce.Character, FileType: sauce.ASCII},
+			{DataType: sauce.Character, FileType: sauce.ANSi},
+			{DataType: sauce.Character, FileType: sauce.ANSiMation},
+		},
+		Decode: func(r io.Reader) (parser.Parser, error) { return Decode(r) },
+	})
+}
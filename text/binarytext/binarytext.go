@@ -3,6 +3,7 @@ package binarytext
 import (
 	"errors"
 	"image"
+	"image/color"
 	"io"
 	"io/ioutil"
 	"strings"
@@ -72,6 +73,7 @@ func Decode(r io.Reader) (*BinaryText, error) {
 	}
 	bin.AutoExpand = true
 	bin.DisableBlink = record.Flags.NonBlink
+	bin.NineDot = record.NineDot()
 	if err = bin.decode(b); err != nil {
 		return nil, err
 	}
@@ -80,8 +82,8 @@ func Decode(r io.Reader) (*BinaryText, error) {
 
 func (bin *BinaryText) decode(b []byte) (err error) {
 	for i, l := 0, len(b); i < l; i += 2 {
-		bin.SetBackgroundColor(vga.Palette[(b[i+1]&0xf0)>>4])
-		bin.SetForegroundColor(vga.Palette[(b[i+1]&0x0f)>>0])
+		bin.SetBackgroundIndex(vga.AttributeIndex((b[i+1] & 0xf0) >> 4))
+		bin.SetForegroundIndex(vga.AttributeIndex((b[i+1] & 0x0f) >> 0))
 		bin.WriteCharacter(b[i])
 	}
 	return
@@ -98,8 +100,26 @@ func (bin *BinaryText) ImageBlink(blink bool) (image.Image, error) {
 	return bin.Text.Image(bin.Font, blink)
 }
 
+// VGAText returns the text buffer.
+func (bin *BinaryText) VGAText() *vga.Text { return bin.Text }
+
+// TextFont returns the font and its SAUCE font name.
+func (bin *BinaryText) TextFont() (*chargen.Font, string) {
+	if bin.Record.Info == "" {
+		return bin.Font, "IBM VGA"
+	}
+	return bin.Font, bin.Record.Info
+}
+
+// TextPalette returns the palette of the text buffer.
+func (bin *BinaryText) TextPalette() color.Palette { return bin.ActivePalette() }
+
+// SAUCERecord returns the SAUCE record.
+func (bin *BinaryText) SAUCERecord() *sauce.Record { return bin.Record }
+
 // Interface checks
 var (
-	_ parser.Parser = (*BinaryText)(nil)
-	_ parser.Image  = (*BinaryText)(nil)
+	_ parser.Parser     = (*BinaryText)(nil)
+	_ parser.Image      = (*BinaryText)(nil)
+	_ parser.TextBuffer = (*BinaryText)(nil)
 )
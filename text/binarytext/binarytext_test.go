@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
 )
 
 func TestDecode(t *testing.T) {
@@ -89,3 +90,26 @@ func TestDecodeError(t *testing.T) {
 		}
 	})
 }
+
+func TestDecodeColors(t *testing.T) {
+	b := bytes.NewBuffer([]byte{'A', 0x1e, 'B', 0x4a})
+	r := &sauce.Record{DataType: sauce.BinaryText, FileType: 1, Info: "IBM VGA"}
+	b.WriteByte(0x1a)
+	r.WriteTo(b)
+
+	bin, err := Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		FG, BG vga.RGB
+	}{
+		{vga.BrightYellow, vga.Blue},
+		{vga.BrightGreen, vga.Red},
+	} {
+		fg, bg := bin.CellColors(bin.Buffer[i])
+		if vga.ToRGB(fg) != test.FG || vga.ToRGB(bg) != test.BG {
+			t.Errorf("cell %d: expected %s on %s, got %v on %v", i, test.FG, test.BG, fg, bg)
+		}
+	}
+}
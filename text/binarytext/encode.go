@@ -0,0 +1,84 @@
+package binarytext
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+)
+
+// MaxWidth is the widest BinaryText the SAUCE record can describe.
+const MaxWidth = 0xff << 1
+
+/*
+Encode writes text as BinaryText, followed by a SAUCE record based on record,
+which may be nil. The SAUCE record carries the width of the buffer, iCE colors
+and letter spacing; the title, author, group, font name and aspect ratio are
+copied from record.
+
+Cells are written as character and VGA attribute pairs, see
+vga.Text.CellAttribute. BinaryText widths are a multiple of two, an odd width
+is padded with a blank column. Glyphs beyond the first 256 are written modulo
+256.
+*/
+func Encode(w io.Writer, text *vga.Text, record *sauce.Record) error {
+	var (
+		width = text.Width()
+		pad   = width & 1
+	)
+	if width+pad > MaxWidth {
+		return fmt.Errorf("binarytext: width %d exceeds %d", width, MaxWidth)
+	}
+
+	var (
+		b     = new(bytes.Buffer)
+		blank = text.CellAttribute(vga.BlankCharacter)
+	)
+	for y := 0; y < text.Height(); y++ {
+		for _, char := range text.Buffer[y*width : (y+1)*width] {
+			b.WriteByte(char.CodePoint())
+			b.WriteByte(text.CellAttribute(char))
+		}
+		if pad > 0 {
+			b.WriteByte(vga.BlankCharacter.CodePoint())
+			b.WriteByte(blank)
+		}
+	}
+
+	size := b.Len()
+	b.WriteByte(0x1a)
+	b.Write(Record(text, record, size).Bytes())
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// Record returns a SAUCE record for a BinaryText of size bytes, based on
+// record (may be nil).
+func Record(text *vga.Text, record *sauce.Record, size int) *sauce.Record {
+	out := new(sauce.Record)
+	if record != nil {
+		*out = *record
+	}
+	out.DataType = sauce.BinaryText
+	out.FileType = uint8((text.Width() + 1) >> 1)
+	out.FileSize = uint32(size)
+	out.TypeInfo = [4]uint16{}
+	out.Flags = new(sauce.ANSiFlags)
+	if record != nil && record.Flags != nil {
+		*out.Flags = *record.Flags
+	}
+	out.Flags.NonBlink = text.DisableBlink
+	if text.NineDot {
+		out.Flags.LetterSpacing = sauce.LetterSpacing9Pixel
+	} else if out.Flags.LetterSpacing == sauce.LetterSpacing9Pixel {
+		out.Flags.LetterSpacing = sauce.LetterSpacing8Pixel
+	}
+	out.RawFlags = out.Flags.Byte()
+	if out.Date.IsZero() {
+		out.Date = time.Now()
+	}
+	return out
+}
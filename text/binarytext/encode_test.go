@@ -0,0 +1,93 @@
+package binarytext
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+)
+
+func TestEncode(t *testing.T) {
+	text := vga.NewText(3, 1)
+	text.DisableBlink = true
+	text.NineDot = true
+	text.Buffer[0] = vga.MakeIndexedCharacter('A', 11, 4)
+	text.Buffer[1] = vga.MakeIndexedCharacter('B', 10, 1)
+
+	var b bytes.Buffer
+	if err := Encode(&b, text, &sauce.Record{Title: "test", Info: "IBM VGA50"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{'A', 0x1e, 'B', 0x4a, ' ', 0x07, ' ', 0x07}; !bytes.HasPrefix(b.Bytes(), want) {
+		t.Fatalf("expected % x, got % x", want, b.Bytes()[:len(want)])
+	}
+
+	record, err := sauce.ParseBytes(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.DataType != sauce.BinaryText || record.FileType != 2 || record.FileSize != 8 {
+		t.Fatalf("expected BinaryText of width 4 and size 8, got type %d/%d size %d", record.DataType, record.FileType, record.FileSize)
+	}
+	if record.Title != "test" || record.Info != "IBM VGA50" {
+		t.Fatalf("expected title and font name to be copied, got %q and %q", record.Title, record.Info)
+	}
+	if !record.Flags.NonBlink || record.Flags.LetterSpacing != sauce.LetterSpacing9Pixel {
+		t.Fatalf("expected iCE colors and 9 pixel letter spacing, got %+v", record.Flags)
+	}
+
+	if err = Encode(new(bytes.Buffer), vga.NewText(MaxWidth+1, 1), nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	names, err := filepath.Glob("testdata/*.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			f, err := os.Open(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			src, err := Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var b bytes.Buffer
+			if err = Encode(&b, src.Text, src.Record); err != nil {
+				t.Fatal(err)
+			}
+			dst, err := Decode(bytes.NewReader(b.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dst.Width() != src.Width() || dst.Height() != src.Height() {
+				t.Fatalf("expected size %dx%d, got %dx%d", src.Width(), src.Height(), dst.Width(), dst.Height())
+			}
+			if dst.DisableBlink != src.DisableBlink || dst.NineDot != src.NineDot {
+				t.Fatal("expected flags to be stable")
+			}
+			for i, want := range src.Buffer {
+				if got := dst.Buffer[i]; got != want {
+					t.Fatalf("cell %d: expected %q %s, got %q %s", i, want.Glyph(), want.Attributes(), got.Glyph(), got.Attributes())
+				}
+			}
+
+			var again bytes.Buffer
+			if err = Encode(&again, dst.Text, dst.Record); err != nil {
+				t.Fatal(err)
+			}
+			if n := len(again.Bytes()) - 128; !bytes.Equal(again.Bytes()[:n], b.Bytes()[:n]) {
+				t.Fatal("expected encoding to be stable")
+			}
+		})
+	}
+}
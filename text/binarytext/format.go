@@ -0,0 +1,17 @@
+package binarytext
+
+import (
+	"io"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/format/sauce"
+)
+
+func init() {
+	parser.Register(parser.Format{
+		Name:       "binarytext",
+		Extensions: []string{".bin"},
+		SAUCE:      []parser.SAUCEType{{DataType: sauce.BinaryText, FileType: parser.AnyFileType}},
+		Decode:     func(r io.Reader) (parser.Parser, error) { return Decode(r) },
+	})
+}
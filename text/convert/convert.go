@@ -0,0 +1,252 @@
+/*
+Package convert transcodes between the text mode formats, such as ANSi,
+BinaryText, XBin and TundraDraw.
+
+Any parser.TextBuffer can be converted to a Target. The font and palette are
+carried over if the target format supports them; for everything that can not
+be represented in the target format a warning is returned, and the encoder
+makes the best of it.
+*/
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+)
+
+// Errors.
+var (
+	ErrUnknownTarget = errors.New("convert: unknown target format")
+	ErrNotText       = errors.New("convert: not a text mode file")
+)
+
+// DefaultFont is the SAUCE name of the font targets use when they can not
+// carry the font of the source.
+const DefaultFont = "IBM VGA"
+
+// Feature of a target format.
+type Feature uint8
+
+// Features.
+const (
+	// FontName is a font selected by SAUCE font name.
+	FontName Feature = 1 << iota
+
+	// FontEmbed is an embedded font.
+	FontEmbed
+
+	// Font512 is an embedded font with 512 characters.
+	Font512
+
+	// Palette is a custom 16 color palette.
+	Palette
+
+	// TrueColor are 24-bit colors for each cell.
+	TrueColor
+)
+
+// Source is the text buffer to convert, with its font, palette and SAUCE
+// record.
+type Source struct {
+	// Text buffer.
+	Text *vga.Text
+
+	// Font of the text buffer.
+	Font *chargen.Font
+
+	// FontName is the SAUCE font name, empty if the font is embedded.
+	FontName string
+
+	// Palette of the text buffer.
+	Palette color.Palette
+
+	// Record is the SAUCE record (may be nil).
+	Record *sauce.Record
+}
+
+// NewSource returns the Source for a text buffer.
+func NewSource(buf parser.TextBuffer) *Source {
+	font, name := buf.TextFont()
+	return &Source{
+		Text:     buf.VGAText(),
+		Font:     font,
+		FontName: name,
+		Palette:  buf.TextPalette(),
+		Record:   buf.SAUCERecord(),
+	}
+}
+
+// SAUCE returns a copy of the SAUCE record, or an empty record if there is
+// none, for the target to fill in.
+func (src *Source) SAUCE() *sauce.Record {
+	if src.Record == nil {
+		return new(sauce.Record)
+	}
+	record := *src.Record
+	return &record
+}
+
+// Target is a format to convert to.
+type Target struct {
+	// Name of the format, as registered with the parser.
+	Name string
+
+	// Extension for file names, including the dot.
+	Extension string
+
+	// Features the format supports.
+	Features Feature
+
+	// Check returns warnings specific to the format (may be nil).
+	Check func(src *Source) []string
+
+	// Encode the source.
+	Encode func(w io.Writer, src *Source) error
+}
+
+// Targets returns the formats that can be converted to.
+func Targets() []Target {
+	list := make([]Target, len(targets))
+	copy(list, targets)
+	return list
+}
+
+// Lookup a target format by name.
+func Lookup(name string) (Target, error) {
+	for _, target := range targets {
+		if strings.EqualFold(target.Name, name) {
+			return target, nil
+		}
+	}
+	return Target{}, ErrUnknownTarget
+}
+
+// Convert the text buffer to the target format. The returned warnings list
+// everything that could not be carried over.
+func Convert(w io.Writer, p parser.Parser, target Target) (warnings []string, err error) {
+	buf, ok := p.(parser.TextBuffer)
+	if !ok {
+		return nil, ErrNotText
+	}
+
+	var (
+		src      = NewSource(buf)
+		features = target.Features
+		warnf    = func(format string, v ...interface{}) {
+			warnings = append(warnings, target.Name+": "+fmt.Sprintf(format, v...))
+		}
+	)
+
+	switch {
+	case src.FontName == "" && features&FontEmbed == 0:
+		warnf("embedded font is not supported, using %s", DefaultFont)
+		src.FontName = DefaultFont
+	case !isDefaultFont(src.FontName) && features&(FontName|FontEmbed) == 0:
+		warnf("font %q is not supported, using %s", src.FontName, DefaultFont)
+		src.FontName = DefaultFont
+	}
+	if n := countCells(src.Text, func(char vga.Character) bool { return char.Glyph() > 0xff }); n > 0 && features&Font512 == 0 {
+		warnf("%d cells use the second half of a 512 character font", n)
+	}
+
+	if !isDefaultPalette(src.Palette) && features&Palette == 0 {
+		if features&TrueColor == 0 {
+			warnf("custom palette is not supported, using the VGA palette")
+		} else {
+			src.Text = resolveColors(src.Text, src.Palette)
+		}
+		src.Palette = vga.Palette
+	}
+	if features&TrueColor == 0 {
+		palette := src.Palette
+		if len(palette) > 16 {
+			palette = palette[:16]
+		}
+		if n := countCells(src.Text, func(char vga.Character) bool { return !inPalette(char, palette) }); n > 0 {
+			warnf("%d cells use colors outside the 16 color palette, using the nearest colors", n)
+		}
+	}
+
+	if target.Check != nil {
+		for _, warning := range target.Check(src) {
+			warnf("%s", warning)
+		}
+	}
+	return warnings, target.Encode(w, src)
+}
+
+// isDefaultFont checks if name is one of the names of the default VGA font.
+func isDefaultFont(name string) bool {
+	switch name {
+	case "", DefaultFont, "IBM VGA 437":
+		return true
+	default:
+		return false
+	}
+}
+
+// isDefaultPalette checks if the palette matches the VGA palette.
+func isDefaultPalette(palette color.Palette) bool {
+	if len(palette) < 16 || len(palette) > len(vga.Palette) {
+		return false
+	}
+	for i, c := range palette {
+		if vga.ToRGB(c) != vga.ToRGB(vga.Palette[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// inPalette checks if the foreground and background colors of char are in the
+// palette.
+func inPalette(char vga.Character, palette color.Palette) bool {
+	if i, ok := char.ForegroundIndex(); !ok || int(i) >= len(palette) {
+		if vga.ColorIndex(char.ForegroundColor(), palette) < 0 {
+			return false
+		}
+	}
+	if i, ok := char.BackgroundIndex(); !ok || int(i) >= len(palette) {
+		if vga.ColorIndex(char.BackgroundColor(), palette) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// countCells counts the cells for which f returns true.
+func countCells(text *vga.Text, f func(vga.Character) bool) (n int) {
+	for _, char := range text.Buffer {
+		if f(char) {
+			n++
+		}
+	}
+	return
+}
+
+// resolveColors returns a copy of text with the palette indexes replaced by
+// their colors, for targets with 24-bit colors but no custom palette.
+func resolveColors(text *vga.Text, palette color.Palette) *vga.Text {
+	out := text.Copy(text.Bounds())
+	out.Palette = nil
+	out.DisableBlink = text.DisableBlink
+	out.NineDot = text.NineDot
+	for i, char := range out.Buffer {
+		if j, ok := char.ForegroundIndex(); ok && int(j) < len(palette) {
+			char.SetForegroundColor(palette[j])
+		}
+		if j, ok := char.BackgroundIndex(); ok && int(j) < len(palette) {
+			char.SetBackgroundColor(palette[j])
+		}
+		out.Buffer[i] = char
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package convert
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+	"github.com/textmodes/parser/text/ansi"
+)
+
+type testBuffer struct {
+	*vga.Text
+	font *chargen.Font
+	name string
+}
+
+func (buf testBuffer) VGAText() *vga.Text                     { return buf.Text }
+func (buf testBuffer) TextFont() (*chargen.Font, string)      { return buf.font, buf.name }
+func (buf testBuffer) TextPalette() color.Palette             { return buf.ActivePalette() }
+func (buf testBuffer) SAUCERecord() *sauce.Record             { return nil }
+func (buf testBuffer) withPalette(p color.Palette) testBuffer { buf.Palette = p; return buf }
+func (buf testBuffer) withFont(f *chargen.Font) testBuffer    { buf.font = f; return buf }
+
+func newTestBuffer(name string) testBuffer {
+	text := vga.NewText(4, 2)
+	for i := range text.Buffer {
+		text.Buffer[i] = vga.MakeIndexedCharacter('a'+uint8(i), uint8(i+1), 0)
+	}
+	return testBuffer{Text: text, name: name}
+}
+
+func TestConvert(t *testing.T) {
+	custom := make(color.Palette, 16)
+	copy(custom, vga.Palette)
+	custom[1] = vga.NewRGB(0x12, 0x34, 0x56)
+
+	glyphs := newTestBuffer(DefaultFont)
+	glyphs.Buffer[0].SetGlyph(0x141)
+
+	controls := newTestBuffer(DefaultFont)
+	controls.Buffer[0].SetCodePoint(ansi.ESC)
+
+	// Cell 0 uses the second 256 characters, cell 7 has a bright foreground
+	font512 := chargen.New(chargen.NewBytesMask(make([]byte, 512*16), chargen.MaskOptions{Size: image.Pt(8, 16)}))
+	glyphs512 := newTestBuffer("").withFont(font512)
+	glyphs512.Buffer[0].SetGlyph(0x141)
+
+	for _, test := range []struct {
+		Name     string
+		Buffer   parser.TextBuffer
+		Target   string
+		Warnings []string
+		Contains string
+	}{
+		{"default", newTestBuffer(DefaultFont), "ansi", nil, "\x1b[31ma"},
+		{"font name", newTestBuffer("IBM VGA50"), "ansi", nil, "IBM VGA50"},
+		{"embedded font", newTestBuffer(""), "ansi", []string{"ansi: embedded font is not supported, using IBM VGA"}, "IBM VGA"},
+		{"palette", newTestBuffer(DefaultFont).withPalette(custom), "ansi", nil, "\x1b[38;2;18;52;86ma"},
+		{"512 glyphs", glyphs, "ansi", []string{"ansi: 1 cells use the second half of a 512 character font"}, "A"},
+		{"controls", controls, "ansi", []string{"ansi: 1 cells with control characters are replaced by spaces"}, "\x1b[31m \x1b[32mb"},
+		{"xbin", newTestBuffer(DefaultFont).withPalette(custom), "xbin", nil, "XBIN\x1a"},
+		{"xbin font name", newTestBuffer("IBM VGA50"), "xbin", nil, "XBIN\x1a"},
+		{"xbin 512 glyphs", glyphs512, "xbin", []string{"xbin: 2 cells change foreground intensity in 512 character mode"}, "XBIN\x1a"},
+		{"binarytext", newTestBuffer("IBM VGA50"), "binarytext", nil, "a\x04b\x02"},
+		{"tundradraw", newTestBuffer(DefaultFont), "tundradraw", []string{"tundradraw: height 2 is padded to 25"}, "\x06a\x00\xaa\x00\x00\x00\x00\x00\x00\x02b"},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			target, err := Lookup(test.Target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			warnings, err := Convert(&b, test.Buffer, target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(test.Warnings, "\n") {
+				t.Fatalf("expected warnings %q, got %q", test.Warnings, warnings)
+			}
+			if !strings.Contains(b.String(), test.Contains) {
+				t.Fatalf("expected output to contain %q, got %q", test.Contains, b.String())
+			}
+		})
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	src, err := ansi.Decode(strings.NewReader("\x1b[1;33mhello\x1b[0m \x1b[44mworld\r\n\x1b[7mbye"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := Lookup("ANSi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	warnings, err := Convert(&b, src, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) > 0 {
+		t.Fatalf("expected no warnings, got %q", warnings)
+	}
+
+	dst, err := ansi.Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.Width() != src.Width() {
+		t.Fatalf("expected width %d, got %d", src.Width(), dst.Width())
+	}
+	for i, want := range src.Buffer[:2*src.Width()] {
+		if got := dst.Buffer[i]; got != want {
+			t.Fatalf("cell %d: expected %q %s, got %q %s", i, want.Glyph(), want.Attributes(), got.Glyph(), got.Attributes())
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	if _, err := Lookup("nope"); err != ErrUnknownTarget {
+		t.Fatalf("expected %v, got %v", ErrUnknownTarget, err)
+	}
+	target, _ := Lookup("ansi")
+	if _, err := Convert(new(bytes.Buffer), struct{}{}, target); err != ErrNotText {
+		t.Fatalf("expected %v, got %v", ErrNotText, err)
+	}
+}
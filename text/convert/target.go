@@ -0,0 +1,127 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/text/ansi"
+	"github.com/textmodes/parser/text/binarytext"
+	"github.com/textmodes/parser/text/tundradraw"
+	"github.com/textmodes/parser/text/xbin"
+)
+
+// targets are the formats that can be converted to.
+var targets = []Target{
+	{
+		Name:      "ansi",
+		Extension: ".ans",
+		Features:  FontName | TrueColor,
+		Check:     checkANSi,
+		Encode:    encodeANSi,
+	},
+	{
+		Name:      "xbin",
+		Extension: ".xb",
+		Features:  FontName | FontEmbed | Font512 | Palette,
+		Check:     checkXBin,
+		Encode:    encodeXBin,
+	},
+	{
+		Name:      "binarytext",
+		Extension: ".bin",
+		Features:  FontName,
+		Check:     checkBinaryText,
+		Encode:    encodeBinaryText,
+	},
+	{
+		Name:      "tundradraw",
+		Extension: ".tnd",
+		Features:  TrueColor,
+		Check:     checkTundraDraw,
+		Encode:    encodeTundraDraw,
+	},
+}
+
+func checkANSi(src *Source) (warnings []string) {
+	if n := ansi.Unencodable(src.Text); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d cells with control characters are replaced by spaces", n))
+	}
+	return
+}
+
+func encodeANSi(w io.Writer, src *Source) error {
+	record := src.SAUCE()
+	record.Info = src.FontName
+	return ansi.Encode(w, src.Text, record)
+}
+
+func checkXBin(src *Source) (warnings []string) {
+	font := xbinFont(src)
+	if font != nil && !embeddable(font) {
+		warnings = append(warnings, fmt.Sprintf("%dx%d font can not be embedded, using %s", font.Size.X, font.Size.Y, DefaultFont))
+	} else if font != nil && font.Mask.Characters() > 256 {
+		// The foreground intensity bit selects the second 256 characters
+		var n int
+		for _, char := range src.Text.Buffer {
+			if bright := src.Text.CellAttribute(char)&0x08 != 0; bright != (char.Glyph() > 0xff) {
+				n++
+			}
+		}
+		if n > 0 {
+			warnings = append(warnings, fmt.Sprintf("%d cells change foreground intensity in 512 character mode", n))
+		}
+	}
+	return
+}
+
+func encodeXBin(w io.Writer, src *Source) error {
+	font := xbinFont(src)
+	if font != nil && !embeddable(font) {
+		font = nil
+	}
+	return xbin.Encode(w, src.Text, font, src.Palette, src.SAUCE())
+}
+
+// xbinFont returns the font to embed in an XBin, which is the font loaded for
+// the SAUCE font name if the source has no font.
+func xbinFont(src *Source) *chargen.Font {
+	if src.Font != nil || src.FontName == "" {
+		return src.Font
+	}
+	font, err := sauce.Font(src.FontName)
+	if err != nil {
+		return nil
+	}
+	return font
+}
+
+// embeddable checks if an XBin can embed the font.
+func embeddable(font *chargen.Font) bool {
+	return font.Size.X <= 8 && font.Size.Y >= 1 && font.Size.Y <= 32
+}
+
+func checkBinaryText(src *Source) (warnings []string) {
+	if width := src.Text.Width(); width&1 == 1 {
+		warnings = append(warnings, fmt.Sprintf("odd width %d is padded to %d", width, width+1))
+	}
+	return
+}
+
+func encodeBinaryText(w io.Writer, src *Source) error {
+	record := src.SAUCE()
+	record.Info = src.FontName
+	return binarytext.Encode(w, src.Text, record)
+}
+
+func checkTundraDraw(src *Source) (warnings []string) {
+	if height := src.Text.Height(); height < 25 {
+		warnings = append(warnings, fmt.Sprintf("height %d is padded to 25", height))
+	}
+	return
+}
+
+func encodeTundraDraw(w io.Writer, src *Source) error {
+	return tundradraw.Encode(w, src.Text, src.SAUCE())
+}
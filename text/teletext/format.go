@@ -0,0 +1,44 @@
+package teletext
+
+import (
+	"io"
+
+	"github.com/textmodes/parser"
+)
+
+func init() {
+	parser.Register(parser.Format{
+		Name:       "ep1",
+		Extensions: []string{".ep1"},
+		Magic:      []string{ep1Prefix},
+		Decode:     func(r io.Reader) (parser.Parser, error) { return DecodeEP1(r) },
+	})
+	parser.Register(parser.Format{
+		Name:       "tti",
+		Extensions: []string{".tti", ".ttix"},
+		Match:      isTTI,
+		Decode:     func(r io.Reader) (parser.Parser, error) { return DecodeTTI(r) },
+	})
+	parser.Register(parser.Format{
+		Name:       "m7",
+		Extensions: []string{".m7"},
+		Decode:     func(r io.Reader) (parser.Parser, error) { return DecodeM7(r) },
+	})
+}
+
+// ttiCommands are the TTI commands recognised at the start of a file.
+var ttiCommands = []string{"DE", "DS", "PN", "PS", "CT", "RE", "SC", "OL", "FL", "PF", "MS", "RD", "SP"}
+
+// isTTI checks if the first line of a file is a known TTI command followed by
+// a comma.
+func isTTI(header []byte) bool {
+	if len(header) < 3 || header[2] != ',' {
+		return false
+	}
+	for _, command := range ttiCommands {
+		if string(header[:2]) == command {
+			return true
+		}
+	}
+	return false
+}
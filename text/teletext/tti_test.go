@@ -30,3 +30,21 @@ func TestDecodeTTI(t *testing.T) {
 		})
 	}
 }
+
+func TestIsTTI(t *testing.T) {
+	for _, test := range []struct {
+		Header string
+		Want   bool
+	}{
+		{"DE,Test page\r\n", true},
+		{"PN,10000\r\n", true},
+		{"SP,page.tti\r\n", true},
+		{"OK, here we go\r\n", false},
+		{"PN", false},
+		{"de,Test page\r\n", false},
+	} {
+		if got := isTTI([]byte(test.Header)); got != test.Want {
+			t.Errorf("%q: expected %t, got %t", test.Header, test.Want, got)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package tundradraw
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+	"github.com/textmodes/parser/text/ansi"
+)
+
+// positionSize is the size of a position opcode in bytes.
+const positionSize = 9
+
+// cellColors are the 24-bit colors of a cell.
+type cellColors struct {
+	fg, bg vga.RGB
+}
+
+// state of the decoder, the colors are not valid before the first cell.
+type state struct {
+	colors cellColors
+	valid  bool
+}
+
+// encoder writes opcodes while keeping track of the decoder state.
+type encoder struct {
+	bytes.Buffer
+	state
+}
+
+/*
+Encode writes text as 24-bit TundraDraw, followed by a SAUCE record based on
+record, which may be nil. The SAUCE record carries the size of the buffer and
+letter spacing; the title, author, group and date are copied from record.
+
+Cells are written with their colors as rendered: colors in the first 16
+colors of the palette follow bold, blink, reverse video and conceal as in
+vga.Text.CellAttribute, other colors are only swapped for reverse video. The
+other attributes are lost.
+
+Color opcodes are only written if the colors change, and runs of blank cells
+are skipped with a position opcode where that is smaller. TundraDraw buffers
+are at least 25 rows high, glyphs beyond the first 256 are written modulo 256.
+*/
+func Encode(w io.Writer, text *vga.Text, record *sauce.Record) error {
+	var (
+		e     = new(encoder)
+		n     = len(text.Buffer)
+		cells = make([]cellColors, n)
+		blank = resolve(text, vga.BlankCharacter)
+	)
+	for i, char := range text.Buffer {
+		cells[i] = resolve(text, char)
+	}
+	isBlank := func(i int) bool {
+		return text.Buffer[i].Glyph() == ' ' && cells[i] == blank
+	}
+
+	e.WriteString(tundraDrawID)
+	for i := 0; i < n; {
+		// The last cell is always written, so the buffer keeps its height
+		if isBlank(i) && i < n-1 {
+			j := i
+			for j < n-1 && isBlank(j) {
+				j++
+			}
+			if e.cost(text.Buffer[i:j], cells[i:j]) > positionSize {
+				e.writePosition(j%text.Width(), j/text.Width())
+				i = j
+				continue
+			}
+		}
+		e.writeCell(text.Buffer[i].CodePoint(), cells[i])
+		i++
+	}
+
+	size := e.Len()
+	e.WriteByte(ansi.SUB)
+	e.Write(Record(text, record, size).Bytes())
+	_, err := e.WriteTo(w)
+	return err
+}
+
+// Record returns a SAUCE record for a TundraDraw of size bytes, based on
+// record (may be nil).
+func Record(text *vga.Text, record *sauce.Record, size int) *sauce.Record {
+	out := new(sauce.Record)
+	if record != nil {
+		*out = *record
+	}
+	out.DataType = sauce.Character
+	out.FileType = sauce.TundraDraw
+	out.FileSize = uint32(size)
+	out.TypeInfo = [4]uint16{uint16(text.Width()), uint16(text.Height())}
+	out.Flags = &sauce.ANSiFlags{NonBlink: true}
+	if text.NineDot {
+		out.Flags.LetterSpacing = sauce.LetterSpacing9Pixel
+	}
+	out.RawFlags = out.Flags.Byte()
+	out.Info = ""
+	if out.Date.IsZero() {
+		out.Date = time.Now()
+	}
+	return out
+}
+
+// resolve the colors of a cell as rendered. Cells with colors in the first 16
+// colors of the palette follow the VGA attribute rules, other cells only swap
+// their colors for reverse video.
+func resolve(text *vga.Text, char vga.Character) cellColors {
+	palette := text.ActivePalette()
+	fg, bg := text.CellColors(char)
+	if len(palette) >= 16 {
+		palette = palette[:16]
+		if vga.ColorIndex(fg, palette) >= 0 && vga.ColorIndex(bg, palette) >= 0 {
+			attr := text.CellAttribute(char)
+			return cellColors{
+				fg: vga.ToRGB(palette[vga.AttributeIndex(attr&0x0f)]),
+				bg: vga.ToRGB(palette[vga.AttributeIndex(attr>>4)]),
+			}
+		}
+	}
+	if char.Attributes()&vga.Reverse == vga.Reverse {
+		fg, bg = bg, fg
+	}
+	return cellColors{fg: vga.ToRGB(fg), bg: vga.ToRGB(bg)}
+}
+
+// isOpcode checks if the decoder interprets a code point as an opcode.
+func isOpcode(cp uint8) bool {
+	switch cp {
+	case tundraDrawPos, tundraDrawForeground, tundraDrawBackground, tundraDrawColors, ansi.SUB:
+		return true
+	default:
+		return false
+	}
+}
+
+// opcode returns the opcode to write a cell, or 0 if the code point can be
+// written as is.
+func (s state) opcode(cp uint8, colors cellColors) (op uint8) {
+	if !s.valid || colors.fg != s.colors.fg {
+		op |= tundraDrawForeground
+	}
+	if !s.valid || colors.bg != s.colors.bg {
+		op |= tundraDrawBackground
+	}
+	if op == 0 && isOpcode(cp) {
+		// Opcodes are written as the character of a color opcode
+		op = tundraDrawForeground
+	}
+	return
+}
+
+// cost returns the number of bytes needed to write the cells, without
+// changing the state.
+func (s state) cost(chars []vga.Character, cells []cellColors) (n int) {
+	for i, char := range chars {
+		switch op := s.opcode(char.CodePoint(), cells[i]); op {
+		case 0:
+			n++
+		case tundraDrawColors:
+			n += 10
+		default:
+			n += 6
+		}
+		s.colors, s.valid = cells[i], true
+	}
+	return
+}
+
+// writeCell writes a code point with its colors.
+func (e *encoder) writeCell(cp uint8, colors cellColors) {
+	op := e.opcode(cp, colors)
+	if op != 0 {
+		e.WriteByte(op)
+	}
+	e.WriteByte(cp)
+	if op&tundraDrawForeground != 0 {
+		e.writeColor(colors.fg)
+	}
+	if op&tundraDrawBackground != 0 {
+		e.writeColor(colors.bg)
+	}
+	e.colors, e.valid = colors, true
+}
+
+// writeColor writes a color as 0x00RRGGBB.
+func (e *encoder) writeColor(c vga.RGB) {
+	e.Write([]byte{0x00, uint8(c >> 16), uint8(c >> 8), uint8(c)})
+}
+
+// writePosition moves the cursor to (x, y).
+func (e *encoder) writePosition(x, y int) {
+	var b [positionSize]byte
+	b[0] = tundraDrawPos
+	binary.BigEndian.PutUint32(b[1:], uint32(y))
+	binary.BigEndian.PutUint32(b[5:], uint32(x))
+	e.Write(b[:])
+}
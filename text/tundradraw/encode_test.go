@@ -0,0 +1,95 @@
+package tundradraw
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+)
+
+func TestEncode(t *testing.T) {
+	text := vga.NewText(20, 1)
+	text.Buffer[0] = vga.MakeCharacter('A', vga.NewRGB(1, 2, 3), vga.NewRGB(4, 5, 6))
+	text.Buffer[1] = vga.MakeCharacter('B', vga.NewRGB(1, 2, 3), vga.NewRGB(4, 5, 6))
+	text.Buffer[2] = vga.MakeCharacter(tundraDrawPos, vga.NewRGB(1, 2, 3), vga.NewRGB(4, 5, 6))
+	text.Buffer[3] = vga.MakeCharacter('C', vga.NewRGB(7, 8, 9), vga.NewRGB(4, 5, 6))
+	text.Buffer[19] = vga.MakeCharacter('D', vga.NewRGB(7, 8, 9), vga.NewRGB(4, 5, 6))
+
+	var b bytes.Buffer
+	if err := Encode(&b, text, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte(tundraDrawID)
+	want = append(want, tundraDrawColors, 'A', 0, 1, 2, 3, 0, 4, 5, 6)
+	want = append(want, 'B')
+	want = append(want, tundraDrawForeground, tundraDrawPos, 0, 1, 2, 3)
+	want = append(want, tundraDrawForeground, 'C', 0, 7, 8, 9)
+	want = append(want, tundraDrawPos, 0, 0, 0, 0, 0, 0, 0, 19)
+	want = append(want, 'D', 0x1a)
+	if !bytes.HasPrefix(b.Bytes(), want) {
+		t.Fatalf("expected % x, got % x", want, b.Bytes()[:len(want)])
+	}
+
+	record, err := sauce.ParseBytes(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.FileType != sauce.TundraDraw || record.TypeInfo[0] != 20 {
+		t.Fatalf("expected TundraDraw of width 20, got type %d width %d", record.FileType, record.TypeInfo[0])
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	names, err := filepath.Glob("testdata/*.tnd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			f, err := os.Open(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			src, err := Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var b bytes.Buffer
+			if err = Encode(&b, src.Text, src.Record); err != nil {
+				t.Fatal(err)
+			}
+			dst, err := Decode(bytes.NewReader(b.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dst.Width() != src.Width() || dst.Height() != src.Height() {
+				t.Fatalf("expected size %dx%d, got %dx%d", src.Width(), src.Height(), dst.Width(), dst.Height())
+			}
+			for i, want := range src.Buffer {
+				got := dst.Buffer[i]
+				if got.Glyph() != want.Glyph() {
+					t.Fatalf("cell %d: expected glyph %q, got %q", i, want.Glyph(), got.Glyph())
+				}
+				wfg, wbg := src.CellColors(want)
+				gfg, gbg := dst.CellColors(got)
+				if vga.ToRGB(gfg) != vga.ToRGB(wfg) || vga.ToRGB(gbg) != vga.ToRGB(wbg) {
+					t.Fatalf("cell %d: expected %s on %s, got %s on %s", i, vga.ToRGB(wfg), vga.ToRGB(wbg), vga.ToRGB(gfg), vga.ToRGB(gbg))
+				}
+			}
+
+			var again bytes.Buffer
+			if err = Encode(&again, dst.Text, dst.Record); err != nil {
+				t.Fatal(err)
+			}
+			if n := len(again.Bytes()) - 128; !bytes.Equal(again.Bytes()[:n], b.Bytes()[:n]) {
+				t.Fatal("expected encoding to be stable")
+			}
+			t.Logf("%d bytes encoded", b.Len())
+		})
+	}
+}
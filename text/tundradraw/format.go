@@ -0,0 +1,18 @@
+package tundradraw
+
+import (
+	"io"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/format/sauce"
+)
+
+func init() {
+	parser.Register(parser.Format{
+		Name:       "tundradraw",
+		Extensions: []string{".tnd"},
+		Magic:      []string{tundraDrawID},
+		SAUCE:      []parser.SAUCEType{{DataType: sauce.Character, FileType: sauce.TundraDraw}},
+		Decode:     func(r io.Reader) (parser.Parser, error) { return Decode(r) },
+	})
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"image"
 	"image/color"
 	"io"
 	"io/ioutil"
 
+	"github.com/textmodes/parser"
 	"github.com/textmodes/parser/chargen"
 	"github.com/textmodes/parser/format/sauce"
 	"github.com/textmodes/parser/format/vga"
@@ -47,10 +49,7 @@ func Decode(r io.Reader) (*TundraDraw, error) {
 		return nil, errors.New("text: not a 24-bit TundraDraw file")
 	}
 
-	var (
-		record *sauce.Record
-		bits   = 8
-	)
+	var record *sauce.Record
 	if record, err = sauce.ParseBytes(b); err != nil {
 		if err != sauce.ErrNoRecord {
 			return nil, err
@@ -61,8 +60,6 @@ func Decode(r io.Reader) (*TundraDraw, error) {
 	}
 	if record.Flags == nil {
 		record.Flags = &sauce.ANSiFlags{}
-	} else if record.Flags.LetterSpacing == sauce.LetterSpacing9Pixel {
-		bits = 9
 	}
 	// It appears PabloDraw happily ignores all flags :-|
 	record.Flags.NonBlink = true
@@ -87,13 +84,10 @@ func Decode(r io.Reader) (*TundraDraw, error) {
 	}
 	tnd.Text = vga.NewText(width, 25)
 	tnd.AutoExpand = true
+	tnd.NineDot = record.NineDot()
 	tnd.Palette = make(color.Palette, len(palette))
 	copy(tnd.Palette, palette)
 
-	if bits > 8 {
-		tnd.Font = chargen.New(chargen.AddColumn(tnd.Font.Mask))
-	}
-
 	if err = tnd.decode(b); err != nil {
 		return nil, err
 	}
@@ -171,6 +165,17 @@ func (tnd *TundraDraw) decodePosition(b []byte) (x, y uint, remain []byte, err e
 	return
 }
 
+// Image renders the TundraDraw to an image.
+func (tnd *TundraDraw) Image() (image.Image, error) {
+	return tnd.Text.Image(tnd.Font, true)
+}
+
+// ImageBlink renders the TundraDraw to an image; blink indicates if we're in
+// blink state.
+func (tnd *TundraDraw) ImageBlink(blink bool) (image.Image, error) {
+	return tnd.Text.Image(tnd.Font, blink)
+}
+
 var palette = color.Palette{
 	color.RGBA{0, 0, 0, 255},
 	color.RGBA{173, 0, 0, 255},
@@ -189,3 +194,22 @@ var palette = color.Palette{
 	color.RGBA{82, 255, 255, 255},
 	color.RGBA{255, 255, 255, 255},
 }
+
+// VGAText returns the text buffer.
+func (tnd *TundraDraw) VGAText() *vga.Text { return tnd.Text }
+
+// TextFont returns the font and its SAUCE font name.
+func (tnd *TundraDraw) TextFont() (*chargen.Font, string) { return tnd.Font, "IBM VGA" }
+
+// TextPalette returns the palette of the text buffer.
+func (tnd *TundraDraw) TextPalette() color.Palette { return tnd.ActivePalette() }
+
+// SAUCERecord returns the SAUCE record.
+func (tnd *TundraDraw) SAUCERecord() *sauce.Record { return tnd.Record }
+
+// Interface checks.
+var (
+	_ parser.Parser     = (*TundraDraw)(nil)
+	_ parser.Image      = (*TundraDraw)(nil)
+	_ parser.TextBuffer = (*TundraDraw)(nil)
+)
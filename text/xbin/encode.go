@@ -0,0 +1,296 @@
+package xbin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"time"
+
+	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+)
+
+// maxRun is the maximum number of cells in a compressed run.
+const maxRun = 64
+
+/*
+Encode writes text as XBin, followed by a SAUCE record based on record, which
+may be nil. The title, author, group and date are copied from record.
+
+The font is embedded if it is not the default IBM VGA font; fonts with more
+than 256 characters are embedded as 512 character fonts, where the foreground
+intensity bit selects the second 256 characters and the foreground intensity
+of the cells is lost. The palette is embedded if
+it differs from the VGA palette, if palette is nil the palette of text is
+used. Cells are written with their VGA attributes, see vga.Text.CellAttribute.
+
+The cells are compressed row by row, choosing the run lengths and compression
+types that give the smallest output; if compression does not make the buffer
+smaller, it is written uncompressed.
+*/
+func Encode(w io.Writer, text *vga.Text, font *chargen.Font, palette color.Palette, record *sauce.Record) error {
+	if text.Width() > 0xffff || text.Height() > 0xffff {
+		return fmt.Errorf("xbin: text size %dx%d is too large", text.Width(), text.Height())
+	}
+	if palette == nil {
+		palette = text.ActivePalette()
+	}
+
+	header := Header{
+		ID:       [4]byte{'X', 'B', 'I', 'N'},
+		EOFChar:  0x1a,
+		Width:    uint16(text.Width()),
+		Height:   uint16(text.Height()),
+		FontSize: 16,
+	}
+	if text.DisableBlink {
+		header.Flags |= FlagNonBlink
+	}
+
+	fontData, err := encodeFont(&header, font)
+	if err != nil {
+		return err
+	}
+	paletteData := encodePalette(&header, palette)
+
+	var (
+		cells = encodeCells(text, header.Flags&Flag512Chars == Flag512Chars)
+		data  = compress(cells, text.Width())
+	)
+	if len(data) < len(cells) {
+		header.Flags |= FlagCompression
+	} else {
+		data = cells
+	}
+
+	b := new(bytes.Buffer)
+	binary.Write(b, binary.LittleEndian, header)
+	b.Write(paletteData)
+	b.Write(fontData)
+	b.Write(data)
+
+	size := b.Len()
+	b.WriteByte(header.EOFChar)
+	b.Write(Record(text, record, size).Bytes())
+	_, err = b.WriteTo(w)
+	return err
+}
+
+// Record returns a SAUCE record for an XBin of size bytes, based on record
+// (may be nil). The record carries the size of the buffer and the letter
+// spacing, which the decoder reads from the record.
+func Record(text *vga.Text, record *sauce.Record, size int) *sauce.Record {
+	out := new(sauce.Record)
+	if record != nil {
+		*out = *record
+	}
+	out.DataType = sauce.XBIN
+	out.FileType = 0
+	out.FileSize = uint32(size)
+	out.TypeInfo = [4]uint16{uint16(text.Width()), uint16(text.Height())}
+	out.Flags = new(sauce.ANSiFlags)
+	if text.NineDot {
+		out.Flags.LetterSpacing = sauce.LetterSpacing9Pixel
+	}
+	out.RawFlags = out.Flags.Byte()
+	out.Info = ""
+	if out.Date.IsZero() {
+		out.Date = time.Now()
+	}
+	return out
+}
+
+// encodeFont returns the font bytes to embed and updates the header, or nil
+// if the font is the default font.
+func encodeFont(header *Header, font *chargen.Font) ([]byte, error) {
+	if font == nil {
+		return nil, nil
+	}
+	if font.Size.Y < 1 || font.Size.Y > 32 {
+		return nil, fmt.Errorf("xbin: font height %d is not supported", font.Size.Y)
+	}
+	data, err := font.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if isDefaultFont(data) {
+		return nil, nil
+	}
+
+	chars := 256
+	if int(font.Mask.Characters()) > chars {
+		chars <<= 1
+		header.Flags |= Flag512Chars
+	}
+	header.Flags |= FlagFont
+	header.FontSize = uint8(font.Size.Y)
+
+	embed := make([]byte, chars*font.Size.Y)
+	copy(embed, data)
+	return embed, nil
+}
+
+// isDefaultFont checks if the font bitmap matches the default font.
+func isDefaultFont(data []byte) bool {
+	font, err := sauce.Font("IBM VGA")
+	if err != nil {
+		return false
+	}
+	other, err := font.Bytes()
+	return err == nil && bytes.Equal(data, other)
+}
+
+// encodePalette returns the palette bytes to embed and updates the header, or
+// nil if the palette is the VGA palette.
+func encodePalette(header *Header, palette color.Palette) []byte {
+	if isDefaultPalette(palette) {
+		return nil
+	}
+	header.Flags |= FlagPalette
+
+	// The palette is stored in VGA attribute order, see decodePalette.
+	data := make([]byte, 0, 16*3)
+	for i := uint8(0); i < 16; i++ {
+		rgb := vga.Black
+		if j := vga.AttributeIndex(i); int(j) < len(palette) {
+			rgb = vga.ToRGB(palette[j])
+		}
+		data = append(data, uint8(rgb>>16)>>2, uint8(rgb>>8)>>2, uint8(rgb)>>2)
+	}
+	return data
+}
+
+// isDefaultPalette checks if the first 16 colors of the palette match the
+// VGA palette.
+func isDefaultPalette(palette color.Palette) bool {
+	if len(palette) < 16 {
+		return false
+	}
+	for i, c := range palette[:16] {
+		if vga.ToRGB(c) != vga.ToRGB(vga.Palette[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeCells returns the uncompressed character and attribute pairs.
+func encodeCells(text *vga.Text, chars512 bool) []byte {
+	data := make([]byte, 0, len(text.Buffer)*2)
+	for _, char := range text.Buffer {
+		var (
+			glyph = char.Glyph()
+			attr  = text.CellAttribute(char)
+		)
+		if chars512 {
+			// The foreground intensity bit selects the second 256 characters
+			attr = attr&^0x08 | uint8(glyph>>5)&0x08
+		}
+		data = append(data, uint8(glyph), attr)
+	}
+	return data
+}
+
+// compress the character and attribute pairs row by row, with runs that do
+// not cross rows.
+func compress(cells []byte, width int) []byte {
+	var (
+		data = make([]byte, 0, len(cells))
+		row  = width * 2
+	)
+	if row == 0 {
+		return data
+	}
+	for o := 0; o < len(cells); o += row {
+		data = compressRow(data, cells[o:o+row])
+	}
+	return data
+}
+
+// run is a compressed run of cells.
+type run struct {
+	kind, count int
+}
+
+// size of the run in bytes.
+func (r run) size() int {
+	switch r.kind {
+	case CompressChar, CompressAttr:
+		return 2 + r.count
+	case CompressBoth:
+		return 3
+	default:
+		return 1 + r.count*2
+	}
+}
+
+// compressRow appends the smallest encoding of a row of character and
+// attribute pairs to data.
+func compressRow(data, cells []byte) []byte {
+	var (
+		n    = len(cells) / 2
+		cost = make([]int, n+1) // cost[j] is the smallest size of cells[:j]
+		last = make([]run, n+1) // last[j] is the final run of that encoding
+	)
+	for j := 1; j <= n; j++ {
+		cost[j] = -1
+		var (
+			code, attr = cells[(j-1)*2], cells[(j-1)*2+1]
+			sameCode   = true
+			sameAttr   = true
+		)
+		for count := 1; count <= maxRun && count <= j; count++ {
+			i := j - count
+			sameCode = sameCode && cells[i*2] == code
+			sameAttr = sameAttr && cells[i*2+1] == attr
+
+			kind := CompressNone
+			switch {
+			case count == 1:
+			case sameCode && sameAttr:
+				kind = CompressBoth
+			case sameCode:
+				kind = CompressChar
+			case sameAttr:
+				kind = CompressAttr
+			}
+			r := run{kind, count}
+			if c := cost[i] + r.size(); cost[j] < 0 || c < cost[j] {
+				cost[j], last[j] = c, r
+			}
+		}
+	}
+
+	// Collect the runs from the end of the row
+	var runs []run
+	for j := n; j > 0; j -= last[j].count {
+		runs = append(runs, last[j])
+	}
+
+	o := 0
+	for k := len(runs) - 1; k >= 0; k-- {
+		r := runs[k]
+		data = append(data, uint8(r.kind)<<6|uint8(r.count-1))
+		switch r.kind {
+		case CompressNone:
+			data = append(data, cells[o:o+r.count*2]...)
+		case CompressChar:
+			data = append(data, cells[o])
+			for i := 0; i < r.count; i++ {
+				data = append(data, cells[o+i*2+1])
+			}
+		case CompressAttr:
+			data = append(data, cells[o+1])
+			for i := 0; i < r.count; i++ {
+				data = append(data, cells[o+i*2])
+			}
+		case CompressBoth:
+			data = append(data, cells[o], cells[o+1])
+		}
+		o += r.count * 2
+	}
+	return data
+}
@@ -0,0 +1,173 @@
+package xbin
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/textmodes/parser/chargen"
+	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
+)
+
+func TestCompressRow(t *testing.T) {
+	for _, test := range []struct {
+		Name  string
+		Cells []byte
+		Want  []byte
+	}{
+		{"single", []byte{'a', 7}, []byte{0x00, 'a', 7}},
+		{"both", []byte{'a', 7, 'a', 7, 'a', 7}, []byte{0xc2, 'a', 7}},
+		{"char", []byte{'a', 1, 'a', 2, 'a', 3}, []byte{0x42, 'a', 1, 2, 3}},
+		{"attr", []byte{'a', 7, 'b', 7, 'c', 7}, []byte{0x82, 7, 'a', 'b', 'c'}},
+		{"none", []byte{'a', 1, 'b', 2, 'c', 3}, []byte{0x02, 'a', 1, 'b', 2, 'c', 3}},
+		{"mixed", []byte{'a', 1, 'b', 2, 'x', 7, 'x', 7, 'x', 7}, []byte{0x01, 'a', 1, 'b', 2, 0xc2, 'x', 7}},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := compressRow(nil, test.Cells); !bytes.Equal(got, test.Want) {
+				t.Fatalf("expected % x, got % x", test.Want, got)
+			}
+		})
+	}
+
+	t.Run("long", func(t *testing.T) {
+		cells := bytes.Repeat([]byte{' ', 7}, 100)
+		want := []byte{0xc0 | (maxRun - 1), ' ', 7, 0xc0 | (100 - maxRun - 1), ' ', 7}
+		if got := compressRow(nil, cells); !bytes.Equal(got, want) {
+			t.Fatalf("expected % x, got % x", want, got)
+		}
+	})
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	palette := make(color.Palette, 16)
+	copy(palette, vga.Palette)
+	palette[1] = vga.NewRGB(0x10, 0x20, 0x30)
+
+	glyphs := make([]byte, 512*4)
+	for i := range glyphs {
+		glyphs[i] = uint8(i * 7)
+	}
+	font := chargen.New(chargen.NewBytesMask(glyphs, chargen.MaskOptions{Size: image.Pt(8, 4)}))
+
+	text := vga.NewText(80, 3)
+	text.DisableBlink = true
+	text.NineDot = true
+	for i := range text.Buffer {
+		char := vga.MakeIndexedCharacter('A'+uint8(i%26), uint8(i/8)%8, uint8(i/10)%16)
+		if i/5%2 == 0 {
+			char.SetGlyph(0x100 | uint16(i))
+		}
+		text.Buffer[i] = char
+	}
+
+	var b bytes.Buffer
+	if err := Encode(&b, text, font, palette, &sauce.Record{Title: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	x, err := Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := FlagPalette | FlagFont | FlagCompression | FlagNonBlink | Flag512Chars
+	if x.Header.Flags != Flag(want) {
+		t.Fatalf("expected flags %s, got %s", Flag(want), x.Header.Flags)
+	}
+	if x.Record == nil || x.Record.Title != "test" || x.Record.DataType != sauce.XBIN {
+		t.Fatalf("expected SAUCE record, got %+v", x.Record)
+	}
+	if x.Record.TypeInfo[0] != 80 || x.Record.TypeInfo[1] != 3 {
+		t.Fatalf("expected SAUCE size 80x3, got %dx%d", x.Record.TypeInfo[0], x.Record.TypeInfo[1])
+	}
+	if !x.DisableBlink {
+		t.Fatal("expected blink to be disabled")
+	}
+	if !x.NineDot {
+		t.Fatal("expected 9 pixel letter spacing")
+	}
+	if vga.ToRGB(x.Palette[1]) != vga.ToRGB(palette[1]) {
+		t.Fatalf("expected palette color %s, got %s", vga.ToRGB(palette[1]), vga.ToRGB(x.Palette[1]))
+	}
+	if data, _ := x.Font.Bytes(); !bytes.Equal(data, glyphs) {
+		t.Fatal("expected embedded font to match")
+	}
+	for i, want := range text.Buffer {
+		got := x.Buffer[i]
+		if got.Glyph() != want.Glyph() {
+			t.Fatalf("cell %d: expected glyph %#03x, got %#03x", i, want.Glyph(), got.Glyph())
+		}
+		if x.CellAttribute(got) != text.CellAttribute(want) {
+			t.Fatalf("cell %d: expected attribute %#02x, got %#02x", i, text.CellAttribute(want), x.CellAttribute(got))
+		}
+	}
+}
+
+func TestEncodeDefaults(t *testing.T) {
+	font, err := sauce.Font("IBM VGA")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// Cells that do not compress are written uncompressed
+	text := vga.NewText(4, 1)
+	for i := range text.Buffer {
+		text.Buffer[i] = vga.MakeIndexedCharacter('a'+uint8(i), uint8(i), 0)
+	}
+
+	var b bytes.Buffer
+	if err = Encode(&b, text, font, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	x, err := Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Header.Flags != 0 {
+		t.Fatalf("expected no flags, got %s", x.Header.Flags)
+	}
+	if size := 11 + len(text.Buffer)*2; int(x.Record.FileSize) != size {
+		t.Fatalf("expected file size %d, got %d", size, x.Record.FileSize)
+	}
+}
+
+func TestEncodeTestdata(t *testing.T) {
+	names, err := filepath.Glob("testdata/*.xb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			f, err := os.Open(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			src, err := Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var b bytes.Buffer
+			if err = Encode(&b, src.Text, src.Font, src.Palette, src.Record); err != nil {
+				t.Fatal(err)
+			}
+			dst, err := Decode(bytes.NewReader(b.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dst.Header.Width != src.Header.Width || dst.Header.Height != src.Header.Height {
+				t.Fatalf("expected size %dx%d, got %dx%d", src.Header.Width, src.Header.Height, dst.Header.Width, dst.Header.Height)
+			}
+			for i, want := range src.Buffer {
+				if got := dst.Buffer[i]; got != want {
+					t.Fatalf("cell %d: expected %#03x, got %#03x", i, want.Glyph(), got.Glyph())
+				}
+			}
+			t.Logf("%d bytes encoded", b.Len())
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package xbin
+
+import (
+	"io"
+
+	"github.com/textmodes/parser"
+	"github.com/textmodes/parser/format/sauce"
+)
+
+func init() {
+	parser.Register(parser.Format{
+		Name:       "xbin",
+		Extensions: []string{".xb"},
+		Magic:      []string{"XBIN\x1a"},
+		SAUCE:      []parser.SAUCEType{{DataType: sauce.XBIN, FileType: 0}},
+		Decode:     func(r io.Reader) (parser.Parser, error) { return Decode(r) },
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"image"
+	"image/color"
 	"io"
 	"io/ioutil"
 	"strings"
@@ -110,6 +111,8 @@ func Decode(r io.Reader) (*XBin, error) {
 	}
 
 	xbin.Text = vga.NewText(uint(xbin.Header.Width), uint(xbin.Header.Height))
+	xbin.Text.NineDot = record.NineDot()
+	xbin.Text.DisableBlink = xbin.Header.Flags&FlagNonBlink == FlagNonBlink
 
 	if b, err = xbin.decodePalette(b); err != nil {
 		return nil, err
@@ -133,12 +136,15 @@ func (xbin *XBin) decodePalette(b []byte) (remain []byte, err error) {
 	if len(b) < 16*3 {
 		return nil, fmt.Errorf("xbin: expected %d palette bytes, got %d", 16*3, len(b))
 	}
-	for i := 0; i < 16*3; i += 3 {
-		xbin.Text.Palette = append(xbin.Text.Palette, vga.NewRGB(
+	// The palette is in VGA attribute order, it's stored in the ANSI order of
+	// the palette indexes, see vga.AttributeIndex.
+	xbin.Text.Palette = make(color.Palette, 16)
+	for i := uint8(0); i < 16; i++ {
+		xbin.Text.Palette[vga.AttributeIndex(i)] = vga.NewRGB(
 			(b[0]&0x3f)<<2|(b[0]&0x3f)>>4,
 			(b[1]&0x3f)<<2|(b[1]&0x3f)>>4,
 			(b[2]&0x3f)<<2|(b[2]&0x3f)>>4,
-		))
+		)
 		b = b[3:]
 	}
 
@@ -148,7 +154,7 @@ func (xbin *XBin) decodePalette(b []byte) (remain []byte, err error) {
 func (xbin *XBin) decodeFont(b []byte) (remain []byte, err error) {
 	if xbin.Header.Flags&FlagFont == 0 {
 		xbin.Font, err = sauce.Font("IBM VGA")
-		return
+		return b, err
 	}
 
 	if xbin.Header.FontSize == 0 {
@@ -246,8 +252,15 @@ func (xbin *XBin) decodeUncompressed(b []byte) (err error) {
 }
 
 func (xbin *XBin) write(code, attr uint8) {
-	xbin.Text.SetForegroundColor(xbin.Text.Palette[(attr&0x0f)>>0])
-	xbin.Text.SetBackgroundColor(xbin.Text.Palette[(attr&0xf0)>>4])
+	if xbin.Header.Flags&Flag512Chars == Flag512Chars {
+		// The foreground intensity bit selects the second 256 characters
+		xbin.Text.SetForegroundIndex(vga.AttributeIndex((attr & 0x07) >> 0))
+		xbin.Text.SetBackgroundIndex(vga.AttributeIndex((attr & 0xf0) >> 4))
+		xbin.Text.WriteCodePoint(uint16(code) | uint16(attr&0x08)<<5)
+		return
+	}
+	xbin.Text.SetForegroundIndex(vga.AttributeIndex((attr & 0x0f) >> 0))
+	xbin.Text.SetBackgroundIndex(vga.AttributeIndex((attr & 0xf0) >> 4))
 	xbin.Text.WriteCharacter(code)
 }
 
@@ -261,8 +274,27 @@ func (xbin *XBin) ImageBlink(blink bool) (image.Image, error) {
 	return xbin.Text.Image(xbin.Font, blink)
 }
 
+// VGAText returns the text buffer.
+func (xbin *XBin) VGAText() *vga.Text { return xbin.Text }
+
+// TextFont returns the font and its SAUCE font name, which is empty if the
+// font is embedded.
+func (xbin *XBin) TextFont() (*chargen.Font, string) {
+	if xbin.Header.Flags&FlagFont == FlagFont {
+		return xbin.Font, ""
+	}
+	return xbin.Font, "IBM VGA"
+}
+
+// TextPalette returns the palette of the text buffer.
+func (xbin *XBin) TextPalette() color.Palette { return xbin.ActivePalette() }
+
+// SAUCERecord returns the SAUCE record (may be nil).
+func (xbin *XBin) SAUCERecord() *sauce.Record { return xbin.Record }
+
 // Interface checks.
 var (
-	_ parser.Parser = (*XBin)(nil)
-	_ parser.Image  = (*XBin)(nil)
+	_ parser.Parser     = (*XBin)(nil)
+	_ parser.Image      = (*XBin)(nil)
+	_ parser.TextBuffer = (*XBin)(nil)
 )
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/textmodes/parser/format/sauce"
+	"github.com/textmodes/parser/format/vga"
 )
 
 func TestDecode(t *testing.T) {
@@ -128,3 +129,86 @@ func TestDecodeError(t *testing.T) {
 		t.Fatal("expected error")
 	})
 }
+
+func TestDecode512Chars(t *testing.T) {
+	b := new(bytes.Buffer)
+	h := Header{
+		ID:       [4]byte{'X', 'B', 'I', 'N'},
+		EOFChar:  0x1a,
+		Width:    2,
+		Height:   1,
+		FontSize: 1,
+		Flags:    FlagFont | Flag512Chars,
+	}
+	binary.Write(b, binary.LittleEndian, h)
+
+	// The first 256 characters are blank, the second 256 are solid
+	font := make([]byte, 512)
+	for i := 256; i < 512; i++ {
+		font[i] = 0xff
+	}
+	b.Write(font)
+	b.Write([]byte{'A', 0x0f, 'A', 0x07})
+
+	x, err := Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []uint16{0x141, 0x041} {
+		char := x.Buffer[i]
+		if g := char.Glyph(); g != want {
+			t.Errorf("cell %d: expected glyph %#03x, got %#03x", i, want, g)
+		}
+		if c := vga.ToRGB(char.ForegroundColor()); c != vga.White {
+			t.Errorf("cell %d: expected foreground %s, got %s", i, vga.White, c)
+		}
+	}
+
+	im, err := x.Image()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		X    int
+		Want vga.RGB
+	}{
+		{0, vga.White}, // second 256 characters
+		{8, vga.Black}, // first 256 characters
+	} {
+		if c := vga.ToRGB(im.At(test.X, 0)); c != test.Want {
+			t.Errorf("pixel (%d, 0): expected %s, got %s", test.X, test.Want, c)
+		}
+	}
+}
+
+func TestDecodeColors(t *testing.T) {
+	b := new(bytes.Buffer)
+	h := Header{
+		ID:       [4]byte{'X', 'B', 'I', 'N'},
+		EOFChar:  0x1a,
+		Width:    2,
+		Height:   1,
+		FontSize: 16,
+	}
+	binary.Write(b, binary.LittleEndian, h)
+	b.Write([]byte{'A', 0x1e, 'B', 0x4a})
+	r := &sauce.Record{DataType: sauce.XBIN, FileType: 2 >> 1}
+	b.WriteByte(0x1a)
+	r.WriteTo(b)
+
+	x, err := Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		FG, BG vga.RGB
+	}{
+		{vga.BrightYellow, vga.Blue},
+		{vga.BrightGreen, vga.Red},
+	} {
+		fg, bg := x.CellColors(x.Buffer[i])
+		if vga.ToRGB(fg) != test.FG || vga.ToRGB(bg) != test.BG {
+			t.Errorf("cell %d: expected %s on %s, got %v on %v", i, test.FG, test.BG, fg, bg)
+		}
+	}
+}